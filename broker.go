@@ -1,9 +1,13 @@
 package influxdb
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/influxdb/influxdb/messaging"
@@ -13,15 +17,21 @@ import (
 type Broker struct {
 	*messaging.Broker
 
+	mu   sync.Mutex
 	done chan struct{}
 
-	// send CQ processing requests to the same data node
-	currentCQProcessingNode *messaging.Replica
+	// data node assigned to each CQ and when data nodes last failed a request
+	continuousQueryOwners map[ContinuousQueryID]uint64
+	failedDataNodes       map[uint64]time.Time
 
 	// variables to control when to trigger processing and when to timeout
 	TriggerInterval     time.Duration
 	TriggerTimeout      time.Duration
 	TriggerFailurePause time.Duration
+
+	// The client used to send requests to data nodes. Its timeout is
+	// replaced by DefaultDataNodeTimeout. Uses the default transport if nil.
+	HTTPClient *http.Client
 }
 
 const (
@@ -36,6 +46,10 @@ const (
 	// DefaultFailureSleep is how long the broker will sleep before trying the next data node in
 	// the cluster if the current data node failed to respond
 	DefaultFailureSleep = 100 * time.Millisecond
+
+	// DefaultFailedDataNodeRetryInterval is how long the broker will wait before assigning
+	// continuous queries to a data node that failed to respond.
+	DefaultFailedDataNodeRetryInterval = 10 * time.Second
 )
 
 // NewBroker returns a new instance of a Broker with default values.
@@ -44,6 +58,9 @@ func NewBroker() *Broker {
 		TriggerInterval:     5 * time.Second,
 		TriggerTimeout:      20 * time.Second,
 		TriggerFailurePause: 1 * time.Second,
+
+		continuousQueryOwners: make(map[ContinuousQueryID]uint64),
+		failedDataNodes:       make(map[uint64]time.Time),
 	}
 	b.Broker = messaging.NewBroker()
 	return b
@@ -68,7 +85,7 @@ func (b *Broker) continuousQueryLoop(done chan struct{}) {
 	for {
 		// Check if broker is currently leader.
 		if b.Broker.IsLeader() {
-			b.runContinuousQueries()
+			b.RunContinuousQueries()
 		}
 
 		// Sleep until either the broker is closed or we need to run continuous queries again
@@ -80,42 +97,155 @@ func (b *Broker) continuousQueryLoop(done chan struct{}) {
 	}
 }
 
-func (b *Broker) runContinuousQueries() {
-	next := 0
-	for {
-		// if the current node hasn't been set it's our first time or we're reset. move to the next one
-		if b.currentCQProcessingNode == nil {
-			dataNodes := b.Broker.Replicas()
-			if len(dataNodes) == 0 {
-				return // don't have any nodes to try, give it up
+// RunContinuousQueries assigns each continuous query in the cluster to a data node and
+// requests that every data node process the queries it owns. Queries are assigned using
+// rendezvous hashing across the data nodes that are currently responding so that only
+// the queries of a failed data node are reassigned to the remaining data nodes.
+func (b *Broker) RunContinuousQueries() {
+	nodes := b.availableDataNodes()
+	if len(nodes) == 0 {
+		return // don't have any nodes to try, give it up
+	}
+
+	// retrieve the continuous queries from the first data node that responds
+	var ids []ContinuousQueryID
+	for len(nodes) > 0 {
+		var err error
+		if ids, err = b.requestContinuousQueries(nodes[0]); err == nil {
+			break
+		}
+		log.Printf("broker cq: error hitting data node: %s: %s\n", nodes[0].URL, err.Error())
+		b.markDataNodeFailed(nodes[0])
+		nodes = nodes[1:]
+	}
+
+	pending := ids
+	owners := make(map[ContinuousQueryID]uint64, len(ids))
+	for len(pending) > 0 && len(nodes) > 0 {
+		// assign the pending queries across the available data nodes
+		assignments := make(map[*messaging.Replica][]ContinuousQueryID)
+		for _, id := range pending {
+			n := continuousQueryOwner(id, nodes)
+			assignments[n] = append(assignments[n], id)
+		}
+		pending = nil
+
+		// request processing and collect the queries of any node that fails
+		for _, n := range nodes {
+			if assignments[n] == nil {
+				continue
+			}
+
+			if err := b.requestContinuousQueryProcessing(n, assignments[n]); err != nil {
+				log.Printf("broker cq: error hitting data node: %s: %s\n", n.URL, err.Error())
+				b.markDataNodeFailed(n)
+				pending = append(pending, assignments[n]...)
+				continue
+			}
+
+			for _, id := range assignments[n] {
+				owners[id] = n.ID()
 			}
-			next = next % len(dataNodes)
-			b.currentCQProcessingNode = dataNodes[next]
-			next++
 		}
 
-		// if no error, we're all good
-		err := b.requestContinuousQueryProcessing()
-		if err == nil {
-			return
+		// let the loop reassign the failed node's queries to the remaining nodes
+		if len(pending) > 0 {
+			nodes = b.availableDataNodes()
+			<-time.After(DefaultFailureSleep)
 		}
-		log.Printf("broker cq: error hitting data node: %s: %s\n", b.currentCQProcessingNode.URL, err.Error())
+	}
+
+	b.mu.Lock()
+	b.continuousQueryOwners = owners
+	b.mu.Unlock()
+}
 
-		// reset and let the loop try the next data node in the cluster
-		b.currentCQProcessingNode = nil
-		<-time.After(DefaultFailureSleep)
+// ContinuousQueryOwners returns the data node ID that each continuous query was last assigned to.
+func (b *Broker) ContinuousQueryOwners() map[ContinuousQueryID]uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m := make(map[ContinuousQueryID]uint64, len(b.continuousQueryOwners))
+	for id, nodeID := range b.continuousQueryOwners {
+		m[id] = nodeID
+	}
+	return m
+}
+
+// availableDataNodes returns the data nodes that haven't recently failed a request.
+func (b *Broker) availableDataNodes() []*messaging.Replica {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var a []*messaging.Replica
+	for _, n := range b.Broker.Replicas() {
+		if t, ok := b.failedDataNodes[n.ID()]; ok && time.Since(t) < DefaultFailedDataNodeRetryInterval {
+			continue
+		}
+		a = append(a, n)
 	}
+	return a
 }
 
-func (b *Broker) requestContinuousQueryProcessing() error {
+// markDataNodeFailed excludes a data node from continuous query assignment until
+// the retry interval has passed.
+func (b *Broker) markDataNodeFailed(n *messaging.Replica) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failedDataNodes[n.ID()] = time.Now()
+}
+
+// dataNodeClient returns the client used to send requests to data nodes.
+func (b *Broker) dataNodeClient() *http.Client {
+	client := &http.Client{Timeout: DefaultDataNodeTimeout}
+	if b.HTTPClient != nil {
+		client.Transport = b.HTTPClient.Transport
+	}
+	return client
+}
+
+// requestContinuousQueries returns the identifiers of all continuous queries from a data node.
+func (b *Broker) requestContinuousQueries(n *messaging.Replica) ([]ContinuousQueryID, error) {
+	// Send request.
+	cqURL := copyURL(n.URL)
+	cqURL.Path = "/continuous_queries"
+	if cqURL.Scheme == "" {
+		cqURL.Scheme = "http"
+	}
+	client := b.dataNodeClient()
+	resp, err := client.Get(cqURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check if successful.
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request returned status %s", resp.Status)
+	}
+
+	var ids []ContinuousQueryID
+	if err := json.NewDecoder(resp.Body).Decode(&ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// requestContinuousQueryProcessing requests that a data node process a set of continuous queries.
+func (b *Broker) requestContinuousQueryProcessing(n *messaging.Replica, ids []ContinuousQueryID) error {
+	body, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
 	// Send request.
-	cqURL := copyURL(b.currentCQProcessingNode.URL)
+	cqURL := copyURL(n.URL)
 	cqURL.Path = "/process_continuous_queries"
-	cqURL.Scheme = "http"
-	client := &http.Client{
-		Timeout: DefaultDataNodeTimeout,
+	if cqURL.Scheme == "" {
+		cqURL.Scheme = "http"
 	}
-	resp, err := client.Post(cqURL.String(), "application/octet-stream", nil)
+	client := b.dataNodeClient()
+	resp, err := client.Post(cqURL.String(), "application/json", bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -128,3 +258,17 @@ func (b *Broker) requestContinuousQueryProcessing() error {
 
 	return nil
 }
+
+// continuousQueryOwner returns the data node with the highest hash for the continuous query.
+func continuousQueryOwner(id ContinuousQueryID, nodes []*messaging.Replica) *messaging.Replica {
+	var owner *messaging.Replica
+	var max uint64
+	for _, n := range nodes {
+		h := fnv.New64a()
+		_, _ = fmt.Fprintf(h, "%s/%d", id, n.ID())
+		if v := h.Sum64(); owner == nil || v > max {
+			owner, max = n, v
+		}
+	}
+	return owner
+}
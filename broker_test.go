@@ -1,10 +1,13 @@
 package influxdb_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -93,3 +96,118 @@ func (h *BrokerTestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 	}
 }
+
+// Ensure the broker assigns each continuous query to exactly one responding data node.
+func TestBroker_RunContinuousQueries_Distributed(t *testing.T) {
+	var ids []influxdb.ContinuousQueryID
+	for i := 0; i < 20; i++ {
+		ids = append(ids, influxdb.ContinuousQueryID{Database: "db", Name: fmt.Sprintf("cq%d", i)})
+	}
+
+	// Create two working data nodes and one that returns errors.
+	h1, h2 := &BrokerCQHandler{ids: ids}, &BrokerCQHandler{ids: ids}
+	h3 := &BrokerCQHandler{ids: ids, sendError: true}
+	s1, s2, s3 := httptest.NewServer(h1), httptest.NewServer(h2), httptest.NewServer(h3)
+	defer s1.Close()
+	defer s2.Close()
+	defer s3.Close()
+
+	b := influxdb.NewBroker()
+	f := tempfile()
+	defer os.Remove(f)
+	if err := b.Open(f, &url.URL{Host: "127.0.0.1:8080"}); err != nil {
+		t.Fatalf("error opening broker: %s", err)
+	}
+	if err := b.Initialize(); err != nil {
+		t.Fatalf("error initializing broker: %s", err)
+	}
+	defer b.Close()
+
+	b.Broker.CreateReplica(1, &url.URL{Host: s1.URL[7:]})
+	b.Broker.CreateReplica(2, &url.URL{Host: s2.URL[7:]})
+	b.Broker.CreateReplica(3, &url.URL{Host: s3.URL[7:]})
+
+	// Run twice. The failed node should be skipped on the second run.
+	b.RunContinuousQueries()
+	n := h3.requestCount()
+	b.RunContinuousQueries()
+	if h3.requestCount() != n {
+		t.Fatal("broker sent request to failed data node")
+	}
+
+	// Ensure every query was processed by exactly one working node per run.
+	owners := b.ContinuousQueryOwners()
+	if len(owners) != len(ids) {
+		t.Fatalf("unexpected owner count: %d", len(owners))
+	}
+	for _, id := range ids {
+		n1, n2 := h1.processedCount(id), h2.processedCount(id)
+		if n1+n2 != 2 {
+			t.Fatalf("query %s processed %d times", id, n1+n2)
+		} else if (owners[id] == 1 && n1 != 2) || (owners[id] == 2 && n2 != 2) || owners[id] == 3 {
+			t.Fatalf("query %s assigned to unexpected node: %d", id, owners[id])
+		}
+	}
+	if h1.processedTotal() == 0 || h2.processedTotal() == 0 {
+		t.Fatal("queries not distributed across data nodes")
+	}
+}
+
+// BrokerCQHandler represents a data node that lists and processes continuous queries.
+type BrokerCQHandler struct {
+	mu        sync.Mutex
+	ids       []influxdb.ContinuousQueryID
+	processed map[influxdb.ContinuousQueryID]int
+	requests  int
+	sendError bool
+}
+
+// ServeHTTP serves an HTTP request.
+func (h *BrokerCQHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requests++
+
+	if h.sendError {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/continuous_queries":
+		_ = json.NewEncoder(w).Encode(h.ids)
+	case "/process_continuous_queries":
+		var ids []influxdb.ContinuousQueryID
+		if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if h.processed == nil {
+			h.processed = make(map[influxdb.ContinuousQueryID]int)
+		}
+		for _, id := range ids {
+			h.processed[id]++
+		}
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *BrokerCQHandler) requestCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+func (h *BrokerCQHandler) processedCount(id influxdb.ContinuousQueryID) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.processed[id]
+}
+
+func (h *BrokerCQHandler) processedTotal() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.processed)
+}
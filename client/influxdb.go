@@ -2,9 +2,12 @@ package client
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"time"
@@ -17,6 +20,9 @@ type Config struct {
 	Username  string
 	Password  string
 	UserAgent string
+
+	// TLS configuration used for https URLs. The system's root CAs are used if nil.
+	TLS *tls.Config
 }
 
 type Client struct {
@@ -46,9 +52,44 @@ func NewClient(c Config) (*Client, error) {
 		httpClient: &http.Client{},
 		userAgent:  c.UserAgent,
 	}
+	if c.TLS != nil {
+		client.httpClient.Transport = &http.Transport{TLSClientConfig: c.TLS}
+	}
 	return &client, nil
 }
 
+// NewTLSConfig returns a TLS configuration that verifies the server against the
+// CA bundle at caPath and presents the client certificate at certPath and keyPath.
+// The system's root CAs are used if caPath is blank and no client certificate is
+// presented if certPath is blank. keyPath defaults to certPath.
+func NewTLSConfig(caPath, certPath, keyPath string) (*tls.Config, error) {
+	config := &tls.Config{}
+
+	if caPath != "" {
+		b, err := ioutil.ReadFile(caPath)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in CA bundle: %s", caPath)
+		}
+	}
+
+	if certPath != "" {
+		if keyPath == "" {
+			keyPath = certPath
+		}
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
 func (c *Client) Query(q Query) (*Results, error) {
 	u := c.url
 
@@ -1,6 +1,7 @@
 package client_test
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -43,6 +44,39 @@ func TestClient_Ping(t *testing.T) {
 	}
 }
 
+func TestClient_Ping_TLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Influxdb-Version", "x.x")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	config := client.Config{URL: *u, TLS: &tls.Config{InsecureSkipVerify: true}}
+	c, err := client.NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	}
+	_, version, err := c.Ping()
+	if err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	}
+	if version != "x.x" {
+		t.Fatalf("unexpected version.  expected %s,  actual %v", "x.x", version)
+	}
+}
+
+func TestNewTLSConfig_MissingCA(t *testing.T) {
+	if _, err := client.NewTLSConfig("/no/such/ca.pem", "", ""); err == nil {
+		t.Fatal("expected error")
+	}
+	if config, err := client.NewTLSConfig("", "", ""); err != nil {
+		t.Fatalf("unexpected error.  expected %v, actual %v", nil, err)
+	} else if config.RootCAs != nil || len(config.Certificates) != 0 {
+		t.Fatalf("unexpected config: %#v", config)
+	}
+}
+
 func TestClient_Query(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var data influxdb.Results
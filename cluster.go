@@ -0,0 +1,108 @@
+package influxdb
+
+import (
+	"crypto/hmac"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+)
+
+// ClusterSecretHeader is the header carrying the cluster's shared secret on
+// requests between nodes.
+const ClusterSecretHeader = "X-InfluxDB-Cluster-Secret"
+
+var (
+	// ErrClusterSecretInvalid is returned when a request between nodes has a missing or wrong secret.
+	ErrClusterSecretInvalid = errors.New("invalid cluster secret")
+
+	// ErrClusterCertificateRequired is returned when a request between nodes has no verified certificate.
+	ErrClusterCertificateRequired = errors.New("cluster certificate required")
+)
+
+// ClusterAuth verifies that requests to the broker and data node endpoints
+// come from other nodes in the cluster. A nil ClusterAuth allows every request.
+type ClusterAuth struct {
+	// Shared secret sent by every node. Not checked if blank.
+	Secret string
+
+	// Requires nodes to present a client certificate verified against CAs.
+	RequireCertificate bool
+
+	// CAs of the cluster. Kept separate from the CAs of API clients so that
+	// certificates issued to users can't authenticate as nodes.
+	CAs *x509.CertPool
+}
+
+// Authenticate returns an error if r was not sent by a node in the cluster.
+func (a *ClusterAuth) Authenticate(r *http.Request) error {
+	if a == nil {
+		return nil
+	}
+
+	if a.RequireCertificate && VerifyClientCertificate(r.TLS, a.CAs) == nil {
+		return ErrClusterCertificateRequired
+	}
+	if a.Secret != "" && !hmac.Equal([]byte(r.Header.Get(ClusterSecretHeader)), []byte(a.Secret)) {
+		return ErrClusterSecretInvalid
+	}
+	return nil
+}
+
+// VerifyClientCertificate returns the client certificate of a connection if it was
+// issued by one of the roots. Returns nil if there is no certificate or it can't be
+// verified. The same listener may verify certificates from other CAs, so handlers
+// check the roots they trust themselves.
+func VerifyClientCertificate(cs *tls.ConnectionState, roots *x509.CertPool) *x509.Certificate {
+	if cs == nil || roots == nil || len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	cert := cs.PeerCertificates[0]
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil
+	}
+	return cert
+}
+
+// NewClusterClient returns an HTTP client for requests to other nodes in the
+// cluster. The client sends the shared secret with every request, if set, and
+// uses tlsConfig for https URLs.
+func NewClusterClient(secret string, tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Transport: &clusterTransport{
+			secret:    secret,
+			transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+	}
+}
+
+// clusterTransport adds the cluster's shared secret to requests.
+type clusterTransport struct {
+	secret    string
+	transport http.RoundTripper
+}
+
+// RoundTrip sends the request with the secret header set.
+func (t *clusterTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.secret == "" {
+		return t.transport.RoundTrip(r)
+	}
+
+	// Round trippers must not modify the request so the secret is set on a copy.
+	other := *r
+	other.Header = make(http.Header, len(r.Header)+1)
+	for k, v := range r.Header {
+		other.Header[k] = v
+	}
+	other.Header.Set(ClusterSecretHeader, t.secret)
+	return t.transport.RoundTrip(&other)
+}
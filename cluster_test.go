@@ -0,0 +1,128 @@
+package influxdb_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+// Ensure requests between nodes must carry the shared secret.
+func TestClusterAuth_Authenticate_Secret(t *testing.T) {
+	a := &influxdb.ClusterAuth{Secret: "foo"}
+
+	r, _ := http.NewRequest("GET", "/raft/heartbeat", nil)
+	if err := a.Authenticate(r); err != influxdb.ErrClusterSecretInvalid {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.Header.Set(influxdb.ClusterSecretHeader, "bar")
+	if err := a.Authenticate(r); err != influxdb.ErrClusterSecretInvalid {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.Header.Set(influxdb.ClusterSecretHeader, "foo")
+	if err := a.Authenticate(r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure requests between nodes must have a client certificate issued by the cluster's CA, if required.
+func TestClusterAuth_Authenticate_Certificate(t *testing.T) {
+	ca, caKey := MustCreateCertificate("cluster-ca", nil, nil)
+	otherCA, otherCAKey := MustCreateCertificate("api-ca", nil, nil)
+	a := &influxdb.ClusterAuth{RequireCertificate: true, CAs: x509.NewCertPool()}
+	a.CAs.AddCert(ca)
+
+	r, _ := http.NewRequest("GET", "/raft/heartbeat", nil)
+	if err := a.Authenticate(r); err != influxdb.ErrClusterCertificateRequired {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Certificates from other CAs are rejected even if the listener verified them.
+	cert, _ := MustCreateCertificate("node1", otherCA, otherCAKey)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert, otherCA}}}
+	if err := a.Authenticate(r); err != influxdb.ErrClusterCertificateRequired {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cert, _ = MustCreateCertificate("node1", ca, caKey)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if err := a.Authenticate(r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a nil cluster auth allows every request.
+func TestClusterAuth_Authenticate_Nil(t *testing.T) {
+	var a *influxdb.ClusterAuth
+	r, _ := http.NewRequest("GET", "/raft/heartbeat", nil)
+	if err := a.Authenticate(r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the cluster client sends the shared secret with every request.
+func TestNewClusterClient(t *testing.T) {
+	var secret string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret = r.Header.Get(influxdb.ClusterSecretHeader)
+	}))
+	defer s.Close()
+
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	resp, err := influxdb.NewClusterClient("foo", nil).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if secret != "foo" {
+		t.Fatalf("unexpected secret: %q", secret)
+	} else if req.Header.Get(influxdb.ClusterSecretHeader) != "" {
+		t.Fatal("request modified")
+	}
+}
+
+// MustCreateCertificate returns a client certificate and key for name issued by
+// parent. The certificate is a self-signed CA if parent is nil. Panic on error.
+func MustCreateCertificate(name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	b, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		panic(err.Error())
+	}
+	cert, err := x509.ParseCertificate(b)
+	if err != nil {
+		panic(err.Error())
+	}
+	return cert, key
+}
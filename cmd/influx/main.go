@@ -40,6 +40,11 @@ type CommandLine struct {
 	Version  string
 	Pretty   bool   // controls pretty print for json
 	Format   string // controls the output format.  Valid values are json, csv, or column
+
+	SSL        bool   // connect using https
+	CACert     string // CA bundle used to verify the server
+	ClientCert string // client certificate presented to the server
+	ClientKey  string // key of the client certificate
 }
 
 func main() {
@@ -52,6 +57,10 @@ func main() {
 	fs.StringVar(&c.Password, "password", c.Password, `password to connect to the server.  Leaving blank will prompt for password (--password="")`)
 	fs.StringVar(&c.Database, "database", c.Database, "database to connect to the server.")
 	fs.StringVar(&c.Format, "output", default_format, "format specifies the format of the server responses:  json, csv, or column")
+	fs.BoolVar(&c.SSL, "ssl", false, "use https for requests.")
+	fs.StringVar(&c.CACert, "ca-cert", "", "CA bundle used to verify the server's certificate. Defaults to the system's CAs.")
+	fs.StringVar(&c.ClientCert, "client-cert", "", "client certificate to authenticate with over https.")
+	fs.StringVar(&c.ClientKey, "client-key", "", "key of the client certificate. Defaults to the client certificate file.")
 	fs.Parse(os.Args[1:])
 
 	var promptForPassword bool
@@ -180,6 +189,9 @@ func (c *CommandLine) connect(cmd string) {
 	u := url.URL{
 		Scheme: "http",
 	}
+	if c.SSL {
+		u.Scheme = "https"
+	}
 	if c.Port > 0 {
 		u.Host = fmt.Sprintf("%s:%d", c.Host, c.Port)
 	} else {
@@ -188,13 +200,21 @@ func (c *CommandLine) connect(cmd string) {
 	if c.Username != "" {
 		u.User = url.UserPassword(c.Username, c.Password)
 	}
-	cl, err := client.NewClient(
-		client.Config{
-			URL:       u,
-			Username:  c.Username,
-			Password:  c.Password,
-			UserAgent: "InfluxDBShell/" + version,
-		})
+	config := client.Config{
+		URL:       u,
+		Username:  c.Username,
+		Password:  c.Password,
+		UserAgent: "InfluxDBShell/" + version,
+	}
+	if c.SSL {
+		tlsConfig, err := client.NewTLSConfig(c.CACert, c.ClientCert, c.ClientKey)
+		if err != nil {
+			fmt.Printf("Could not load TLS configuration: %s\n", err)
+			return
+		}
+		config.TLS = tlsConfig
+	}
+	cl, err := client.NewClient(config)
 	if err != nil {
 		fmt.Printf("Could not create client %s", err)
 		return
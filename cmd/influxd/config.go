@@ -1,7 +1,10 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
 	"net/url"
@@ -13,6 +16,7 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/influxdb/influxdb"
 	"github.com/influxdb/influxdb/collectd"
 	"github.com/influxdb/influxdb/graphite"
 )
@@ -54,7 +58,9 @@ type Config struct {
 	} `toml:"initialization"`
 
 	Authentication struct {
-		Enabled bool `toml:"enabled"`
+		Enabled              bool   `toml:"enabled"`
+		SharedSecret         string `toml:"shared-secret"`
+		AllowRemoteBootstrap bool   `toml:"allow-remote-bootstrap"`
 	} `toml:"authentication"`
 
 	Admin struct {
@@ -64,9 +70,29 @@ type Config struct {
 
 	HTTPAPI struct {
 		Port        int      `toml:"port"`
-		SSLPort     int      `toml:"ssl-port"`
-		SSLCertPath string   `toml:"ssl-cert"`
 		ReadTimeout Duration `toml:"read-timeout"`
+
+		// Serves the API over HTTPS on a separate port. Plain HTTP stays
+		// enabled on the data port since nodes also use it to reach each other.
+		SSLPort     int    `toml:"ssl-port"`
+		SSLCertPath string `toml:"ssl-cert"`
+
+		// Key of the SSL certificate. Defaults to the certificate file.
+		SSLKeyPath string `toml:"ssl-key"`
+
+		// CA bundle used to verify client certificates. A verified client
+		// certificate authenticates the user named by its common name.
+		SSLClientCAPath      string `toml:"ssl-client-ca"`
+		SSLRequireClientCert bool   `toml:"ssl-require-client-cert"`
+
+		// Cross-origin requests allowed from browsers. Empty methods and
+		// headers allow the defaults. Any origin is allowed unless set, and
+		// an empty list of origins disables CORS.
+		CORSAllowedOrigins   []string `toml:"cors-allowed-origins"`
+		CORSAllowedMethods   []string `toml:"cors-allowed-methods"`
+		CORSAllowedHeaders   []string `toml:"cors-allowed-headers"`
+		CORSAllowCredentials bool     `toml:"cors-allow-credentials"`
+		CORSQueryOnly        bool     `toml:"cors-query-only"`
 	} `toml:"api"`
 
 	Graphites []Graphite `toml:"graphite"`
@@ -89,15 +115,56 @@ type Config struct {
 		Port                  int      `toml:"port"`
 		RetentionCheckEnabled bool     `toml:"retention-check-enabled"`
 		RetentionCheckPeriod  Duration `toml:"retention-check-period"`
+
+		// Series cardinality limits for each database. Zero means unlimited.
+		MaxSeriesPerDatabase int `toml:"max-series-per-database"`
+		MaxValuesPerTag      int `toml:"max-values-per-tag"`
+
+		// Maximum number of bytes of shard data kept for each database. Zero means unlimited.
+		// Only supported on a single data node.
+		MaxDatabaseSize int64 `toml:"max-database-size"`
+
+		// Limits that override the defaults for individual databases.
+		DatabaseLimits map[string]DatabaseLimits `toml:"database-limits"`
 	} `toml:"data"`
 
+	// Rate limits applied to each user and each database. Zero means unlimited.
+	RateLimits struct {
+		Users     RateLimits `toml:"users"`
+		Databases RateLimits `toml:"databases"`
+
+		// Rate limits that override the defaults for individual users and databases.
+		UserOverrides     map[string]RateLimits `toml:"user-overrides"`
+		DatabaseOverrides map[string]RateLimits `toml:"database-overrides"`
+	} `toml:"rate-limits"`
+
 	Cluster struct {
 		Dir string `toml:"dir"`
+
+		// Secret sent with every request between nodes. Requests to the raft,
+		// messaging and data node endpoints without it are rejected.
+		SharedSecret string `toml:"shared-secret"`
+
+		// Certificate and key used to serve the broker and data ports over TLS.
+		// Nodes contact each other with https if set. The key defaults to the
+		// certificate file.
+		SSLCertPath string `toml:"ssl-cert"`
+		SSLKeyPath  string `toml:"ssl-key"`
+
+		// CA bundle used to verify the certificates of other nodes. Defaults
+		// to the system's CAs.
+		SSLCAPath string `toml:"ssl-ca"`
+
+		// Requires nodes to present their certificate as a client certificate
+		// when calling the raft, messaging and data node endpoints. The
+		// certificates are verified against ssl-ca, which must be set.
+		SSLClientAuth bool `toml:"ssl-client-auth"`
 	} `toml:"cluster"`
 
 	Logging struct {
 		File              string `toml:"file"`
 		WriteTraceEnabled bool   `toml:"write-tracing"`
+		AuditFile         string `toml:"audit-file"`
 	} `toml:"logging"`
 
 	ContinuousQuery struct {
@@ -128,11 +195,69 @@ type Config struct {
 		// than 10m will get computed 10 times for each interval.
 		ComputeNoMoreThan Duration `toml:"compute-no-more-than"`
 
+		// MaxCatchUpWindow sets how far back a CQ will go to compute intervals it missed since its last
+		// completed interval, for example while the cluster was down. Missed intervals are computed one at a
+		// time, oldest first. Set to zero to disable catching up.
+		MaxCatchUpWindow Duration `toml:"max-catch-up-window"`
+
+		// BackfillDelay is how long to wait between intervals when running BACKFILL CONTINUOUS QUERY.
+		// Raise it to reduce the load a large backfill puts on the cluster.
+		BackfillDelay Duration `toml:"backfill-delay"`
+
 		// If this flag is set to true, both the brokers and data nodes should ignore any CQ processing.
 		Disable bool `toml:"disable"`
 	} `toml:"continuous_queries"`
 }
 
+// DatabaseSizeLimited returns true if the size of any database is limited.
+func (c *Config) DatabaseSizeLimited() bool {
+	if c.Data.MaxDatabaseSize > 0 {
+		return true
+	}
+	for _, l := range c.Data.DatabaseLimits {
+		if l.MaxSize > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DatabaseLimits represents the series cardinality and size limits of a single database.
+type DatabaseLimits struct {
+	MaxSeries       int   `toml:"max-series"`
+	MaxValuesPerTag int   `toml:"max-values-per-tag"`
+	MaxSize         int64 `toml:"max-size"`
+}
+
+// RateLimits represents the rate limits of a single user or database.
+type RateLimits struct {
+	PointsPerSecond      int `toml:"points-per-second"`
+	RequestsPerSecond    int `toml:"requests-per-second"`
+	MaxConcurrentQueries int `toml:"max-concurrent-queries"`
+}
+
+// NewRateLimiter returns a rate limiter enforcing the configured limits.
+func (c *Config) NewRateLimiter() *influxdb.RateLimiter {
+	l := influxdb.NewRateLimiter()
+	l.UserLimits = c.RateLimits.Users.limits()
+	l.DatabaseLimits = c.RateLimits.Databases.limits()
+	for name, r := range c.RateLimits.UserOverrides {
+		l.UserOverrides[name] = r.limits()
+	}
+	for name, r := range c.RateLimits.DatabaseOverrides {
+		l.DatabaseOverrides[name] = r.limits()
+	}
+	return l
+}
+
+func (r RateLimits) limits() influxdb.RateLimits {
+	return influxdb.RateLimits{
+		PointsPerSecond:      r.PointsPerSecond,
+		RequestsPerSecond:    r.RequestsPerSecond,
+		MaxConcurrentQueries: r.MaxConcurrentQueries,
+	}
+}
+
 // NewConfig returns an instance of Config with reasonable defaults.
 func NewConfig() *Config {
 	u, _ := user.Current()
@@ -147,10 +272,13 @@ func NewConfig() *Config {
 	c.Data.RetentionCheckPeriod = Duration(10 * time.Minute)
 	c.Admin.Enabled = true
 	c.Admin.Port = 8083
+	c.HTTPAPI.CORSAllowedOrigins = []string{"*"}
 	c.ContinuousQuery.RecomputePreviousN = 2
 	c.ContinuousQuery.RecomputeNoOlderThan = Duration(10 * time.Minute)
 	c.ContinuousQuery.ComputeRunsPerInterval = 10
 	c.ContinuousQuery.ComputeNoMoreThan = Duration(2 * time.Minute)
+	c.ContinuousQuery.MaxCatchUpWindow = Duration(24 * time.Hour)
+	c.ContinuousQuery.BackfillDelay = Duration(10 * time.Millisecond)
 	c.ContinuousQuery.Disable = false
 	c.ReportingDisabled = false
 
@@ -174,6 +302,130 @@ func (c *Config) DataAddr() string {
 	return net.JoinHostPort(c.BindAddress, strconv.Itoa(c.Data.Port))
 }
 
+// APIAddrSSL returns the TCP binding address for the HTTPS API.
+func (c *Config) APIAddrSSL() string {
+	return net.JoinHostPort(c.BindAddress, strconv.Itoa(c.HTTPAPI.SSLPort))
+}
+
+// APITLSConfig returns the TLS configuration for the HTTPS API.
+// Returns nil if HTTPS is disabled.
+func (c *Config) APITLSConfig() (*tls.Config, error) {
+	if c.HTTPAPI.SSLCertPath == "" {
+		return nil, nil
+	} else if c.HTTPAPI.SSLPort <= 0 {
+		return nil, fmt.Errorf("api ssl-cert requires ssl-port")
+	}
+
+	keyPath := c.HTTPAPI.SSLKeyPath
+	if keyPath == "" {
+		keyPath = c.HTTPAPI.SSLCertPath
+	}
+	cert, err := tls.LoadX509KeyPair(c.HTTPAPI.SSLCertPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	// Verify client certificates against the CA bundle, if one is set.
+	if c.HTTPAPI.SSLClientCAPath != "" {
+		if config.ClientCAs, err = loadCertPool(c.HTTPAPI.SSLClientCAPath); err != nil {
+			return nil, err
+		}
+
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if c.HTTPAPI.SSLRequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return config, nil
+}
+
+// clusterScheme returns the URL scheme nodes use to contact each other.
+func (c *Config) clusterScheme() string {
+	if c.ClusterTLSEnabled() {
+		return "https"
+	}
+	return "http"
+}
+
+// ClusterTLSEnabled returns true if nodes communicate over TLS.
+func (c *Config) ClusterTLSEnabled() bool {
+	return c.Cluster.SSLCertPath != ""
+}
+
+// ClusterTLSConfig returns the TLS configuration used both to serve the broker
+// and data ports and to contact other nodes. Returns nil if TLS is disabled.
+func (c *Config) ClusterTLSConfig() (*tls.Config, error) {
+	if !c.ClusterTLSEnabled() {
+		if c.Cluster.SSLClientAuth {
+			return nil, fmt.Errorf("cluster ssl-client-auth requires ssl-cert")
+		}
+		return nil, nil
+	}
+
+	// Node certificates must be verified against the cluster's own CAs, otherwise
+	// any certificate issued by a public CA would be accepted.
+	if c.Cluster.SSLClientAuth && c.Cluster.SSLCAPath == "" {
+		return nil, fmt.Errorf("cluster ssl-client-auth requires ssl-ca")
+	}
+
+	keyPath := c.Cluster.SSLKeyPath
+	if keyPath == "" {
+		keyPath = c.Cluster.SSLCertPath
+	}
+	cert, err := tls.LoadX509KeyPair(c.Cluster.SSLCertPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if c.Cluster.SSLCAPath != "" {
+		if config.RootCAs, err = loadCertPool(c.Cluster.SSLCAPath); err != nil {
+			return nil, err
+		}
+		config.ClientCAs = config.RootCAs
+	}
+
+	// The data port is shared with API clients, so certificates are only
+	// verified here and required for the cluster endpoints by ClusterAuth.
+	if c.Cluster.SSLClientAuth {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return config, nil
+}
+
+// ClusterAuth returns the verification of requests between nodes. Node certificates
+// are verified against the CAs of tlsConfig, as returned by ClusterTLSConfig.
+// Returns nil if neither a shared secret nor client certificates are required.
+func (c *Config) ClusterAuth(tlsConfig *tls.Config) *influxdb.ClusterAuth {
+	if c.Cluster.SharedSecret == "" && !c.Cluster.SSLClientAuth {
+		return nil
+	}
+	a := &influxdb.ClusterAuth{
+		Secret:             c.Cluster.SharedSecret,
+		RequireCertificate: c.Cluster.SSLClientAuth,
+	}
+	if tlsConfig != nil {
+		a.CAs = tlsConfig.ClientCAs
+	}
+	return a
+}
+
+// loadCertPool returns a pool of the certificates in a PEM encoded CA bundle.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in CA bundle: %s", path)
+	}
+	return pool, nil
+}
+
 // DataAddrUDP returns the UDP address for the series listener.
 func (c *Config) DataAddrUDP() string {
 	return net.JoinHostPort(c.UDP.BindAddress, strconv.Itoa(c.UDP.Port))
@@ -182,7 +434,7 @@ func (c *Config) DataAddrUDP() string {
 // DataURL returns the URL required to contact the data server.
 func (c *Config) DataURL() *url.URL {
 	return &url.URL{
-		Scheme: "http",
+		Scheme: c.clusterScheme(),
 		Host:   net.JoinHostPort(c.Hostname, strconv.Itoa(c.Data.Port)),
 	}
 }
@@ -195,7 +447,7 @@ func (c *Config) BrokerAddr() string {
 // BrokerURL returns the URL required to contact the Broker server.
 func (c *Config) BrokerURL() *url.URL {
 	return &url.URL{
-		Scheme: "http",
+		Scheme: c.clusterScheme(),
 		Host:   net.JoinHostPort(c.Hostname, strconv.Itoa(c.Broker.Port)),
 	}
 }
@@ -48,10 +48,16 @@ func TestParseConfig(t *testing.T) {
 
 	if c.Logging.File != "influxdb.log" {
 		t.Fatalf("logging file mismatch: %v", c.Logging.File)
+	} else if c.Logging.AuditFile != "audit.log" {
+		t.Fatalf("audit file mismatch: %v", c.Logging.AuditFile)
 	}
 
 	if !c.Authentication.Enabled {
 		t.Fatalf("authentication enabled mismatch: %v", c.Authentication.Enabled)
+	} else if c.Authentication.SharedSecret != "secret" {
+		t.Fatalf("authentication shared secret mismatch: %v", c.Authentication.SharedSecret)
+	} else if !c.Authentication.AllowRemoteBootstrap {
+		t.Fatalf("authentication allow remote bootstrap mismatch: %v", c.Authentication.AllowRemoteBootstrap)
 	}
 
 	if c.UDP.Enabled {
@@ -66,10 +72,56 @@ func TestParseConfig(t *testing.T) {
 		t.Fatalf("admin port mismatch: %v", c.Admin.Port)
 	}
 
+	if !reflect.DeepEqual(c.HTTPAPI.CORSAllowedOrigins, []string{"https://grafana.example.com"}) {
+		t.Fatalf("api cors allowed origins mismatch: %v", c.HTTPAPI.CORSAllowedOrigins)
+	} else if !c.HTTPAPI.CORSQueryOnly {
+		t.Fatalf("api cors query only mismatch: %v", c.HTTPAPI.CORSQueryOnly)
+	}
+
+	if c.Cluster.SharedSecret != "cluster-secret" {
+		t.Fatalf("cluster shared secret mismatch: %v", c.Cluster.SharedSecret)
+	} else if c.Cluster.SSLCAPath != "../cluster-ca.pem" {
+		t.Fatalf("cluster ssl ca mismatch: %v", c.Cluster.SSLCAPath)
+	} else if !c.Cluster.SSLClientAuth {
+		t.Fatalf("cluster ssl client auth mismatch: %v", c.Cluster.SSLClientAuth)
+	} else if a := c.ClusterAuth(nil); a == nil || a.Secret != "cluster-secret" || !a.RequireCertificate {
+		t.Fatalf("cluster auth mismatch: %+v", a)
+	}
+
+	if c.HTTPAPI.SSLClientCAPath != "../ca.pem" {
+		t.Fatalf("api ssl client ca mismatch: %v", c.HTTPAPI.SSLClientCAPath)
+	} else if !c.HTTPAPI.SSLRequireClientCert {
+		t.Fatalf("api ssl require client cert mismatch: %v", c.HTTPAPI.SSLRequireClientCert)
+	}
+
 	if c.ContinuousQuery.Disable == true {
 		t.Fatalf("continuous query disable mismatch: %v", c.ContinuousQuery.Disable)
 	}
 
+	if time.Duration(c.ContinuousQuery.MaxCatchUpWindow) != 6*time.Hour {
+		t.Fatalf("continuous query max catch-up window mismatch: %v", c.ContinuousQuery.MaxCatchUpWindow)
+	}
+
+	if c.Data.MaxSeriesPerDatabase != 1000000 {
+		t.Fatalf("data max series per database mismatch: %v", c.Data.MaxSeriesPerDatabase)
+	} else if c.Data.MaxValuesPerTag != 100000 {
+		t.Fatalf("data max values per tag mismatch: %v", c.Data.MaxValuesPerTag)
+	} else if c.Data.MaxDatabaseSize != 1073741824 {
+		t.Fatalf("data max database size mismatch: %v", c.Data.MaxDatabaseSize)
+	} else if l := c.Data.DatabaseLimits["telegraf"]; l.MaxSeries != 5000 || l.MaxValuesPerTag != 0 || l.MaxSize != 1048576 {
+		t.Fatalf("data database limits mismatch: %+v", c.Data.DatabaseLimits)
+	} else if !c.DatabaseSizeLimited() {
+		t.Fatalf("expected database size to be limited")
+	}
+
+	if c.RateLimits.Users.PointsPerSecond != 10000 {
+		t.Fatalf("user points per second mismatch: %v", c.RateLimits.Users.PointsPerSecond)
+	} else if c.RateLimits.Databases.MaxConcurrentQueries != 20 {
+		t.Fatalf("database max concurrent queries mismatch: %v", c.RateLimits.Databases.MaxConcurrentQueries)
+	} else if l := c.RateLimits.UserOverrides["john"]; l.RequestsPerSecond != 5 || l.PointsPerSecond != 0 {
+		t.Fatalf("user rate limit overrides mismatch: %+v", c.RateLimits.UserOverrides)
+	}
+
 	if c.Data.Port != main.DefaultBrokerPort {
 		t.Fatalf("data port mismatch: %v", c.Data.Port)
 	}
@@ -171,10 +223,13 @@ join-urls = "http://127.0.0.1:8086"
 # Control authentication
 [authentication]
 enabled = true
+shared-secret = "secret"
+allow-remote-bootstrap = true
 
 [logging]
 file   = "influxdb.log"
 write-tracing = true
+audit-file = "audit.log"
 
 # Configure the admin server
 [admin]
@@ -185,11 +240,15 @@ port = 8083
 [api]
 ssl-port = 8087    # Ssl support is enabled if you set a port and cert
 ssl-cert = "../cert.pem"
+ssl-client-ca = "../ca.pem"
+ssl-require-client-cert = true
 
 # connections will timeout after this amount of time. Ensures that clients that misbehave
 # and keep alive connections they don't use won't end up connection a million times.
 # However, if a request is taking longer than this to complete, could be a problem.
 read-timeout = "5s"
+cors-allowed-origins = ["https://grafana.example.com"]
+cors-query-only = true
 
 [input_plugins]
 
@@ -238,14 +297,84 @@ dir  = "/tmp/influxdb/development/broker"
 dir = "/tmp/influxdb/development/db"
 retention-check-enabled = true
 retention-check-period = "5m"
+max-series-per-database = 1000000
+max-values-per-tag = 100000
+max-database-size = 1073741824
+
+[data.database-limits.telegraf]
+max-series = 5000
+max-size = 1048576
+
+[rate-limits.users]
+points-per-second = 10000
+
+[rate-limits.databases]
+max-concurrent-queries = 20
+
+[rate-limits.user-overrides.john]
+requests-per-second = 5
 
 [continuous_queries]
 disable = false
+max-catch-up-window = "6h"
 
 [cluster]
 dir = "/tmp/influxdb/development/cluster"
+shared-secret = "cluster-secret"
+ssl-ca = "../cluster-ca.pem"
+ssl-client-auth = true
 `
 
+// Ensure CORS allows any origin by default and can be disabled with an empty list.
+func TestParseConfig_CORSAllowedOrigins(t *testing.T) {
+	if c, err := main.ParseConfig(``); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(c.HTTPAPI.CORSAllowedOrigins, []string{"*"}) {
+		t.Fatalf("unexpected default origins: %v", c.HTTPAPI.CORSAllowedOrigins)
+	}
+
+	if c, err := main.ParseConfig("[api]\ncors-allowed-origins = []\n"); err != nil {
+		t.Fatal(err)
+	} else if len(c.HTTPAPI.CORSAllowedOrigins) != 0 {
+		t.Fatalf("unexpected origins: %v", c.HTTPAPI.CORSAllowedOrigins)
+	}
+}
+
+// Ensure the API certificate can't be set without a port to serve HTTPS on.
+func TestConfig_APITLSConfig_RequiresPort(t *testing.T) {
+	if c, err := main.ParseConfig(``); err != nil {
+		t.Fatal(err)
+	} else if tlsConfig, err := c.APITLSConfig(); err != nil || tlsConfig != nil {
+		t.Fatalf("unexpected config: %v, %v", tlsConfig, err)
+	}
+
+	c, err := main.ParseConfig(`
+[api]
+ssl-cert = "/no/such/cert.pem"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.APITLSConfig(); err == nil || err.Error() != "api ssl-cert requires ssl-port" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure node certificates can't be required without the cluster's CA.
+func TestConfig_ClusterTLSConfig_ClientAuthRequiresCA(t *testing.T) {
+	c, err := main.ParseConfig(`
+[cluster]
+ssl-cert = "/no/such/cert.pem"
+ssl-client-auth = true
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ClusterTLSConfig(); err == nil || err.Error() != "cluster ssl-client-auth requires ssl-ca" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestCollectd_ConnectionString(t *testing.T) {
 	var tests = []struct {
 		name             string
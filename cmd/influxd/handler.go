@@ -3,6 +3,8 @@ package main
 import (
 	"net/http"
 	"strings"
+
+	"github.com/influxdb/influxdb"
 )
 
 // Handler represents an HTTP handler for InfluxDB node.
@@ -10,6 +12,10 @@ import (
 type Handler struct {
 	brokerHandler http.Handler
 	serverHandler http.Handler
+
+	// Verifies that raft and messaging requests come from other nodes
+	// in the cluster. Every request is allowed if nil.
+	clusterAuth *influxdb.ClusterAuth
 }
 
 // NewHandler returns a new instance of Handler.
@@ -29,6 +35,17 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// Report the error in the header read by each package's client.
+		if err := h.clusterAuth.Authenticate(r); err != nil {
+			if strings.HasPrefix(r.URL.Path, "/raft") {
+				w.Header().Set("X-Raft-Error", err.Error())
+			} else {
+				w.Header().Set("X-Broker-Error", err.Error())
+			}
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
 		h.brokerHandler.ServeHTTP(w, r)
 		return
 	}
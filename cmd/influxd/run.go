@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -20,6 +21,7 @@ import (
 	"github.com/influxdb/influxdb/graphite"
 	"github.com/influxdb/influxdb/httpd"
 	"github.com/influxdb/influxdb/messaging"
+	"github.com/influxdb/influxdb/raft"
 	"github.com/influxdb/influxdb/udp"
 )
 
@@ -50,18 +52,29 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 		joinURLs = parseURLs(join)
 	}
 
+	// Secure the traffic between nodes, if configured.
+	clusterTLS, err := config.ClusterTLSConfig()
+	if err != nil {
+		log.Fatalf("unable to load cluster certificate: %s", err)
+	}
+	clusterClient := influxdb.NewClusterClient(config.Cluster.SharedSecret, clusterTLS)
+	clusterAuth := config.ClusterAuth(clusterTLS)
+
 	// Open broker, initialize or join as necessary.
-	b := openBroker(config.BrokerDir(), config.BrokerURL(), initBroker, joinURLs, logWriter)
+	b := openBroker(config.BrokerDir(), config.BrokerURL(), initBroker, joinURLs, clusterClient, logWriter)
 
 	// Start the broker handler.
 	var h *Handler
 	if b != nil {
-		h = &Handler{brokerHandler: messaging.NewHandler(b.Broker)}
+		h = &Handler{brokerHandler: messaging.NewHandler(b.Broker), clusterAuth: clusterAuth}
 		// We want to make sure we are spun up before we exit this function, so we manually listen and serve
 		listener, err := net.Listen("tcp", config.BrokerAddr())
 		if err != nil {
 			log.Fatal(err)
 		}
+		if clusterTLS != nil {
+			listener = tls.NewListener(listener, clusterTLS)
+		}
 		go func() { log.Fatal(http.Serve(listener, h)) }()
 		log.Printf("broker listening on %s", config.BrokerAddr())
 
@@ -74,7 +87,7 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 	}
 
 	// Open server, initialize or join as necessary.
-	s := openServer(config, b, initServer, initBroker, configExists, joinURLs, logWriter)
+	s := openServer(config, b, initServer, initBroker, configExists, joinURLs, clusterClient, logWriter)
 	s.SetAuthenticationEnabled(config.Authentication.Enabled)
 
 	// Enable retention policy enforcement if requested.
@@ -91,6 +104,38 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 		sh := httpd.NewHandler(s, config.Authentication.Enabled, version)
 		sh.SetLogOutput(logWriter)
 		sh.WriteTrace = config.Logging.WriteTraceEnabled
+		sh.SharedSecret = config.Authentication.SharedSecret
+		sh.AllowRemoteBootstrap = config.Authentication.AllowRemoteBootstrap
+		sh.CORS = httpd.CORSPolicy{
+			AllowedOrigins:   config.HTTPAPI.CORSAllowedOrigins,
+			AllowedMethods:   config.HTTPAPI.CORSAllowedMethods,
+			AllowedHeaders:   config.HTTPAPI.CORSAllowedHeaders,
+			AllowCredentials: config.HTTPAPI.CORSAllowCredentials,
+			QueryOnly:        config.HTTPAPI.CORSQueryOnly,
+		}
+
+		// Open the audit log, if one is configured.
+		if config.Logging.AuditFile != "" {
+			f, err := os.OpenFile(config.Logging.AuditFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+			if err != nil {
+				log.Fatalf("unable to open audit log: %s", err)
+			}
+			sh.AuditLog = httpd.NewAuditLogger(f)
+		}
+
+		// Share the server's rate limiter between the API and the inputs.
+		limiter := s.RateLimiter
+		sh.RateLimiter = limiter
+		sh.ClusterAuth = clusterAuth
+
+		// Load the API certificate, if HTTPS is configured. Client certificates only
+		// authenticate users if they are verified against the API's CAs.
+		apiTLS, err := config.APITLSConfig()
+		if err != nil {
+			log.Fatalf("unable to load API certificate: %s", err)
+		} else if apiTLS != nil {
+			sh.ClientCAs = apiTLS.ClientCAs
+		}
 
 		if h != nil && config.BrokerAddr() == config.DataAddr() {
 			h.serverHandler = sh
@@ -100,10 +145,27 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 			if err != nil {
 				log.Fatal(err)
 			}
+			if clusterTLS != nil {
+				listener = tls.NewListener(listener, clusterTLS)
+			}
 			go func() { log.Fatal(http.Serve(listener, sh)) }()
 		}
 		log.Printf("data node #%d listening on %s", s.ID(), config.DataAddr())
 
+		// Serve the API over HTTPS on its own port, if configured. The data port keeps
+		// serving plain HTTP, or the cluster's TLS, since other nodes connect to it.
+		if apiTLS != nil {
+			listener, err := tls.Listen("tcp", config.APIAddrSSL(), apiTLS)
+			if err != nil {
+				log.Fatal(err)
+			}
+			go func() { log.Fatal(http.Serve(listener, sh)) }()
+			log.Printf("data node #%d listening on %s (https)", s.ID(), config.APIAddrSSL())
+			if clusterTLS == nil {
+				log.Printf("data node #%d still serves the API over plain http on %s", s.ID(), config.DataAddr())
+			}
+		}
+
 		// Start the admin interface on the default port
 		if config.Admin.Enabled {
 			port := fmt.Sprintf(":%d", config.Admin.Port)
@@ -117,6 +179,7 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 			c := config.Collectd
 			cs := collectd.NewServer(s, c.TypesDB)
 			cs.Database = c.Database
+			cs.RateLimiter = limiter
 			err := collectd.ListenAndServe(cs, c.ConnectionString(config.BindAddress))
 			if err != nil {
 				log.Printf("failed to start collectd Server: %v\n", err.Error())
@@ -127,6 +190,7 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 		if config.UDP.Enabled {
 			log.Printf("Starting UDP listener on %s", config.DataAddrUDP())
 			u := udp.NewUDPServer(s)
+			u.RateLimiter = limiter
 			if err := u.ListenAndServe(config.DataAddrUDP()); err != nil {
 				log.Printf("Failed to start UDP listener on %s: %s", config.DataAddrUDP(), err)
 			}
@@ -148,6 +212,7 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 			if strings.ToLower(c.Protocol) == "tcp" {
 				g := graphite.NewTCPServer(parser, s)
 				g.Database = c.Database
+				g.RateLimiter = limiter
 				err := g.ListenAndServe(c.ConnectionString(config.BindAddress))
 				if err != nil {
 					log.Printf("failed to start TCP Graphite Server: %v\n", err.Error())
@@ -155,6 +220,7 @@ func Run(config *Config, join, version string, logWriter *os.File) (*messaging.B
 			} else if strings.ToLower(c.Protocol) == "udp" {
 				g := graphite.NewUDPServer(parser, s)
 				g.Database = c.Database
+				g.RateLimiter = limiter
 				err := g.ListenAndServe(c.ConnectionString(config.BindAddress))
 				if err != nil {
 					log.Printf("failed to start UDP Graphite Server: %v\n", err.Error())
@@ -218,10 +284,12 @@ func parseConfig(path, hostname string) *Config {
 }
 
 // creates and initializes a broker.
-func openBroker(path string, u *url.URL, initializing bool, joinURLs []*url.URL, w io.Writer) *influxdb.Broker {
+func openBroker(path string, u *url.URL, initializing bool, joinURLs []*url.URL, client *http.Client, w io.Writer) *influxdb.Broker {
 	// Create broker.
 	b := influxdb.NewBroker()
 	b.SetLogOutput(w)
+	b.HTTPClient = client
+	b.Log().Transport = &raft.HTTPTransport{Client: client}
 
 	if err := b.Open(path, u); err != nil {
 		log.Fatalf("failed to open broker: %s", err)
@@ -263,15 +331,36 @@ func joinBroker(b *influxdb.Broker, joinURLs []*url.URL) {
 }
 
 // creates and initializes a server.
-func openServer(config *Config, b *influxdb.Broker, initServer, initBroker, configExists bool, joinURLs []*url.URL, w io.Writer) *influxdb.Server {
+func openServer(config *Config, b *influxdb.Broker, initServer, initBroker, configExists bool, joinURLs []*url.URL, client *http.Client, w io.Writer) *influxdb.Server {
 	// Create and open the server.
 	s := influxdb.NewServer()
 	s.SetLogOutput(w)
+	s.HTTPClient = client
 	s.WriteTrace = config.Logging.WriteTraceEnabled
+	s.Broker = b.Broker
 	s.RecomputePreviousN = config.ContinuousQuery.RecomputePreviousN
 	s.RecomputeNoOlderThan = time.Duration(config.ContinuousQuery.RecomputeNoOlderThan)
 	s.ComputeRunsPerInterval = config.ContinuousQuery.ComputeRunsPerInterval
 	s.ComputeNoMoreThan = time.Duration(config.ContinuousQuery.ComputeNoMoreThan)
+	s.MaxCatchUpWindow = time.Duration(config.ContinuousQuery.MaxCatchUpWindow)
+	s.BackfillDelay = time.Duration(config.ContinuousQuery.BackfillDelay)
+	s.SeriesLimits = influxdb.SeriesLimits{
+		MaxSeries:       config.Data.MaxSeriesPerDatabase,
+		MaxValuesPerTag: config.Data.MaxValuesPerTag,
+	}
+	s.DatabaseSeriesLimits = make(map[string]influxdb.SeriesLimits)
+	s.MaxDatabaseSize = config.Data.MaxDatabaseSize
+	s.DatabaseMaxSizes = make(map[string]int64)
+	for name, l := range config.Data.DatabaseLimits {
+		s.DatabaseSeriesLimits[name] = influxdb.SeriesLimits{MaxSeries: l.MaxSeries, MaxValuesPerTag: l.MaxValuesPerTag}
+		s.DatabaseMaxSizes[name] = l.MaxSize
+	}
+	s.RateLimiter = config.NewRateLimiter()
+
+	// Nodes only know the size of their own shards so sizes can't be limited in a cluster.
+	if len(joinURLs) > 0 && config.DatabaseSizeLimited() {
+		log.Fatalf("max database size is not supported when joining a cluster")
+	}
 
 	if err := s.Open(config.Data.Dir); err != nil {
 		log.Fatalf("failed to open data server: %v", err.Error())
@@ -280,7 +369,7 @@ func openServer(config *Config, b *influxdb.Broker, initServer, initBroker, conf
 	// If the server is uninitialized then initialize or join it.
 	if initServer {
 		if len(joinURLs) == 0 {
-			initializeServer(config.DataURL(), s, b, w, initBroker)
+			initializeServer(config.DataURL(), s, b, client, w, initBroker)
 		} else {
 			joinServer(s, config.DataURL(), joinURLs)
 		}
@@ -290,21 +379,21 @@ func openServer(config *Config, b *influxdb.Broker, initServer, initBroker, conf
 		// We are spining up a server that has no config,
 		// but already has an initialized data directory
 		joinURLs = []*url.URL{b.URL()}
-		openServerClient(s, joinURLs, w)
+		openServerClient(s, joinURLs, client, w)
 	} else {
 		if len(joinURLs) == 0 {
 			// If a config exists, but no joinUrls are specified, fall back to the broker URL
 			// TODO: Make sure we have a leader, and then spin up the server
 			joinURLs = []*url.URL{b.URL()}
 		}
-		openServerClient(s, joinURLs, w)
+		openServerClient(s, joinURLs, client, w)
 	}
 
 	return s
 }
 
 // initializes a new server that does not yet have an ID.
-func initializeServer(u *url.URL, s *influxdb.Server, b *influxdb.Broker, w io.Writer, initBroker bool) {
+func initializeServer(u *url.URL, s *influxdb.Server, b *influxdb.Broker, client *http.Client, w io.Writer, initBroker bool) {
 	// TODO: Create replica using the messaging client.
 
 	if initBroker {
@@ -317,6 +406,7 @@ func initializeServer(u *url.URL, s *influxdb.Server, b *influxdb.Broker, w io.W
 	// Create messaging client.
 	c := messaging.NewClient(1)
 	c.SetLogOutput(w)
+	c.HTTPClient = client
 	if err := c.Open(filepath.Join(s.Path(), messagingClientFile), []*url.URL{b.URL()}); err != nil {
 		log.Fatalf("messaging client error: %s", err)
 	}
@@ -349,9 +439,10 @@ func joinServer(s *influxdb.Server, u *url.URL, joinURLs []*url.URL) {
 }
 
 // opens the messaging client and attaches it to the server.
-func openServerClient(s *influxdb.Server, joinURLs []*url.URL, w io.Writer) {
+func openServerClient(s *influxdb.Server, joinURLs []*url.URL, client *http.Client, w io.Writer) {
 	c := messaging.NewClient(s.ID())
 	c.SetLogOutput(w)
+	c.HTTPClient = client
 	if err := c.Open(filepath.Join(s.Path(), messagingClientFile), joinURLs); err != nil {
 		log.Fatalf("messaging client error: %s", err)
 	}
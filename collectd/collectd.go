@@ -28,6 +28,8 @@ type Server struct {
 
 	writer      SeriesWriter
 	Database    string
+	RateLimiter *influxdb.RateLimiter // limits the rate of points written, if set
+	dropped     *influxdb.DroppedPoints
 	typesdb     gollectd.Types
 	typesdbpath string
 }
@@ -37,6 +39,7 @@ func NewServer(w SeriesWriter, typesDBPath string) *Server {
 		writer:      w,
 		typesdbpath: typesDBPath,
 		typesdb:     make(gollectd.Types),
+		dropped:     influxdb.NewDroppedPoints("Collectd "),
 	}
 
 	return &s
@@ -110,6 +113,11 @@ func (s *Server) handleMessage(buffer []byte) {
 	for _, packet := range *packets {
 		points := Unmarshal(&packet)
 		for _, p := range points {
+			if err := s.RateLimiter.AllowPoints("", s.Database, 1); err != nil {
+				s.dropped.Add(1, err)
+				continue
+			}
+
 			_, err := s.writer.WriteSeries(s.Database, "", []influxdb.Point{p})
 			if err != nil {
 				log.Printf("Collectd cannot write data: %s", err)
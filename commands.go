@@ -30,6 +30,7 @@ const (
 	// Shard messages
 	createShardGroupIfNotExistsMessageType = messaging.MessageType(0x40)
 	deleteShardGroupMessageType            = messaging.MessageType(0x41)
+	deleteShardMessageType                 = messaging.MessageType(0x42)
 
 	// Series messages
 	dropSeriesMessageType = messaging.MessageType(0x50)
@@ -39,13 +40,23 @@ const (
 	dropMeasurementMessageType               = messaging.MessageType(0x61)
 
 	// Continuous Query messages
-	createContinuousQueryMessageType = messaging.MessageType(0x70)
+	createContinuousQueryMessageType      = messaging.MessageType(0x70)
+	setContinuousQueryStateMessageType    = messaging.MessageType(0x71)
+	setContinuousQueryBackfillMessageType = messaging.MessageType(0x72)
+	createDownsampleMessageType           = messaging.MessageType(0x73)
+	dropDownsampleMessageType             = messaging.MessageType(0x74)
 
 	// Write series data messages (per-topic)
 	writeRawSeriesMessageType = messaging.MessageType(0x80)
 
 	// Privilege messages
-	setPrivilegeMessageType = messaging.MessageType(0x90)
+	setPrivilegeMessageType     = messaging.MessageType(0x90)
+	setRolePrivilegeMessageType = messaging.MessageType(0x91)
+
+	// Role messages
+	createRoleMessageType  = messaging.MessageType(0xA0)
+	deleteRoleMessageType  = messaging.MessageType(0xA1)
+	setUserRoleMessageType = messaging.MessageType(0xA2)
 )
 
 type createDataNodeCommand struct {
@@ -74,6 +85,9 @@ type deleteShardGroupCommand struct {
 	Policy   string `json:"policy"`
 	ID       uint64 `json:"id"`
 }
+type deleteShardCommand struct {
+	ID uint64 `json:"id"`
+}
 type createUserCommand struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -89,9 +103,32 @@ type deleteUserCommand struct {
 	Username string `json:"username"`
 }
 type setPrivilegeCommand struct {
-	Privilege influxql.Privilege `json:"privilege"`
-	Username  string             `json:"username"`
-	Database  string             `json:"database"`
+	Privilege       influxql.Privilege `json:"privilege"`
+	Username        string             `json:"username"`
+	Database        string             `json:"database"`
+	RetentionPolicy string             `json:"retentionPolicy,omitempty"`
+	Measurement     *Matcher           `json:"measurement,omitempty"`
+}
+type setRolePrivilegeCommand struct {
+	Privilege       influxql.Privilege `json:"privilege"`
+	Role            string             `json:"role"`
+	Database        string             `json:"database"`
+	RetentionPolicy string             `json:"retentionPolicy,omitempty"`
+	Measurement     *Matcher           `json:"measurement,omitempty"`
+}
+
+type createRoleCommand struct {
+	Name string `json:"name"`
+}
+
+type deleteRoleCommand struct {
+	Name string `json:"name"`
+}
+
+type setUserRoleCommand struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Granted  bool   `json:"granted"`
 }
 type createRetentionPolicyCommand struct {
 	Database string        `json:"database"`
@@ -99,6 +136,7 @@ type createRetentionPolicyCommand struct {
 	Duration time.Duration `json:"duration"`
 	ReplicaN uint32        `json:"replicaN"`
 	SplitN   uint32        `json:"splitN"`
+	MaxSize  int64         `json:"maxSize,omitempty"`
 }
 type updateRetentionPolicyCommand struct {
 	Database string                 `json:"database"`
@@ -203,3 +241,30 @@ type dropSeriesCommand struct {
 type createContinuousQueryCommand struct {
 	Query string `json:"query"`
 }
+
+// setContinuousQueryStateCommand is the raft command for recording the execution state of a continuous query
+type setContinuousQueryStateCommand struct {
+	Database      string    `json:"database"`
+	Name          string    `json:"name"`
+	LastCompleted time.Time `json:"lastCompleted"`
+}
+
+// setContinuousQueryBackfillCommand is the raft command for recording the progress of a continuous query backfill
+type setContinuousQueryBackfillCommand struct {
+	Database string                   `json:"database"`
+	Name     string                   `json:"name"`
+	Backfill *ContinuousQueryBackfill `json:"backfill,omitempty"`
+}
+
+// createDownsampleCommand is the raft command for creating a downsample on a database
+type createDownsampleCommand struct {
+	Database   string      `json:"database"`
+	Downsample *Downsample `json:"downsample"`
+}
+
+// dropDownsampleCommand is the raft command for removing a downsample from a database
+type dropDownsampleCommand struct {
+	Database string `json:"database"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+}
@@ -26,6 +26,7 @@ type database struct {
 
 	policies          map[string]*RetentionPolicy // retention policies by name
 	continuousQueries []*ContinuousQuery          // continuous queries
+	downsamples       []*Downsample               // downsamples between retention policies
 
 	defaultRetentionPolicy string
 
@@ -55,6 +56,16 @@ func (db *database) shardGroupByTimestamp(policy string, timestamp time.Time) (*
 	return p.shardGroupByTimestamp(timestamp), nil
 }
 
+// sortedPolicies returns the retention policies in the database sorted by name.
+func (db *database) sortedPolicies() []*RetentionPolicy {
+	a := make([]*RetentionPolicy, 0, len(db.policies))
+	for _, rp := range db.policies {
+		a = append(a, rp)
+	}
+	sort.Sort(retentionPoliciesByName(a))
+	return a
+}
+
 // Series takes a series ID and returns a series.
 func (db *database) Series(id uint32) *Series {
 	return db.series[id]
@@ -70,6 +81,7 @@ func (db *database) MarshalJSON() ([]byte, error) {
 		o.Policies = append(o.Policies, rp)
 	}
 	o.ContinuousQueries = db.continuousQueries
+	o.Downsamples = db.downsamples
 	return json.Marshal(&o)
 }
 
@@ -95,9 +107,14 @@ func (db *database) UnmarshalJSON(data []byte) error {
 	db.continuousQueries = make([]*ContinuousQuery, 0, len(o.ContinuousQueries))
 	for _, cq := range o.ContinuousQueries {
 		c, _ := NewContinuousQuery(cq.Query)
+		c.LastCompleted = cq.LastCompleted
+		c.Backfill = cq.Backfill
+		c.Managed = cq.Managed
 		db.continuousQueries = append(db.continuousQueries, c)
 	}
 
+	db.downsamples = o.Downsamples
+
 	return nil
 }
 
@@ -107,6 +124,7 @@ type databaseJSON struct {
 	DefaultRetentionPolicy string             `json:"defaultRetentionPolicy,omitempty"`
 	Policies               []*RetentionPolicy `json:"policies,omitempty"`
 	ContinuousQueries      []*ContinuousQuery `json:"continuousQueries,omitempty"`
+	Downsamples            []*Downsample      `json:"downsamples,omitempty"`
 }
 
 // Measurement represents a collection of time series in a database. It also contains in memory
@@ -1019,6 +1037,10 @@ type RetentionPolicy struct {
 	// The number of copies to make of each shard.
 	ReplicaN uint32 `json:"replicaN"`
 
+	// Maximum number of bytes of shard data to keep around. A zero size means no limit.
+	// Only enforced while the cluster has a single data node.
+	MaxSize int64 `json:"maxSize,omitempty"`
+
 	shardGroups []*ShardGroup
 }
 
@@ -1107,12 +1129,73 @@ func (rp *RetentionPolicy) removeShardGroupByID(shardID uint64) {
 	}
 }
 
+// size returns the number of bytes used by the local shards in the policy.
+func (rp *RetentionPolicy) size() (n int64) {
+	for _, g := range rp.shardGroups {
+		n += g.size()
+	}
+	return
+}
+
+// oversizedShardGroups returns the oldest groups that must be removed for the total
+// size of the groups to fit in maxSize. Groups in removed aren't counted and groups
+// in keep are counted but never returned.
+func oversizedShardGroups(a []*ShardGroup, sizes map[uint64]int64, maxSize int64, removed, keep map[uint64]bool) []*ShardGroup {
+	var groups []*ShardGroup
+	var total int64
+	for _, g := range a {
+		if removed[g.ID] {
+			continue
+		}
+		total += sizes[g.ID]
+		if !keep[g.ID] {
+			groups = append(groups, g)
+		}
+	}
+
+	// Remove the oldest groups until the remaining data fits.
+	sort.Sort(shardGroupsByStartTime(groups))
+	var other []*ShardGroup
+	for i := 0; i < len(groups) && total > maxSize; i++ {
+		other = append(other, groups[i])
+		total -= sizes[groups[i].ID]
+	}
+	return other
+}
+
+// newestShardGroup returns the group in the policy with the latest start time.
+// Returns nil if the policy has no groups.
+func (rp *RetentionPolicy) newestShardGroup() *ShardGroup {
+	var newest *ShardGroup
+	for _, g := range rp.shardGroups {
+		if newest == nil || g.StartTime.After(newest.StartTime) {
+			newest = g
+		}
+	}
+	return newest
+}
+
+// shardGroupsByStartTime represents a list of shard groups sortable by start time.
+type shardGroupsByStartTime []*ShardGroup
+
+func (a shardGroupsByStartTime) Len() int           { return len(a) }
+func (a shardGroupsByStartTime) Less(i, j int) bool { return a[i].StartTime.Before(a[j].StartTime) }
+func (a shardGroupsByStartTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// retentionPoliciesByName represents a list of retention policies sortable by name.
+type retentionPoliciesByName []*RetentionPolicy
+
+func (a retentionPoliciesByName) Len() int           { return len(a) }
+func (a retentionPoliciesByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+func (a retentionPoliciesByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // MarshalJSON encodes a retention policy to a JSON-encoded byte slice.
 func (rp *RetentionPolicy) MarshalJSON() ([]byte, error) {
 	var o retentionPolicyJSON
 	o.Name = rp.Name
 	o.Duration = rp.Duration
 	o.ReplicaN = rp.ReplicaN
+	o.MaxSize = rp.MaxSize
 	for _, g := range rp.shardGroups {
 		o.ShardGroups = append(o.ShardGroups, g)
 	}
@@ -1131,6 +1214,7 @@ func (rp *RetentionPolicy) UnmarshalJSON(data []byte) error {
 	rp.Name = o.Name
 	rp.ReplicaN = o.ReplicaN
 	rp.Duration = o.Duration
+	rp.MaxSize = o.MaxSize
 	rp.shardGroups = o.ShardGroups
 
 	return nil
@@ -1142,6 +1226,7 @@ type retentionPolicyJSON struct {
 	ReplicaN    uint32        `json:"replicaN,omitempty"`
 	SplitN      uint32        `json:"splitN,omitempty"`
 	Duration    time.Duration `json:"duration,omitempty"`
+	MaxSize     int64         `json:"maxSize,omitempty"`
 	ShardGroups []*ShardGroup `json:"shardGroups,omitempty"`
 }
 
@@ -1247,6 +1332,15 @@ func (db *database) continuousQueryByName(name string) *ContinuousQuery {
 	return nil
 }
 
+func (db *database) downsampleByRetentionPolicies(source, target string) *Downsample {
+	for _, ds := range db.downsamples {
+		if ds.SourceRetentionPolicy == source && ds.TargetRetentionPolicy == target {
+			return ds
+		}
+	}
+	return nil
+}
+
 // used to convert the tag set to bytes for use as a lookup key
 func marshalTags(tags map[string]string) []byte {
 	s := make([]string, 0, len(tags))
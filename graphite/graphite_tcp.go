@@ -15,13 +15,18 @@ type TCPServer struct {
 	parser *Parser
 
 	Database string
+
+	// Limits the rate of points written to the database. Nothing is limited if nil.
+	RateLimiter *influxdb.RateLimiter
+	dropped     *influxdb.DroppedPoints
 }
 
 // NewTCPServer returns a new instance of a TCPServer.
 func NewTCPServer(p *Parser, w SeriesWriter) *TCPServer {
 	return &TCPServer{
-		parser: p,
-		writer: w,
+		parser:  p,
+		writer:  w,
+		dropped: influxdb.NewDroppedPoints("graphite "),
 	}
 }
 
@@ -73,6 +78,12 @@ func (t *TCPServer) handleConnection(conn net.Conn) {
 			continue
 		}
 
+		// Drop the point if the database is over its rate limit.
+		if err := t.RateLimiter.AllowPoints("", t.Database, 1); err != nil {
+			t.dropped.Add(1, err)
+			continue
+		}
+
 		// Send the data to database
 		t.writer.WriteSeries(t.Database, "", []influxdb.Point{point})
 	}
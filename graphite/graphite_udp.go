@@ -17,6 +17,9 @@ type UDPServer struct {
 	parser *Parser
 
 	Database string
+
+	// Limits the rate of points written to the database. Nothing is limited if nil.
+	RateLimiter *influxdb.RateLimiter
 }
 
 // NewUDPServer returns a new instance of a UDPServer
@@ -60,6 +63,11 @@ func (u *UDPServer) ListenAndServe(iface string) error {
 					continue
 				}
 
+				// Drop the point if the database is over its rate limit.
+				if err := u.RateLimiter.AllowPoints("", u.Database, 1); err != nil {
+					continue
+				}
+
 				// Send the data to database
 				u.writer.WriteSeries(u.Database, "", []influxdb.Point{point})
 			}
@@ -0,0 +1,133 @@
+package httpd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Audit event types.
+const (
+	AuditAuthentication = "authentication"
+	AuditWrite          = "write"
+	AuditStatement      = "statement"
+)
+
+// Audit event outcomes.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent represents a single entry in the audit log.
+type AuditEvent struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	User       string    `json:"user,omitempty"`
+	RemoteAddr string    `json:"remoteAddr"`
+	RequestID  string    `json:"requestId,omitempty"`
+	Database   string    `json:"database,omitempty"`
+	Statement  string    `json:"statement,omitempty"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// AuditLogger writes audit events to a writer as JSON, one event per line.
+type AuditLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewAuditLogger returns a new instance of AuditLogger writing to w.
+func NewAuditLogger(w io.Writer) *AuditLogger {
+	return &AuditLogger{w: w}
+}
+
+// Log appends an event to the log. The time is set if it is zero.
+func (l *AuditLogger) Log(e *AuditEvent) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	// Write each event with a single call so lines are never interleaved.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(b)
+	return err
+}
+
+// audit records an event for a request in the handler's audit log, if one is set.
+func (h *Handler) audit(r *http.Request, e *AuditEvent) {
+	if h.AuditLog == nil {
+		return
+	}
+
+	e.RemoteAddr = r.RemoteAddr
+	e.RequestID = r.Header.Get("Request-Id")
+	if err := h.AuditLog.Log(e); err != nil {
+		h.Logger.Printf("audit log error: %s", err)
+	}
+}
+
+// auditStatementString returns the text of stmt for the audit log with any password redacted.
+func auditStatementString(stmt influxql.Statement) string {
+	if stmt, ok := stmt.(*influxql.CreateUserStatement); ok {
+		other := *stmt
+		other.Password = "[REDACTED]"
+		return other.String()
+	}
+	return stmt.String()
+}
+
+// auditStatementsString returns the text of a list of statements for the audit log.
+func auditStatementsString(a influxql.Statements) string {
+	var str []string
+	for _, stmt := range a {
+		str = append(str, auditStatementString(stmt))
+	}
+	return strings.Join(str, ";\n")
+}
+
+// isAuditedStatement returns true if stmt changes the schema, users or privileges.
+func isAuditedStatement(stmt influxql.Statement) bool {
+	switch stmt.(type) {
+	case *influxql.AlterRetentionPolicyStatement,
+		*influxql.AlterUserStatement,
+		*influxql.BackfillContinuousQueryStatement,
+		*influxql.CreateContinuousQueryStatement,
+		*influxql.CreateDatabaseStatement,
+		*influxql.CreateDownsampleStatement,
+		*influxql.CreateRetentionPolicyStatement,
+		*influxql.CreateRoleStatement,
+		*influxql.CreateUserStatement,
+		*influxql.DeleteStatement,
+		*influxql.DropContinuousQueryStatement,
+		*influxql.DropDataNodeStatement,
+		*influxql.DropDatabaseStatement,
+		*influxql.DropDownsampleStatement,
+		*influxql.DropMeasurementStatement,
+		*influxql.DropRetentionPolicyStatement,
+		*influxql.DropRoleStatement,
+		*influxql.DropSeriesStatement,
+		*influxql.DropShardStatement,
+		*influxql.DropUserStatement,
+		*influxql.GrantStatement,
+		*influxql.GrantRoleStatement,
+		*influxql.RevokeStatement,
+		*influxql.RevokeRoleStatement,
+		*influxql.SetPasswordUserStatement:
+		return true
+	}
+	return false
+}
@@ -1,13 +1,20 @@
 package httpd
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"log"
 	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -48,6 +55,34 @@ type Handler struct {
 
 	Logger     *log.Logger
 	WriteTrace bool // Detailed logging of write path
+
+	// Secret used to verify signed bearer tokens. Bearer tokens are
+	// rejected if no secret is set.
+	SharedSecret string
+
+	// Allows the first admin user to be created by clients other than localhost.
+	AllowRemoteBootstrap bool
+
+	// Records authentication failures, unauthorized requests and changes to
+	// the schema, users and privileges. Nothing is recorded if nil.
+	AuditLog *AuditLogger
+
+	// Limits the rate of writes and queries per user and per database.
+	// Nothing is limited if nil.
+	RateLimiter *influxdb.RateLimiter
+
+	// Policy for cross-origin requests from browsers. Defaults to allowing
+	// any origin so the admin interface can reach the API from its own port.
+	CORS CORSPolicy
+
+	// Verifies that requests to the data node routes come from other nodes
+	// in the cluster. Every request is allowed if nil.
+	ClusterAuth *influxdb.ClusterAuth
+
+	// CAs that client certificates must be issued by to authenticate a user.
+	// Kept separate from the cluster's CAs, which the data port also verifies,
+	// so node certificates can't authenticate as users. Disabled if nil.
+	ClientCAs *x509.CertPool
 }
 
 // NewHandler returns a new instance of Handler.
@@ -57,6 +92,7 @@ func NewHandler(s *influxdb.Server, requireAuthentication bool, version string)
 		mux:    pat.New(),
 		requireAuthentication: requireAuthentication,
 		Logger:                log.New(os.Stderr, "[http] ", log.LstdFlags),
+		CORS:                  CORSPolicy{AllowedOrigins: []string{"*"}},
 	}
 
 	h.routes = append(h.routes,
@@ -100,6 +136,10 @@ func NewHandler(s *influxdb.Server, requireAuthentication bool, version string)
 			"ping-head",
 			"HEAD", "/ping", true, true, h.servePing,
 		},
+		route{ // List CQs so the broker can assign them to data nodes
+			"continuous_queries",
+			"GET", "/continuous_queries", false, false, h.serveContinuousQueries,
+		},
 		route{ // Tell data node to run CQs that should be run
 			"process_continuous_queries",
 			"POST", "/process_continuous_queries", false, false, h.serveProcessContinuousQueries,
@@ -126,11 +166,16 @@ func NewHandler(s *influxdb.Server, requireAuthentication bool, version string)
 			handler = http.HandlerFunc(hf)
 		}
 
+		// Routes used by other nodes are restricted to members of the cluster.
+		if isClusterRoute(r.name) {
+			handler = clusterAuthenticate(handler, h)
+		}
+
 		if r.gzipped {
 			handler = gzipFilter(handler)
 		}
 		handler = versionHeader(handler, version)
-		handler = cors(handler)
+		handler = cors(handler, h, r.name == "query")
 		handler = requestID(handler)
 		if r.log {
 			handler = logging(handler, r.name, h.Logger)
@@ -167,13 +212,71 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *influ
 		return
 	}
 
+	// Enforce the rate limits of the user and the database. Databases that
+	// don't exist aren't limited so arbitrary names don't accumulate state.
+	var username string
+	if user != nil {
+		username = user.Name
+	}
+	limitedDB := db
+	if !h.server.DatabaseExists(db) {
+		limitedDB = ""
+	}
+	if err := h.RateLimiter.AllowRequest(username, limitedDB); err != nil {
+		httpRateLimited(w, err, pretty)
+		return
+	}
+	if err := h.RateLimiter.BeginQuery(username, limitedDB); err != nil {
+		httpRateLimited(w, err, pretty)
+		return
+	}
+	defer h.RateLimiter.EndQuery(username, limitedDB)
+
 	// Execute query. One result will return for each statement.
 	results := h.server.ExecuteQuery(query, db, user)
+	h.auditQuery(r, query, db, user, results)
 
 	// Send results to client.
 	httpResults(w, results, pretty)
 }
 
+// auditQuery records unauthorized queries and the outcome of each statement
+// that changes the schema, users or privileges.
+func (h *Handler) auditQuery(r *http.Request, q *influxql.Query, db string, user *influxdb.User, results influxdb.Results) {
+	if h.AuditLog == nil {
+		return
+	}
+
+	var username string
+	if user != nil {
+		username = user.Name
+	}
+
+	// No statements are executed if any of them is unauthorized.
+	if results.Err != nil {
+		if isAuthorizationError(results.Err) {
+			h.audit(r, &AuditEvent{Event: AuditStatement, User: username, Database: db, Statement: auditStatementsString(q.Statements), Outcome: AuditFailure, Error: results.Err.Error()})
+		}
+		return
+	}
+
+	for i, stmt := range q.Statements {
+		var err error
+		if i < len(results.Results) && results.Results[i] != nil {
+			err = results.Results[i].Err
+		}
+		if !isAuditedStatement(stmt) && !isAuthorizationError(err) {
+			continue
+		}
+
+		e := &AuditEvent{Event: AuditStatement, User: username, Database: db, Statement: auditStatementString(stmt), Outcome: AuditSuccess}
+		if err != nil {
+			e.Outcome, e.Error = AuditFailure, err.Error()
+		}
+		h.audit(r, e)
+	}
+}
+
 // serveWrite receives incoming series data and writes it to the database.
 func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *influxdb.User) {
 	var bp influxdb.BatchPoints
@@ -198,6 +301,16 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *influ
 		return
 	}
 
+	// Unauthorized writes are also recorded in the audit log.
+	writeUnauthorized := func(err error) {
+		e := &AuditEvent{Event: AuditWrite, Database: bp.Database, Outcome: AuditFailure, Error: err.Error()}
+		if user != nil {
+			e.User = user.Name
+		}
+		h.audit(r, e)
+		writeError(influxdb.Result{Err: err}, http.StatusUnauthorized)
+	}
+
 	if err := dec.Decode(&bp); err != nil {
 		if err.Error() == "EOF" {
 			w.WriteHeader(http.StatusOK)
@@ -218,12 +331,16 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *influ
 	}
 
 	if h.requireAuthentication && user == nil {
-		writeError(influxdb.Result{Err: fmt.Errorf("user is required to write to database %q", bp.Database)}, http.StatusUnauthorized)
+		if !h.server.AdminUserExists() {
+			writeUnauthorized(influxdb.ErrAdminUserRequired)
+			return
+		}
+		writeUnauthorized(fmt.Errorf("user is required to write to database %q", bp.Database))
 		return
 	}
 
-	if h.requireAuthentication && !user.Authorize(influxql.WritePrivilege, bp.Database) {
-		writeError(influxdb.Result{Err: fmt.Errorf("%q user is not authorized to write to database %q", user.Name, bp.Database)}, http.StatusUnauthorized)
+	if h.requireAuthentication && !user.Authorize(influxql.WritePrivilege, bp.Database) && !user.HasMeasurementPrivileges() {
+		writeUnauthorized(fmt.Errorf("%q user is not authorized to write to database %q", user.Name, bp.Database))
 		return
 	}
 
@@ -233,6 +350,34 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user *influ
 		return
 	}
 
+	// Without a database-wide privilege, every point must be allowed by the
+	// user's privileges on its measurement.
+	if h.requireAuthentication && !user.Authorize(influxql.WritePrivilege, bp.Database) {
+		policy := bp.RetentionPolicy
+		if policy == "" {
+			if rp, err := h.server.DefaultRetentionPolicy(bp.Database); err == nil && rp != nil {
+				policy = rp.Name
+			}
+		}
+
+		for _, p := range points {
+			if !user.AuthorizeMeasurement(influxql.WritePrivilege, bp.Database, policy, p.Name) {
+				writeUnauthorized(fmt.Errorf("%q user is not authorized to write to measurement %q in database %q", user.Name, p.Name, bp.Database))
+				return
+			}
+		}
+	}
+
+	// Enforce the rate limits of the user and the database.
+	var username string
+	if user != nil {
+		username = user.Name
+	}
+	if err := h.RateLimiter.AllowWrite(username, bp.Database, len(points)); err != nil {
+		httpRateLimited(w, err, false)
+		return
+	}
+
 	if index, err := h.server.WriteSeries(bp.Database, bp.RetentionPolicy, points); err != nil {
 		writeError(influxdb.Result{Err: err}, http.StatusInternalServerError)
 		return
@@ -259,12 +404,19 @@ func (h *Handler) serveStatus(w http.ResponseWriter, r *http.Request) {
 	pretty := r.URL.Query().Get("pretty") == "true"
 
 	data := struct {
-		Id    uint64 `json:"id"`
-		Index uint64 `json:"index"`
+		Id                uint64                          `json:"id"`
+		Index             uint64                          `json:"index"`
+		ContinuousQueries []influxdb.ContinuousQueryStats `json:"continuousQueries,omitempty"`
 	}{
 		Id:    h.server.ID(),
 		Index: h.server.Index(),
 	}
+
+	// The status is not authenticated, so continuous query names and errors are only
+	// included when authentication is disabled. Otherwise use SHOW CONTINUOUS QUERY STATS.
+	if !h.requireAuthentication {
+		data.ContinuousQueries = h.server.ContinuousQueryStats()
+	}
 	var b []byte
 	if pretty {
 		b, _ = json.MarshalIndent(data, "", "    ")
@@ -415,9 +567,22 @@ func (h *Handler) serveDeleteDataNode(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// serveProcessContinuousQueries will execute any continuous queries that should be run
+// serveContinuousQueries returns the identifiers of all continuous queries in the cluster.
+func (h *Handler) serveContinuousQueries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("content-type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.server.ContinuousQueryIDs())
+}
+
+// serveProcessContinuousQueries will execute any continuous queries that should be run.
+// If the body contains a list of continuous query identifiers then only those are run.
 func (h *Handler) serveProcessContinuousQueries(w http.ResponseWriter, r *http.Request) {
-	if err := h.server.RunContinuousQueries(); err != nil {
+	var ids []influxdb.ContinuousQueryID
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil && err != io.EOF {
+		httpError(w, err.Error(), false, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.server.RunContinuousQueries(ids...); err != nil {
 		httpError(w, err.Error(), false, http.StatusInternalServerError)
 		return
 	}
@@ -482,8 +647,58 @@ func httpError(w http.ResponseWriter, error string, pretty bool, code int) {
 	w.Write(b)
 }
 
+// httpRateLimited writes a rate limit error to the client, telling it when to retry.
+func httpRateLimited(w http.ResponseWriter, err error, pretty bool) {
+	if e, ok := err.(influxdb.ErrRateLimitExceeded); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	httpError(w, err.Error(), pretty, statusTooManyRequests)
+}
+
+// statusTooManyRequests is the HTTP status of rate limited requests.
+// It is not defined by net/http before Go 1.6.
+const statusTooManyRequests = 429
+
 // Filters and filter helpers
 
+// isClusterRoute returns true if the named route is only used by other nodes in the cluster.
+func isClusterRoute(name string) bool {
+	switch name {
+	case "data_nodes_index", "data_nodes_create", "data_nodes_delete",
+		"metastore", "continuous_queries", "process_continuous_queries":
+		return true
+	}
+	return false
+}
+
+// clusterAuthenticate rejects requests that do not come from a node in the cluster.
+func clusterAuthenticate(inner http.Handler, h *Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h.ClusterAuth.Authenticate(r); err != nil {
+			h.audit(r, &AuditEvent{Event: AuditAuthentication, Outcome: AuditFailure, Error: err.Error()})
+			httpError(w, err.Error(), false, http.StatusUnauthorized)
+			return
+		}
+		inner.ServeHTTP(w, r)
+	})
+}
+
+// parseClientCertificate returns the common name of the client certificate
+// used for the request. Only certificates verified against roots are returned.
+func parseClientCertificate(r *http.Request, roots *x509.CertPool) (string, bool) {
+	cert := influxdb.VerifyClientCertificate(r.TLS, roots)
+	if cert == nil {
+		return "", false
+	}
+	name := cert.Subject.CommonName
+	return name, name != ""
+}
+
+// hasCredentials returns true if the request includes a username or an Authorization header.
+func hasCredentials(r *http.Request) bool {
+	return r.URL.Query().Get("u") != "" || r.Header.Get("Authorization") != ""
+}
+
 // parseCredentials returns the username and password encoded in
 // a request. The credentials may be present as URL query params, or as
 // a Basic Authentication header.
@@ -502,11 +717,101 @@ func parseCredentials(r *http.Request) (string, string, error) {
 	}
 }
 
+// parseBearerToken returns the token from a "Bearer" Authorization header, if any.
+func parseBearerToken(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(auth[7:]), true
+}
+
+// parseToken verifies a JSON Web Token signed with an HMAC using secret and
+// returns the username it was issued to. The token must have a "username"
+// claim and an "exp" claim, in seconds since the epoch, which has not passed.
+func parseToken(token, secret string, now time.Time) (string, error) {
+	if secret == "" {
+		return "", errors.New("bearer authentication is not enabled")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("invalid token: expected header, claims and signature")
+	}
+
+	// Determine the signing algorithm from the header.
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeTokenSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("invalid token header: %s", err)
+	}
+	var fn func() hash.Hash
+	switch header.Alg {
+	case "HS256":
+		fn = sha256.New
+	case "HS384":
+		fn = sha512.New384
+	case "HS512":
+		fn = sha512.New
+	default:
+		return "", fmt.Errorf("invalid token: unsupported signing algorithm %q", header.Alg)
+	}
+
+	// Verify the signature before trusting any claims.
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid token signature: %s", err)
+	}
+	mac := hmac.New(fn, []byte(secret))
+	_, _ = mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", errors.New("invalid token: signature mismatch")
+	}
+
+	// Validate the claims.
+	var claims struct {
+		Username string `json:"username"`
+		Exp      int64  `json:"exp"`
+	}
+	if err := decodeTokenSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("invalid token claims: %s", err)
+	} else if claims.Exp == 0 {
+		return "", errors.New("invalid token: expiration required")
+	} else if !now.Before(time.Unix(claims.Exp, 0)) {
+		return "", errors.New("token expired")
+	} else if claims.Username == "" {
+		return "", errors.New("invalid token: username required")
+	}
+
+	return claims.Username, nil
+}
+
+// decodeTokenSegment decodes a base64url encoded JSON segment of a token into v.
+func decodeTokenSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// isLoopback returns true if the request was made from the local host.
+func isLoopback(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // authenticate wraps a handler and ensures that if user credentials are passed in
 // an attempt is made to authenticate that user. If authentication fails, an error is returned.
 //
-// There is one exception: if there are no users in the system, authentication is not required. This
-// is to facilitate bootstrapping of a system with authentication enabled.
+// There is one exception: if there is no admin user in the system, the request is passed on
+// without a user so that the server only permits the creation of an admin user. Unless remote
+// bootstrapping is allowed, such requests must come from localhost.
 func authenticate(inner func(http.ResponseWriter, *http.Request, *influxdb.User), h *Handler, requireAuthentication bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Return early if we are not authenticating
@@ -514,25 +819,65 @@ func authenticate(inner func(http.ResponseWriter, *http.Request, *influxdb.User)
 			inner(w, r, nil)
 			return
 		}
-		var user *influxdb.User
 
-		// TODO corylanou: never allow this in the future without users
-		if requireAuthentication && h.server.UserCount() > 0 {
-			username, password, err := parseCredentials(r)
+		// Authentication failures are recorded in the audit log.
+		unauthorized := func(username, msg string) {
+			h.audit(r, &AuditEvent{Event: AuditAuthentication, User: username, Outcome: AuditFailure, Error: msg})
+			httpError(w, msg, false, http.StatusUnauthorized)
+		}
+
+		// Bootstrap the first admin user.
+		if !h.server.AdminUserExists() {
+			if !h.AllowRemoteBootstrap && !isLoopback(r) {
+				unauthorized("", "no admin user exists: the first admin user must be created from localhost")
+				return
+			}
+			inner(w, r, nil)
+			return
+		}
+
+		// Signed bearer tokens identify the user without a password check.
+		if token, ok := parseBearerToken(r); ok {
+			username, err := parseToken(token, h.SharedSecret, time.Now())
 			if err != nil {
-				httpError(w, err.Error(), false, http.StatusUnauthorized)
+				unauthorized("", err.Error())
 				return
 			}
-			if username == "" {
-				httpError(w, "username required", false, http.StatusUnauthorized)
+			user := h.server.User(username)
+			if user == nil {
+				unauthorized(username, "invalid token: user not found")
 				return
 			}
+			inner(w, r, user)
+			return
+		}
 
-			user, err = h.server.Authenticate(username, password)
-			if err != nil {
-				httpError(w, err.Error(), false, http.StatusUnauthorized)
+		// A verified client certificate identifies the user by its common name,
+		// unless the request has credentials of its own.
+		if username, ok := parseClientCertificate(r, h.ClientCAs); ok && !hasCredentials(r) {
+			user := h.server.User(username)
+			if user == nil {
+				unauthorized(username, "invalid client certificate: user not found")
 				return
 			}
+			inner(w, r, user)
+			return
+		}
+
+		username, password, err := parseCredentials(r)
+		if err != nil {
+			unauthorized("", err.Error())
+			return
+		}
+		if username == "" {
+			unauthorized("", "username required")
+			return
+		}
+
+		user, err := h.server.Authenticate(username, password)
+		if err != nil {
+			unauthorized(username, err.Error())
+			return
 		}
 		inner(w, r, user)
 	})
@@ -571,29 +916,86 @@ func versionHeader(inner http.Handler, version string) http.Handler {
 	})
 }
 
-// cors responds to incoming requests and adds the appropriate cors headers
-// TODO: corylanou: add the ability to configure this in our config
-func cors(inner http.Handler) http.Handler {
+// CORSPolicy represents the origins, methods and headers allowed in cross-origin requests.
+type CORSPolicy struct {
+	// Origins allowed to make requests. "*" allows any origin.
+	// Cross-origin requests are not allowed if empty.
+	AllowedOrigins []string
+
+	// Methods and headers allowed in requests. Defaults are used if empty.
+	AllowedMethods []string
+	AllowedHeaders []string
+
+	// Allows requests to include cookies and HTTP authentication.
+	// Credentials are only allowed for explicitly listed origins, not "*".
+	AllowCredentials bool
+
+	// Only allows cross-origin requests to the query route.
+	QueryOnly bool
+}
+
+// DefaultCORSMethods are the methods allowed if the policy does not list any.
+var DefaultCORSMethods = []string{
+	`DELETE`,
+	`GET`,
+	`OPTIONS`,
+	`POST`,
+	`PUT`,
+}
+
+// DefaultCORSHeaders are the headers allowed if the policy does not list any.
+var DefaultCORSHeaders = []string{
+	`Accept`,
+	`Accept-Encoding`,
+	`Authorization`,
+	`Content-Length`,
+	`Content-Type`,
+	`X-CSRF-Token`,
+	`X-HTTP-Method-Override`,
+}
+
+// allowOrigin returns the value of the Access-Control-Allow-Origin header for
+// origin and whether credentials are allowed. Returns a blank value if the
+// origin is not allowed.
+func (p *CORSPolicy) allowOrigin(origin string) (string, bool) {
+	for _, o := range p.AllowedOrigins {
+		if o == origin {
+			return origin, p.AllowCredentials
+		}
+	}
+	for _, o := range p.AllowedOrigins {
+		if o == "*" {
+			return "*", false
+		}
+	}
+	return "", false
+}
+
+// cors sets the CORS headers allowed by the handler's policy. queryRoute is
+// true if the wrapped handler is the query route.
+func cors(inner http.Handler, h *Handler, queryRoute bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if origin := r.Header.Get("Origin"); origin != "" {
-			w.Header().Set(`Access-Control-Allow-Origin`, origin)
-			w.Header().Set(`Access-Control-Allow-Methods`, strings.Join([]string{
-				`DELETE`,
-				`GET`,
-				`OPTIONS`,
-				`POST`,
-				`PUT`,
-			}, ", "))
-
-			w.Header().Set(`Access-Control-Allow-Headers`, strings.Join([]string{
-				`Accept`,
-				`Accept-Encoding`,
-				`Authorization`,
-				`Content-Length`,
-				`Content-Type`,
-				`X-CSRF-Token`,
-				`X-HTTP-Method-Override`,
-			}, ", "))
+		policy := &h.CORS
+		if origin := r.Header.Get("Origin"); origin != "" && (queryRoute || !policy.QueryOnly) {
+			if allowed, credentials := policy.allowOrigin(origin); allowed != "" {
+				methods, headers := policy.AllowedMethods, policy.AllowedHeaders
+				if len(methods) == 0 {
+					methods = DefaultCORSMethods
+				}
+				if len(headers) == 0 {
+					headers = DefaultCORSHeaders
+				}
+
+				w.Header().Set(`Access-Control-Allow-Origin`, allowed)
+				w.Header().Set(`Access-Control-Allow-Methods`, strings.Join(methods, ", "))
+				w.Header().Set(`Access-Control-Allow-Headers`, strings.Join(headers, ", "))
+				if credentials {
+					w.Header().Set(`Access-Control-Allow-Credentials`, "true")
+				}
+				if allowed != "*" {
+					w.Header().Add("Vary", "Origin")
+				}
+			}
 		}
 
 		if r.Method == "OPTIONS" {
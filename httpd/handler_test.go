@@ -2,10 +2,20 @@ package httpd_test
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -262,7 +272,7 @@ func TestHandler_RetentionPolicies(t *testing.T) {
 
 	if status != http.StatusOK {
 		t.Fatalf("unexpected status: %d", status)
-	} else if body != `{"results":[{"series":[{"columns":["name","duration","replicaN"],"values":[["bar","168h0m0s",1]]}]}]}` {
+	} else if body != `{"results":[{"series":[{"columns":["name","duration","replicaN","maxSize","size"],"values":[["bar","168h0m0s",1,0,0]]}]}]}` {
 		t.Fatalf("unexpected body: %s", body)
 	}
 }
@@ -645,6 +655,57 @@ func TestHandler_PingHead(t *testing.T) {
 	}
 }
 
+func TestHandler_Status(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	query := map[string]string{"q": "CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END"}
+	if status, body := MustHTTP("GET", s.URL+`/query`, query, nil, ""); status != http.StatusOK {
+		t.Fatalf("unexpected status: %d, %s", status, body)
+	}
+
+	status, body := MustHTTP("GET", s.URL+`/status`, nil, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	var data struct {
+		ContinuousQueries []influxdb.ContinuousQueryStats `json:"continuousQueries"`
+	}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		t.Fatalf("unexpected body: %s", body)
+	} else if len(data.ContinuousQueries) != 1 || data.ContinuousQueries[0].Name != "myquery" || data.ContinuousQueries[0].Runs != 0 {
+		t.Fatalf("unexpected continuous queries: %s", body)
+	}
+}
+
+// Ensure the status does not show continuous queries when authentication is enabled.
+func TestHandler_Status_Authenticated(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+	srvr.CreateUser("lisa", "password", true)
+	s := NewAuthenticatedHTTPServer(srvr)
+	defer s.Close()
+
+	query := map[string]string{"q": "CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END", "u": "lisa", "p": "password"}
+	if status, body := MustHTTP("GET", s.URL+`/query`, query, nil, ""); status != http.StatusOK {
+		t.Fatalf("unexpected status: %d, %s", status, body)
+	}
+
+	status, body := MustHTTP("GET", s.URL+`/status`, nil, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	} else if strings.Contains(body, "continuousQueries") || strings.Contains(body, "myquery") {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
 func TestHandler_Users_MultipleUsers(t *testing.T) {
 	srvr := OpenAuthlessServer(NewMessagingClient())
 	srvr.CreateUser("jdoe", "1337", false)
@@ -800,13 +861,49 @@ func TestHandler_AuthenticatedCreateAdminUser(t *testing.T) {
 		t.Fatalf("unexpected status: %d", status)
 	}
 
-	// Creating an admin user, without supplying authentication credentials should fail.
+	// Creating the first admin user from localhost, without credentials, should succeed.
 	query = map[string]string{"q": "CREATE USER louise WITH PASSWORD 'pass' WITH ALL PRIVILEGES"}
+	status, body := MustHTTP("GET", s.URL+`/query`, query, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	}
+
+	// Once an admin user exists, credentials are required.
+	query = map[string]string{"q": "CREATE USER lucy WITH PASSWORD 'pass' WITH ALL PRIVILEGES"}
 	status, _ = MustHTTP("GET", s.URL+`/query`, query, nil, "")
 	if status != http.StatusUnauthorized {
 		t.Fatalf("unexpected status: %d", status)
 	}
+}
+
+func TestHandler_AuthenticatedCreateAdminUser_Remote(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	h := httpd.NewHandler(srvr.Server, true, "X.X")
+
+	newRequest := func() *http.Request {
+		r, _ := http.NewRequest("GET", "/query?q="+url.QueryEscape("CREATE USER louise WITH PASSWORD 'pass' WITH ALL PRIVILEGES"), nil)
+		r.RemoteAddr = "10.0.0.1:5000"
+		return r
+	}
 
+	// Remote clients cannot create the first admin user by default.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest())
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := strings.TrimSpace(w.Body.String()); body != `{"error":"no admin user exists: the first admin user must be created from localhost"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// Unless remote bootstrapping is allowed.
+	h.AllowRemoteBootstrap = true
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest())
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if !srvr.AdminUserExists() {
+		t.Fatal("admin user not created")
+	}
 }
 
 func TestHandler_AuthenticatedDatabases_Unauthorized(t *testing.T) {
@@ -876,6 +973,62 @@ func TestHandler_AuthenticatedDatabases_UnauthorizedBasicAuth(t *testing.T) {
 	}
 }
 
+func TestHandler_AuthenticatedDatabases_AuthorizedBearerToken(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	srvr.CreateUser("lisa", "password", true)
+	s := NewAuthenticatedHTTPServer(srvr)
+	s.Handler.SharedSecret = "secret"
+	defer s.Close()
+
+	token := MustSignToken("HS256", "secret", fmt.Sprintf(`{"username":"lisa","exp":%d}`, time.Now().Add(time.Hour).Unix()))
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	query := map[string]string{"q": "SHOW DATABASES"}
+	status, body := MustHTTP("GET", s.URL+`/query`, query, auth, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	}
+}
+
+func TestHandler_AuthenticatedDatabases_UnauthorizedBearerToken(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	srvr.CreateUser("lisa", "password", true)
+	s := NewAuthenticatedHTTPServer(srvr)
+	s.Handler.SharedSecret = "secret"
+	defer s.Close()
+
+	exp := time.Now().Add(time.Hour).Unix()
+	var tests = []struct {
+		token string
+		err   string
+	}{
+		{token: MustSignToken("HS256", "wrong", fmt.Sprintf(`{"username":"lisa","exp":%d}`, exp)), err: `invalid token: signature mismatch`},
+		{token: MustSignToken("HS256", "secret", fmt.Sprintf(`{"username":"lisa","exp":%d}`, time.Now().Add(-time.Minute).Unix())), err: `token expired`},
+		{token: MustSignToken("HS256", "secret", `{"username":"lisa"}`), err: `invalid token: expiration required`},
+		{token: MustSignToken("HS256", "secret", fmt.Sprintf(`{"username":"bob","exp":%d}`, exp)), err: `invalid token: user not found`},
+		{token: MustSignToken("none", "secret", fmt.Sprintf(`{"username":"lisa","exp":%d}`, exp)), err: `invalid token: unsupported signing algorithm "none"`},
+		{token: "foo.bar", err: `invalid token: expected header, claims and signature`},
+	}
+
+	for i, tt := range tests {
+		auth := map[string]string{"Authorization": "Bearer " + tt.token}
+		query := map[string]string{"q": "SHOW DATABASES"}
+		status, body := MustHTTP("GET", s.URL+`/query`, query, auth, "")
+		if status != http.StatusUnauthorized {
+			t.Fatalf("%d. unexpected status: %d", i, status)
+		} else if body != fmt.Sprintf(`{"error":%q}`, tt.err) {
+			t.Fatalf("%d. unexpected body: %s", i, body)
+		}
+	}
+
+	// Tokens are rejected when no secret is configured.
+	s.Handler.SharedSecret = ""
+	token := MustSignToken("HS256", "", fmt.Sprintf(`{"username":"lisa","exp":%d}`, exp))
+	status, _ := MustHTTP("GET", s.URL+`/query`, map[string]string{"q": "SHOW DATABASES"}, map[string]string{"Authorization": "Bearer " + token}, "")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+}
+
 func TestHandler_GrantDBPrivilege(t *testing.T) {
 	srvr := OpenAuthenticatedServer(NewMessagingClient())
 	// Create a cluster admin that will grant privilege to "john".
@@ -1045,12 +1198,295 @@ func TestHandler_serveWriteSeriesWithAuthNilUser(t *testing.T) {
 		t.Fatalf("unexpected status: %d", status)
 	}
 
-	response := `{"error":"user is required to write to database \"foo\""}`
+	// Writes are rejected until an admin user exists.
+	response := `{"error":"no admin user exists: create one with CREATE USER \u003cname\u003e WITH PASSWORD '\u003cpassword\u003e' WITH ALL PRIVILEGES"}`
 	if body != response {
 		t.Fatalf("unexpected body: expected %s, actual %s", response, body)
 	}
 }
 
+func TestHandler_serveWriteSeries_MeasurementPrivilege(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+	srvr.CreateUser("lisa", "password", true)
+	srvr.CreateUser("john", "password", false)
+	srvr.SetMeasurementPrivilege(influxql.WritePrivilege, "john", "foo", "", influxdb.Matcher{IsRegex: true, Name: "^app_"})
+	s := NewAuthenticatedHTTPServer(srvr)
+	defer s.Close()
+
+	auth := map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("john:password"))}
+
+	status, body := MustHTTP("POST", s.URL+`/write`, nil, auth, `{"database" : "foo", "points": [{"name": "app_requests", "timestamp": "2009-11-10T23:00:00Z", "fields": {"value": 100}}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", status, body)
+	}
+
+	// A batch is rejected if any point is outside of the user's privileges.
+	status, body = MustHTTP("POST", s.URL+`/write`, nil, auth, `{"database" : "foo", "points": [{"name": "app_requests", "timestamp": "2009-11-10T23:00:00Z", "fields": {"value": 100}}, {"name": "billing", "timestamp": "2009-11-10T23:00:00Z", "fields": {"value": 100}}]}`)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	} else if response := `{"error":"\"john\" user is not authorized to write to measurement \"billing\" in database \"foo\""}`; body != response {
+		t.Fatalf("unexpected body: expected %s, actual %s", response, body)
+	}
+}
+
+// Ensure auth failures and changes to the schema are recorded in the audit log.
+func TestHandler_AuditLog(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+	srvr.CreateUser("lisa", "password", true)
+	srvr.CreateUser("john", "password", false)
+	srvr.SetPrivilege(influxql.ReadPrivilege, "john", "foo")
+	s := NewAuthenticatedHTTPServer(srvr)
+	defer s.Close()
+
+	var buf bytes.Buffer
+	s.Handler.AuditLog = httpd.NewAuditLogger(&buf)
+
+	lisa := map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("lisa:password"))}
+	john := map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("john:password"))}
+	invalid := map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("lisa:wrong"))}
+
+	MustHTTP("GET", s.URL+`/query`, map[string]string{"q": "SHOW DATABASES"}, invalid, "")
+	MustHTTP("GET", s.URL+`/query`, map[string]string{"q": "CREATE USER susy WITH PASSWORD 'secret'; SHOW USERS"}, lisa, "")
+	MustHTTP("GET", s.URL+`/query`, map[string]string{"q": "CREATE USER bob WITH PASSWORD 'secret'; DROP DATABASE foo"}, john, "")
+	MustHTTP("POST", s.URL+`/write`, nil, john, `{"database" : "foo", "points": [{"name": "cpu", "timestamp": "2009-11-10T23:00:00Z", "fields": {"value": 100}}]}`)
+
+	// Read back the events.
+	var events []*httpd.AuditEvent
+	dec := json.NewDecoder(&buf)
+	for {
+		var e httpd.AuditEvent
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, &e)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("unexpected event count: %d", len(events))
+	}
+	for i, e := range events {
+		if e.Time.IsZero() || e.RemoteAddr == "" || e.RequestID == "" {
+			t.Fatalf("%d. missing request details: %#v", i, e)
+		}
+	}
+	if e := events[0]; e.Event != httpd.AuditAuthentication || e.User != "lisa" || e.Outcome != httpd.AuditFailure || e.Error != "invalid username or password" {
+		t.Fatalf("unexpected event(0): %#v", e)
+	}
+	if e := events[1]; e.Event != httpd.AuditStatement || e.User != "lisa" || e.Outcome != httpd.AuditSuccess || e.Statement != "CREATE USER susy WITH PASSWORD [REDACTED]" {
+		t.Fatalf("unexpected event(1): %#v", e)
+	}
+	if e := events[2]; e.Event != httpd.AuditStatement || e.User != "john" || e.Outcome != httpd.AuditFailure || e.Statement != "CREATE USER bob WITH PASSWORD [REDACTED];\nDROP DATABASE foo" {
+		t.Fatalf("unexpected event(2): %#v", e)
+	}
+	if e := events[3]; e.Event != httpd.AuditWrite || e.User != "john" || e.Database != "foo" || e.Outcome != httpd.AuditFailure {
+		t.Fatalf("unexpected event(3): %#v", e)
+	}
+}
+
+// statusTooManyRequests is the HTTP status of rate limited requests.
+// It is not defined by net/http before Go 1.6.
+const statusTooManyRequests = 429
+
+// Ensure writes and queries over the rate limit are rejected with a Retry-After header.
+func TestHandler_RateLimit(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	srvr.CreateDatabase("foo")
+	srvr.CreateRetentionPolicy("foo", influxdb.NewRetentionPolicy("bar"))
+	srvr.SetDefaultRetentionPolicy("foo", "bar")
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	s.Handler.RateLimiter = influxdb.NewRateLimiter()
+	s.Handler.RateLimiter.DatabaseLimits = influxdb.RateLimits{PointsPerSecond: 1, RequestsPerSecond: 2}
+
+	status, _ := MustHTTP("POST", s.URL+`/write`, nil, nil, `{"database" : "foo", "retentionPolicy" : "bar", "points": [{"name": "cpu", "timestamp": "2009-11-10T23:00:00Z", "fields": {"value": 100}}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	// The database has no points left.
+	resp, err := http.Post(s.URL+`/write`, "application/json", strings.NewReader(`{"database" : "foo", "retentionPolicy" : "bar", "points": [{"name": "cpu", "timestamp": "2009-11-10T23:00:00Z", "fields": {"value": 100}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != statusTooManyRequests {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	} else if resp.Header.Get("Retry-After") != "1" {
+		t.Fatalf("unexpected retry after: %q", resp.Header.Get("Retry-After"))
+	}
+
+	// The rejected write didn't use up a request.
+	status, _ = MustHTTP("GET", s.URL+`/query`, map[string]string{"q": "SHOW MEASUREMENTS", "db": "foo"}, nil, "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	// The database has no requests left.
+	status, body := MustHTTP("GET", s.URL+`/query`, map[string]string{"q": "SHOW MEASUREMENTS", "db": "foo"}, nil, "")
+	if status != statusTooManyRequests {
+		t.Fatalf("unexpected status: %d", status)
+	} else if body != `{"error":"rate limit exceeded: database=foo limit=requests-per-second"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// Databases that don't exist aren't limited.
+	for i := 0; i < 3; i++ {
+		if status, _ := MustHTTP("GET", s.URL+`/query`, map[string]string{"q": "SHOW DATABASES", "db": "nosuchdb"}, nil, ""); status != http.StatusOK {
+			t.Fatalf("unexpected status: %d", status)
+		}
+	}
+}
+
+// Ensure cross-origin requests are only allowed by the CORS policy.
+func TestHandler_CORS(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+
+	// Returns the response headers for a request from origin.
+	request := func(method, path, origin string) http.Header {
+		req, err := http.NewRequest(method, s.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.Header
+	}
+
+	// Any origin is allowed by default.
+	if h := request("OPTIONS", "/write", "http://foo.com"); h.Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("unexpected allowed origin: %q", h.Get("Access-Control-Allow-Origin"))
+	} else if h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatalf("unexpected allowed credentials: %q", h.Get("Access-Control-Allow-Credentials"))
+	}
+
+	s.Handler.CORS = httpd.CORSPolicy{
+		AllowedOrigins:   []string{"http://foo.com"},
+		AllowedMethods:   []string{"GET"},
+		AllowCredentials: true,
+		QueryOnly:        true,
+	}
+
+	if h := request("GET", "/query?q=SHOW+DATABASES", "http://foo.com"); h.Get("Access-Control-Allow-Origin") != "http://foo.com" {
+		t.Fatalf("unexpected allowed origin: %q", h.Get("Access-Control-Allow-Origin"))
+	} else if h.Get("Access-Control-Allow-Methods") != "GET" {
+		t.Fatalf("unexpected allowed methods: %q", h.Get("Access-Control-Allow-Methods"))
+	} else if h.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("unexpected allowed credentials: %q", h.Get("Access-Control-Allow-Credentials"))
+	} else if h.Get("Vary") != "Origin" {
+		t.Fatalf("unexpected vary: %q", h.Get("Vary"))
+	}
+
+	// Other origins are not allowed.
+	if h := request("GET", "/query?q=SHOW+DATABASES", "http://bar.com"); h.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("unexpected allowed origin: %q", h.Get("Access-Control-Allow-Origin"))
+	}
+
+	// Other routes are not allowed.
+	if h := request("OPTIONS", "/write", "http://foo.com"); h.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("unexpected allowed origin: %q", h.Get("Access-Control-Allow-Origin"))
+	}
+}
+
+// Ensure a client certificate issued by the API's CA authenticates the user named by its common name.
+func TestHandler_ClientCertificateAuthentication(t *testing.T) {
+	srvr := OpenAuthenticatedServer(NewMessagingClient())
+	srvr.CreateUser("lisa", "password", true)
+	s := NewAuthenticatedHTTPServer(srvr)
+	defer s.Close()
+
+	ca, caKey := MustCreateCertificate("api-ca", nil, nil)
+	clusterCA, clusterCAKey := MustCreateCertificate("cluster-ca", nil, nil)
+	s.Handler.ClientCAs = x509.NewCertPool()
+	s.Handler.ClientCAs.AddCert(ca)
+
+	// Returns the status of a query made with a client certificate.
+	query := func(cert *x509.Certificate, headers map[string]string) int {
+		req, err := http.NewRequest("GET", "/query?q=SHOW+DATABASES", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+		w := httptest.NewRecorder()
+		s.Handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	lisa, _ := MustCreateCertificate("lisa", ca, caKey)
+	if status := query(lisa, nil); status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	// The user must exist.
+	john, _ := MustCreateCertificate("john", ca, caKey)
+	if status := query(john, nil); status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	// Certificates issued by the cluster's CA are not trusted, even if the listener verified them.
+	node, _ := MustCreateCertificate("lisa", clusterCA, clusterCAKey)
+	req, _ := http.NewRequest("GET", "/query?q=SHOW+DATABASES", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{node}, VerifiedChains: [][]*x509.Certificate{{node, clusterCA}}}
+	w := httptest.NewRecorder()
+	s.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	// Credentials in the request are used instead of the certificate.
+	invalid := map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("lisa:wrong"))}
+	if status := query(lisa, invalid); status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	// Certificates are ignored if no CAs are set.
+	s.Handler.ClientCAs = nil
+	if status := query(lisa, nil); status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+}
+
+// Ensure the data node routes are restricted to other nodes in the cluster.
+func TestHandler_ClusterAuth(t *testing.T) {
+	srvr := OpenAuthlessServer(NewMessagingClient())
+	s := NewHTTPServer(srvr)
+	defer s.Close()
+	s.Handler.ClusterAuth = &influxdb.ClusterAuth{Secret: "foo"}
+
+	if status, _ := MustHTTP("GET", s.URL+`/data_nodes`, nil, nil, ""); status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+	if status, _ := MustHTTP("GET", s.URL+`/metastore`, nil, map[string]string{influxdb.ClusterSecretHeader: "bar"}, ""); status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+	if status, _ := MustHTTP("GET", s.URL+`/data_nodes`, nil, map[string]string{influxdb.ClusterSecretHeader: "foo"}, ""); status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	// Client routes are not restricted.
+	if status, _ := MustHTTP("GET", s.URL+`/ping`, nil, nil, ""); status != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", status)
+	}
+}
+
 func TestHandler_serveWriteSeries_noDatabaseExists(t *testing.T) {
 	srvr := OpenAuthenticatedServer(NewMessagingClient())
 	s := NewHTTPServer(srvr)
@@ -1375,6 +1811,15 @@ func MustParseURL(s string) *url.URL {
 	return u
 }
 
+// MustSignToken returns a JSON Web Token with the given claims signed using an HMAC.
+func MustSignToken(alg, secret, claims string) string {
+	enc := base64.RawURLEncoding
+	token := enc.EncodeToString([]byte(fmt.Sprintf(`{"alg":%q,"typ":"JWT"}`, alg))) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+	return token + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
 // Server is a test HTTP server that wraps a handler
 type HTTPServer struct {
 	*httptest.Server
@@ -1548,3 +1993,37 @@ func mustMarshalJSON(i interface{}) string {
 	}
 	return string(b)
 }
+
+// MustCreateCertificate returns a client certificate and key for name issued by
+// parent. The certificate is a self-signed CA if parent is nil. Panic on error.
+func MustCreateCertificate(name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	b, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		panic(err.Error())
+	}
+	cert, err := x509.ParseCertificate(b)
+	if err != nil {
+		panic(err.Error())
+	}
+	return cert, key
+}
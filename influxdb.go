@@ -32,6 +32,14 @@ var (
 	// ErrDataNodeNotFound is returned when dropping a non-existent data node.
 	ErrDataNodeNotFound = errors.New("data node not found")
 
+	// ErrAdminUserRequired is returned for any request other than the creation
+	// of an admin user while authentication is enabled and no admin user exists.
+	ErrAdminUserRequired = ErrAuthorize{text: "no admin user exists: create one with CREATE USER <name> WITH PASSWORD '<password>' WITH ALL PRIVILEGES"}
+
+	// ErrBrokerUnavailable is returned when broker state is requested from a
+	// server that is not running alongside a broker.
+	ErrBrokerUnavailable = errors.New("broker unavailable")
+
 	// ErrDataNodeRequired is returned when using a blank data node id.
 	ErrDataNodeRequired = errors.New("data node required")
 
@@ -65,6 +73,18 @@ var (
 	// ErrInvalidUsername is returned when using a username with invalid characters.
 	ErrInvalidUsername = errors.New("invalid username")
 
+	// ErrPasswordRequired is returned when setting a blank password.
+	ErrPasswordRequired = errors.New("password required")
+
+	// ErrRoleExists is returned when creating a duplicate role.
+	ErrRoleExists = errors.New("role exists")
+
+	// ErrRoleNotFound is returned when referencing a non-existent role.
+	ErrRoleNotFound = errors.New("role not found")
+
+	// ErrRoleNameRequired is returned when using a blank role name.
+	ErrRoleNameRequired = errors.New("role name required")
+
 	// ErrRetentionPolicyExists is returned when creating a duplicate shard space.
 	ErrRetentionPolicyExists = errors.New("retention policy exists")
 
@@ -81,6 +101,10 @@ var (
 	// ErrShardNotFound is returned writing to a non-existent shard.
 	ErrShardNotFound = errors.New("shard not found")
 
+	// ErrShardGroupHasMultipleShards is returned when dropping a shard from a group with
+	// other shards. Series are assigned to shards by the number of shards in the group.
+	ErrShardGroupHasMultipleShards = errors.New("cannot drop a shard from a group with multiple shards")
+
 	// ErrInvalidPointBuffer is returned when a buffer containing data for writing is invalid
 	ErrInvalidPointBuffer = errors.New("invalid point buffer")
 
@@ -128,6 +152,24 @@ var (
 
 	// ErrContinuousQueryExists is returned when creating a duplicate continuous query.
 	ErrContinuousQueryExists = errors.New("continuous query already exists")
+
+	// ErrContinuousQueryNotFound is returned when a continuous query doesn't exist.
+	ErrContinuousQueryNotFound = errors.New("continuous query not found")
+
+	// ErrContinuousQueryIntervalRequired is returned when backfilling a continuous query without a group by interval.
+	ErrContinuousQueryIntervalRequired = errors.New("continuous query group by interval required")
+
+	// ErrInvalidBackfillTimeRange is returned when the start of a backfill is not before its end.
+	ErrInvalidBackfillTimeRange = errors.New("backfill start time must be before end time")
+
+	// ErrDownsampleExists is returned when creating a duplicate downsample.
+	ErrDownsampleExists = errors.New("downsample already exists")
+
+	// ErrDownsampleNotFound is returned when a downsample doesn't exist.
+	ErrDownsampleNotFound = errors.New("downsample not found")
+
+	// ErrDownsampleSourceIsTarget is returned when a downsample reads from and writes into the same retention policy.
+	ErrDownsampleSourceIsTarget = errors.New("downsample source and target retention policies must differ")
 )
 
 // BatchPoints is used to send batched data in a single write.
@@ -249,6 +291,23 @@ func (e ErrAuthorize) Error() string {
 // authorize satisfies isAuthorizationError
 func (ErrAuthorize) authorize() {}
 
+// ErrSeriesLimitExceeded is returned when a write would create more series in a database,
+// or more values for a tag key of a measurement, than the database's series limits allow.
+type ErrSeriesLimitExceeded struct {
+	Database    string
+	Measurement string // only set if the limit of values per tag was exceeded
+	TagKey      string // only set if the limit of values per tag was exceeded
+	Limit       int
+}
+
+// Error returns the text of the error.
+func (e ErrSeriesLimitExceeded) Error() string {
+	if e.TagKey != "" {
+		return fmt.Sprintf("max values per tag exceeded: database=%s measurement=%s tag=%s limit=%d", e.Database, e.Measurement, e.TagKey, e.Limit)
+	}
+	return fmt.Sprintf("max series per database exceeded: database=%s limit=%d", e.Database, e.Limit)
+}
+
 func isAuthorizationError(err error) bool {
 	type authorize interface {
 		authorize()
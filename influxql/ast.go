@@ -57,30 +57,50 @@ type Node interface {
 func (*Query) node()     {}
 func (Statements) node() {}
 
-func (*AlterRetentionPolicyStatement) node()  {}
-func (*CreateContinuousQueryStatement) node() {}
-func (*CreateDatabaseStatement) node()        {}
-func (*CreateRetentionPolicyStatement) node() {}
-func (*CreateUserStatement) node()            {}
-func (*DeleteStatement) node()                {}
-func (*DropContinuousQueryStatement) node()   {}
-func (*DropDatabaseStatement) node()          {}
-func (*DropMeasurementStatement) node()       {}
-func (*DropRetentionPolicyStatement) node()   {}
-func (*DropSeriesStatement) node()            {}
-func (*DropUserStatement) node()              {}
-func (*GrantStatement) node()                 {}
-func (*ShowContinuousQueriesStatement) node() {}
-func (*ShowDatabasesStatement) node()         {}
-func (*ShowFieldKeysStatement) node()         {}
-func (*ShowRetentionPoliciesStatement) node() {}
-func (*ShowMeasurementsStatement) node()      {}
-func (*ShowSeriesStatement) node()            {}
-func (*ShowTagKeysStatement) node()           {}
-func (*ShowTagValuesStatement) node()         {}
-func (*ShowUsersStatement) node()             {}
-func (*RevokeStatement) node()                {}
-func (*SelectStatement) node()                {}
+func (*AlterRetentionPolicyStatement) node()     {}
+func (*AlterUserStatement) node()                {}
+func (*BackfillContinuousQueryStatement) node()  {}
+func (*CreateContinuousQueryStatement) node()    {}
+func (*CreateDatabaseStatement) node()           {}
+func (*CreateDownsampleStatement) node()         {}
+func (*CreateRetentionPolicyStatement) node()    {}
+func (*CreateRoleStatement) node()               {}
+func (*CreateUserStatement) node()               {}
+func (*DeleteStatement) node()                   {}
+func (*DropDataNodeStatement) node()             {}
+func (*DropContinuousQueryStatement) node()      {}
+func (*DropDatabaseStatement) node()             {}
+func (*DropDownsampleStatement) node()           {}
+func (*DropMeasurementStatement) node()          {}
+func (*DropRetentionPolicyStatement) node()      {}
+func (*DropRoleStatement) node()                 {}
+func (*DropSeriesStatement) node()               {}
+func (*DropShardStatement) node()                {}
+func (*DropUserStatement) node()                 {}
+func (*GrantStatement) node()                    {}
+func (*GrantRoleStatement) node()                {}
+func (*ShowBrokersStatement) node()              {}
+func (*ShowContinuousQueriesStatement) node()    {}
+func (*ShowContinuousQueryStatsStatement) node() {}
+func (*ShowDataNodesStatement) node()            {}
+func (*ShowDatabasesStatement) node()            {}
+func (*ShowFieldKeysStatement) node()            {}
+func (*ShowGrantsForUserStatement) node()        {}
+func (*ShowRetentionPoliciesStatement) node()    {}
+func (*ShowRolesStatement) node()                {}
+func (*ShowMeasurementsStatement) node()         {}
+func (*ShowSeriesStatement) node()               {}
+func (*ShowSeriesCardinalityStatement) node()    {}
+func (*ShowShardsStatement) node()               {}
+func (*ShowShardGroupsStatement) node()          {}
+func (*ShowTagKeysStatement) node()              {}
+func (*ShowTagValuesStatement) node()            {}
+func (*ShowTagValuesCardinalityStatement) node() {}
+func (*ShowUsersStatement) node()                {}
+func (*RevokeStatement) node()                   {}
+func (*RevokeRoleStatement) node()               {}
+func (*SetPasswordUserStatement) node()          {}
+func (*SelectStatement) node()                   {}
 
 func (*BinaryExpr) node()      {}
 func (*BooleanLiteral) node()  {}
@@ -101,6 +121,7 @@ func (*RegexLiteral) node()    {}
 func (*SortField) node()       {}
 func (SortFields) node()       {}
 func (*StringLiteral) node()   {}
+func (*SubQuery) node()        {}
 func (*Target) node()          {}
 func (*TimeLiteral) node()     {}
 func (*VarRef) node()          {}
@@ -147,30 +168,50 @@ type ExecutionPrivilege struct {
 // ExecutionPrivileges is a list of privileges required to execute a statement.
 type ExecutionPrivileges []ExecutionPrivilege
 
-func (*AlterRetentionPolicyStatement) stmt()  {}
-func (*CreateContinuousQueryStatement) stmt() {}
-func (*CreateDatabaseStatement) stmt()        {}
-func (*CreateRetentionPolicyStatement) stmt() {}
-func (*CreateUserStatement) stmt()            {}
-func (*DeleteStatement) stmt()                {}
-func (*DropContinuousQueryStatement) stmt()   {}
-func (*DropDatabaseStatement) stmt()          {}
-func (*DropMeasurementStatement) stmt()       {}
-func (*DropRetentionPolicyStatement) stmt()   {}
-func (*DropSeriesStatement) stmt()            {}
-func (*DropUserStatement) stmt()              {}
-func (*GrantStatement) stmt()                 {}
-func (*ShowContinuousQueriesStatement) stmt() {}
-func (*ShowDatabasesStatement) stmt()         {}
-func (*ShowFieldKeysStatement) stmt()         {}
-func (*ShowMeasurementsStatement) stmt()      {}
-func (*ShowRetentionPoliciesStatement) stmt() {}
-func (*ShowSeriesStatement) stmt()            {}
-func (*ShowTagKeysStatement) stmt()           {}
-func (*ShowTagValuesStatement) stmt()         {}
-func (*ShowUsersStatement) stmt()             {}
-func (*RevokeStatement) stmt()                {}
-func (*SelectStatement) stmt()                {}
+func (*AlterRetentionPolicyStatement) stmt()     {}
+func (*AlterUserStatement) stmt()                {}
+func (*BackfillContinuousQueryStatement) stmt()  {}
+func (*CreateContinuousQueryStatement) stmt()    {}
+func (*CreateDatabaseStatement) stmt()           {}
+func (*CreateDownsampleStatement) stmt()         {}
+func (*CreateRetentionPolicyStatement) stmt()    {}
+func (*CreateRoleStatement) stmt()               {}
+func (*CreateUserStatement) stmt()               {}
+func (*DeleteStatement) stmt()                   {}
+func (*DropDataNodeStatement) stmt()             {}
+func (*DropContinuousQueryStatement) stmt()      {}
+func (*DropDatabaseStatement) stmt()             {}
+func (*DropDownsampleStatement) stmt()           {}
+func (*DropMeasurementStatement) stmt()          {}
+func (*DropRetentionPolicyStatement) stmt()      {}
+func (*DropRoleStatement) stmt()                 {}
+func (*DropSeriesStatement) stmt()               {}
+func (*DropShardStatement) stmt()                {}
+func (*DropUserStatement) stmt()                 {}
+func (*GrantStatement) stmt()                    {}
+func (*GrantRoleStatement) stmt()                {}
+func (*ShowBrokersStatement) stmt()              {}
+func (*ShowContinuousQueriesStatement) stmt()    {}
+func (*ShowContinuousQueryStatsStatement) stmt() {}
+func (*ShowDataNodesStatement) stmt()            {}
+func (*ShowDatabasesStatement) stmt()            {}
+func (*ShowFieldKeysStatement) stmt()            {}
+func (*ShowGrantsForUserStatement) stmt()        {}
+func (*ShowMeasurementsStatement) stmt()         {}
+func (*ShowRetentionPoliciesStatement) stmt()    {}
+func (*ShowRolesStatement) stmt()                {}
+func (*ShowSeriesStatement) stmt()               {}
+func (*ShowSeriesCardinalityStatement) stmt()    {}
+func (*ShowShardsStatement) stmt()               {}
+func (*ShowShardGroupsStatement) stmt()          {}
+func (*ShowTagKeysStatement) stmt()              {}
+func (*ShowTagValuesStatement) stmt()            {}
+func (*ShowTagValuesCardinalityStatement) stmt() {}
+func (*ShowUsersStatement) stmt()                {}
+func (*RevokeStatement) stmt()                   {}
+func (*RevokeRoleStatement) stmt()               {}
+func (*SetPasswordUserStatement) stmt()          {}
+func (*SelectStatement) stmt()                   {}
 
 // Expr represents an expression that can be evaluated to a value.
 type Expr interface {
@@ -200,6 +241,7 @@ type Source interface {
 func (*Join) source()        {}
 func (*Measurement) source() {}
 func (*Merge) source()       {}
+func (*SubQuery) source()    {}
 
 // SortField represents a field to sort results by.
 type SortField struct {
@@ -326,6 +368,49 @@ func (s *CreateUserStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// SetPasswordUserStatement represents a command for changing a user's password.
+type SetPasswordUserStatement struct {
+	// Name of the user whose password is changed.
+	Name string
+
+	// New password for the user.
+	Password string
+}
+
+// String returns a string representation of the set password statement.
+// The password is redacted so the statement can be logged safely.
+func (s *SetPasswordUserStatement) String() string {
+	return "SET PASSWORD FOR " + s.Name + " = [REDACTED]"
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a SetPasswordUserStatement.
+// Users may also change their own password; this is checked when authorizing.
+func (s *SetPasswordUserStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// AlterUserStatement represents a command for granting or revoking cluster admin.
+type AlterUserStatement struct {
+	// Name of the user to alter.
+	Name string
+
+	// Whether the user is a cluster admin.
+	Admin bool
+}
+
+// String returns a string representation of the alter user statement.
+func (s *AlterUserStatement) String() string {
+	if s.Admin {
+		return "ALTER USER " + s.Name + " WITH ADMIN"
+	}
+	return "ALTER USER " + s.Name + " WITHOUT ADMIN"
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute an AlterUserStatement.
+func (s *AlterUserStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // DropUserStatement represents a command for dropping a user.
 type DropUserStatement struct {
 	// Name of the user to drop.
@@ -385,8 +470,19 @@ type GrantStatement struct {
 	// Thing to grant privilege on (e.g., a DB).
 	On string
 
+	// Retention policy and measurement to limit the privilege to.
+	// An empty retention policy matches every policy in the database.
+	RetentionPolicy string
+	Measurement     string
+
+	// Regex matching the measurements to limit the privilege to.
+	MeasurementRegex *RegexLiteral
+
 	// Who to grant the privilege to.
 	User string
+
+	// Role to grant the privilege to instead of a user.
+	Role string
 }
 
 // String returns a string representation of the grant statement.
@@ -396,10 +492,15 @@ func (s *GrantStatement) String() string {
 	_, _ = buf.WriteString(s.Privilege.String())
 	if s.On != "" {
 		_, _ = buf.WriteString(" ON ")
-		_, _ = buf.WriteString(s.On)
+		_, _ = buf.WriteString(privilegeTargetString(s.On, s.RetentionPolicy, s.Measurement, s.MeasurementRegex))
 	}
 	_, _ = buf.WriteString(" TO ")
-	_, _ = buf.WriteString(s.User)
+	if s.Role != "" {
+		_, _ = buf.WriteString("ROLE ")
+		_, _ = buf.WriteString(s.Role)
+	} else {
+		_, _ = buf.WriteString(s.User)
+	}
 	return buf.String()
 }
 
@@ -416,8 +517,18 @@ type RevokeStatement struct {
 	// Thing to revoke privilege to (e.g., a DB)
 	On string
 
+	// Retention policy and measurement the privilege was limited to.
+	RetentionPolicy string
+	Measurement     string
+
+	// Regex matching the measurements the privilege was limited to.
+	MeasurementRegex *RegexLiteral
+
 	// Who to revoke privilege from.
 	User string
+
+	// Role to revoke the privilege from instead of a user.
+	Role string
 }
 
 // String returns a string representation of the revoke statement.
@@ -427,10 +538,15 @@ func (s *RevokeStatement) String() string {
 	_, _ = buf.WriteString(s.Privilege.String())
 	if s.On != "" {
 		_, _ = buf.WriteString(" ON ")
-		_, _ = buf.WriteString(s.On)
+		_, _ = buf.WriteString(privilegeTargetString(s.On, s.RetentionPolicy, s.Measurement, s.MeasurementRegex))
 	}
 	_, _ = buf.WriteString(" FROM ")
-	_, _ = buf.WriteString(s.User)
+	if s.Role != "" {
+		_, _ = buf.WriteString("ROLE ")
+		_, _ = buf.WriteString(s.Role)
+	} else {
+		_, _ = buf.WriteString(s.User)
+	}
 	return buf.String()
 }
 
@@ -439,6 +555,115 @@ func (s *RevokeStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// CreateRoleStatement represents a command for creating a new role.
+type CreateRoleStatement struct {
+	// Name of the role to be created.
+	Name string
+}
+
+// String returns a string representation of the create role statement.
+func (s *CreateRoleStatement) String() string {
+	return "CREATE ROLE " + s.Name
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a CreateRoleStatement.
+func (s *CreateRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// DropRoleStatement represents a command for dropping a role.
+type DropRoleStatement struct {
+	// Name of the role to drop.
+	Name string
+}
+
+// String returns a string representation of the drop role statement.
+func (s *DropRoleStatement) String() string {
+	return "DROP ROLE " + s.Name
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a DropRoleStatement.
+func (s *DropRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// GrantRoleStatement represents a command for adding a user to a role.
+type GrantRoleStatement struct {
+	// Role to be granted.
+	Role string
+
+	// Who to grant the role to.
+	User string
+}
+
+// String returns a string representation of the grant role statement.
+func (s *GrantRoleStatement) String() string {
+	return "GRANT ROLE " + s.Role + " TO " + s.User
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a GrantRoleStatement.
+func (s *GrantRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// RevokeRoleStatement represents a command for removing a user from a role.
+type RevokeRoleStatement struct {
+	// Role to be revoked.
+	Role string
+
+	// Who to revoke the role from.
+	User string
+}
+
+// String returns a string representation of the revoke role statement.
+func (s *RevokeRoleStatement) String() string {
+	return "REVOKE ROLE " + s.Role + " FROM " + s.User
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a RevokeRoleStatement.
+func (s *RevokeRoleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// privilegeTargetString returns the string representation of the thing a
+// privilege is granted on: either a database or a measurement pattern.
+func privilegeTargetString(database, policy, measurement string, regex *RegexLiteral) string {
+	if measurement == "" && regex == nil {
+		return database
+	}
+
+	var buf bytes.Buffer
+	_, _ = buf.WriteString(QuoteIdent([]string{database}))
+	_ = buf.WriteByte('.')
+	if policy != "" {
+		_, _ = buf.WriteString(QuoteIdent([]string{policy}))
+	}
+	_ = buf.WriteByte('.')
+	if regex != nil {
+		_, _ = buf.WriteString(regex.String())
+	} else {
+		_, _ = buf.WriteString(QuoteIdent([]string{measurement}))
+	}
+	return buf.String()
+}
+
+// ShowGrantsForUserStatement represents a command for listing the privileges of a user.
+type ShowGrantsForUserStatement struct {
+	// Name of the user to list privileges for.
+	Name string
+}
+
+// String returns a string representation of the show grants statement.
+func (s *ShowGrantsForUserStatement) String() string {
+	return "SHOW GRANTS FOR " + s.Name
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowGrantsForUserStatement.
+// Users may also show their own grants; this is checked when authorizing.
+func (s *ShowGrantsForUserStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // CreateRetentionPolicyStatement represents a command to create a retention policy.
 type CreateRetentionPolicyStatement struct {
 	// Name of policy to create.
@@ -453,6 +678,9 @@ type CreateRetentionPolicyStatement struct {
 	// Replication factor for data written to this policy.
 	Replication int
 
+	// Maximum size in bytes of the data in this policy. Unlimited if zero.
+	MaxSize int64
+
 	// Should this policy be set as default for the database?
 	Default bool
 }
@@ -468,6 +696,10 @@ func (s *CreateRetentionPolicyStatement) String() string {
 	_, _ = buf.WriteString(FormatDuration(s.Duration))
 	_, _ = buf.WriteString(" REPLICATION ")
 	_, _ = buf.WriteString(strconv.Itoa(s.Replication))
+	if s.MaxSize > 0 {
+		_, _ = buf.WriteString(" SIZE ")
+		_, _ = buf.WriteString(strconv.FormatInt(s.MaxSize, 10))
+	}
 	if s.Default {
 		_, _ = buf.WriteString(" DEFAULT")
 	}
@@ -493,6 +725,9 @@ type AlterRetentionPolicyStatement struct {
 	// Replication factor for data written to this policy.
 	Replication *int
 
+	// Maximum size in bytes of the data in this policy. Unlimited if zero.
+	MaxSize *int64
+
 	// Should this policy be set as defalut for the database?
 	Default bool
 }
@@ -515,6 +750,11 @@ func (s *AlterRetentionPolicyStatement) String() string {
 		_, _ = buf.WriteString(strconv.Itoa(*s.Replication))
 	}
 
+	if s.MaxSize != nil {
+		_, _ = buf.WriteString(" SIZE ")
+		_, _ = buf.WriteString(strconv.FormatInt(*s.MaxSize, 10))
+	}
+
 	if s.Default {
 		_, _ = buf.WriteString(" DEFAULT")
 	}
@@ -547,13 +787,24 @@ type SelectStatement struct {
 	// Fields to sort results by
 	SortFields SortFields
 
-	// Maximum number of rows to be returned.
+	// Maximum number of rows to be returned per series.
 	// Unlimited if zero.
 	Limit int
 
-	// Returns rows starting at an offset from the first row.
+	// Returns rows starting at an offset from the first row of each series.
 	Offset int
 
+	// Maximum number of series to be returned.
+	// Unlimited if zero.
+	SLimit int
+
+	// Returns series starting at an offset from the first series.
+	SOffset int
+
+	// Time zone used for interval boundaries and returned timestamps.
+	// Defaults to UTC if nil.
+	Location *time.Location
+
 	// memoize the group by interval
 	groupByInterval time.Duration
 
@@ -571,6 +822,9 @@ func (s *SelectStatement) Clone() *SelectStatement {
 		Condition:  CloneExpr(s.Condition),
 		Limit:      s.Limit,
 		Offset:     s.Offset,
+		SLimit:     s.SLimit,
+		SOffset:    s.SOffset,
+		Location:   s.Location,
 	}
 	if s.Target != nil {
 		other.Target = &Target{Measurement: s.Target.Measurement, Database: s.Target.Database}
@@ -608,6 +862,8 @@ func cloneSource(s Source) Source {
 			other.Measurements[i] = &Measurement{Name: m.Name}
 		}
 		return other
+	case *SubQuery:
+		return &SubQuery{Statement: s.Statement.Clone()}
 	default:
 		panic("unreachable")
 	}
@@ -615,7 +871,8 @@ func cloneSource(s Source) Source {
 
 // RewriteWildcards returns the re-written form of the select statement. Any wildcard query
 // fields are replaced with the supplied fields, and any wildcard GROUP BY fields are replaced
-// with the supplied dimensions.
+// with the supplied dimensions. Regex GROUP BY fields are replaced with the supplied
+// dimensions whose tag keys match the expression.
 func (s *SelectStatement) RewriteWildcards(fields Fields, dimensions Dimensions) *SelectStatement {
 	other := s.Clone()
 
@@ -634,9 +891,15 @@ func (s *SelectStatement) RewriteWildcards(fields Fields, dimensions Dimensions)
 	// Rewrite all wildcard GROUP BY fields
 	rwDimensions := make(Dimensions, 0, len(s.Dimensions))
 	for _, d := range s.Dimensions {
-		switch d.Expr.(type) {
+		switch expr := d.Expr.(type) {
 		case *Wildcard:
 			rwDimensions = append(rwDimensions, dimensions...)
+		case *RegexLiteral:
+			for _, dim := range dimensions {
+				if ref, ok := dim.Expr.(*VarRef); ok && expr.Val.MatchString(ref.Val) {
+					rwDimensions = append(rwDimensions, dim)
+				}
+			}
 		default:
 			rwDimensions = append(rwDimensions, d)
 		}
@@ -677,6 +940,15 @@ func (s *SelectStatement) String() string {
 		_, _ = buf.WriteString(" OFFSET ")
 		_, _ = buf.WriteString(strconv.Itoa(s.Offset))
 	}
+	if s.SLimit > 0 {
+		_, _ = fmt.Fprintf(&buf, " SLIMIT %d", s.SLimit)
+	}
+	if s.SOffset > 0 {
+		_, _ = fmt.Fprintf(&buf, " SOFFSET %d", s.SOffset)
+	}
+	if s.Location != nil {
+		_, _ = fmt.Fprintf(&buf, " tz(%s)", QuoteString(s.Location.String()))
+	}
 	return buf.String()
 }
 
@@ -685,7 +957,13 @@ func (s *SelectStatement) RequiredPrivileges() ExecutionPrivileges {
 	ep := ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 
 	if s.Target != nil {
-		p := ExecutionPrivilege{Name: s.Target.Database, Privilege: WritePrivilege}
+		// A fully qualified target measurement overrides the ON database.
+		name := s.Target.Database
+		if a, err := SplitIdent(s.Target.Measurement); err == nil && len(a) == 3 {
+			name = a[0]
+		}
+
+		p := ExecutionPrivilege{Name: name, Privilege: WritePrivilege}
 		ep = append(ep, p)
 	}
 	return ep
@@ -727,7 +1005,8 @@ func (s *SelectStatement) walkForTime(node Node) bool {
 	}
 }
 
-// HasWildcard returns whether or not the select statement has at least 1 wildcard
+// HasWildcard returns whether or not the select statement has at least 1 wildcard.
+// Regex dimensions are treated as wildcards since they are expanded in the same way.
 func (s *SelectStatement) HasWildcard() bool {
 	for _, f := range s.Fields {
 		_, ok := f.Expr.(*Wildcard)
@@ -737,8 +1016,8 @@ func (s *SelectStatement) HasWildcard() bool {
 	}
 
 	for _, d := range s.Dimensions {
-		_, ok := d.Expr.(*Wildcard)
-		if ok {
+		switch d.Expr.(type) {
+		case *Wildcard, *RegexLiteral:
 			return true
 		}
 	}
@@ -760,9 +1039,9 @@ func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
 
 	for _, d := range s.Dimensions {
 		if call, ok := d.Expr.(*Call); ok && strings.ToLower(call.Name) == "time" {
-			// Make sure there is exactly one argument.
-			if len(call.Args) != 1 {
-				return 0, errors.New("time dimension expected one argument")
+			// Make sure there is an interval and an optional offset.
+			if len(call.Args) != 1 && len(call.Args) != 2 {
+				return 0, errors.New("time dimension expected one or two arguments")
 			}
 
 			// Ensure the argument is a duration.
@@ -777,6 +1056,66 @@ func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
 	return 0, nil
 }
 
+// GroupByOffset extracts the offset of the time interval, if specified.
+func (s *SelectStatement) GroupByOffset() (time.Duration, error) {
+	for _, d := range s.Dimensions {
+		if call, ok := d.Expr.(*Call); ok && strings.ToLower(call.Name) == "time" {
+			if len(call.Args) != 2 {
+				return 0, nil
+			}
+
+			// Ensure the argument is a duration.
+			lit, ok := call.Args[1].(*DurationLiteral)
+			if !ok {
+				return 0, errors.New("time dimension offset must be a duration")
+			}
+			return lit.Val, nil
+		}
+	}
+	return 0, nil
+}
+
+// GroupByIntervalStart returns the start of the GROUP BY time() interval containing t.
+// Intervals are aligned the same way as the statement's results, including any
+// offset and time zone.
+func (s *SelectStatement) GroupByIntervalStart(t time.Time) (time.Time, error) {
+	m, err := s.intervalMapper()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, m.truncate(t.UnixNano())).UTC(), nil
+}
+
+// NextGroupByInterval returns the start of the GROUP BY time() interval following
+// the one starting at t.
+func (s *SelectStatement) NextGroupByInterval(t time.Time) (time.Time, error) {
+	m, err := s.intervalMapper()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, m.next(t.UnixNano())).UTC(), nil
+}
+
+// intervalMapper returns a mapper aligned to the statement's GROUP BY time() interval.
+func (s *SelectStatement) intervalMapper() (*Mapper, error) {
+	interval, err := s.GroupByInterval()
+	if err != nil {
+		return nil, err
+	} else if interval == 0 {
+		return nil, errors.New("time dimension required")
+	}
+
+	offset, err := s.GroupByOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewMapper(nil, nil, interval)
+	m.offset = offset.Nanoseconds()
+	m.location = s.Location
+	return m, nil
+}
+
 // SetTimeRange sets the start and end time of the select statement to [start, end). i.e. start inclusive, end exclusive.
 // This is used commonly for continuous queries so the start and end are in buckets.
 func (s *SelectStatement) SetTimeRange(start, end time.Time) error {
@@ -856,11 +1195,14 @@ func (s *SelectStatement) Substatement(ref *VarRef) (*SelectStatement, error) {
 		Dimensions: s.Dimensions,
 		Limit:      s.Limit,
 		Offset:     s.Offset,
+		SLimit:     s.SLimit,
+		SOffset:    s.SOffset,
 		SortFields: s.SortFields,
 	}
 
 	// If there is only one series source then return it with the whole condition.
-	if _, ok := s.Source.(*Measurement); ok {
+	switch s.Source.(type) {
+	case *Measurement, *SubQuery:
 		other.Source = s.Source
 		other.Condition = s.Condition
 		return other, nil
@@ -994,6 +1336,29 @@ func (s *DeleteStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: WritePrivilege}}
 }
 
+// ShowSeriesCardinalityStatement represents a command for counting the series in the database.
+type ShowSeriesCardinalityStatement struct {
+	// Measurement(s) the series are listed for.
+	Source Source
+}
+
+// String returns a string representation of the statement.
+func (s *ShowSeriesCardinalityStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SHOW SERIES CARDINALITY")
+
+	if s.Source != nil {
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(s.Source.String())
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowSeriesCardinalityStatement.
+func (s *ShowSeriesCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+}
+
 // ShowSeriesStatement represents a command for listing series in the database.
 type ShowSeriesStatement struct {
 	// Measurement(s) the series are listed for.
@@ -1092,6 +1457,17 @@ func (s *ShowContinuousQueriesStatement) RequiredPrivileges() ExecutionPrivilege
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 }
 
+// ShowContinuousQueryStatsStatement represents a command for listing continuous query execution statistics.
+type ShowContinuousQueryStatsStatement struct{}
+
+// String returns a string representation of the show continuous query stats statement.
+func (s *ShowContinuousQueryStatsStatement) String() string { return "SHOW CONTINUOUS QUERY STATS" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowContinuousQueryStatsStatement.
+func (s *ShowContinuousQueryStatsStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+}
+
 // ShowDatabasesStatement represents a command for listing all databases in the cluster.
 type ShowDatabasesStatement struct{}
 
@@ -1111,13 +1487,31 @@ type CreateContinuousQueryStatement struct {
 	// Name of the database to create the continuous query on.
 	Database string
 
+	// How often the query is run. Defaults to the server settings if zero.
+	ResampleEvery time.Duration
+
+	// How far back the query recomputes intervals. Defaults to the server settings if zero.
+	ResampleFor time.Duration
+
 	// Source of data (SELECT statement).
 	Source *SelectStatement
 }
 
 // String returns a string representation of the statement.
 func (s *CreateContinuousQueryStatement) String() string {
-	return fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s BEGIN %s END", s.Name, s.Database, s.Source.String())
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "CREATE CONTINUOUS QUERY %s ON %s ", s.Name, s.Database)
+	if s.ResampleEvery > 0 || s.ResampleFor > 0 {
+		_, _ = buf.WriteString("RESAMPLE ")
+		if s.ResampleEvery > 0 {
+			_, _ = fmt.Fprintf(&buf, "EVERY %s ", FormatDuration(s.ResampleEvery))
+		}
+		if s.ResampleFor > 0 {
+			_, _ = fmt.Fprintf(&buf, "FOR %s ", FormatDuration(s.ResampleFor))
+		}
+	}
+	_, _ = fmt.Fprintf(&buf, "BEGIN %s END", s.Source.String())
+	return buf.String()
 }
 
 // RequiredPrivileges returns the privilege required to execute a CreateContinuousQueryStatement.
@@ -1140,6 +1534,167 @@ func (s *CreateContinuousQueryStatement) RequiredPrivileges() ExecutionPrivilege
 	return ep
 }
 
+// BackfillContinuousQueryStatement represents a command for computing a continuous query over historical data.
+type BackfillContinuousQueryStatement struct {
+	// Name of the continuous query to be backfilled.
+	Name string
+
+	// Name of the database the continuous query is on.
+	Database string
+
+	// Time range to compute the continuous query over.
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// String returns a string representation of the statement.
+func (s *BackfillContinuousQueryStatement) String() string {
+	return fmt.Sprintf("BACKFILL CONTINUOUS QUERY %s ON %s FROM %s TO %s", s.Name, s.Database,
+		QuoteString(s.StartTime.UTC().Format(time.RFC3339Nano)), QuoteString(s.EndTime.UTC().Format(time.RFC3339Nano)))
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a BackfillContinuousQueryStatement.
+// Write privilege on the database the continuous query writes into is also required
+// but can only be resolved by the server.
+func (s *BackfillContinuousQueryStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: s.Database, Privilege: WritePrivilege}}
+}
+
+// CreateDownsampleStatement represents a command for rolling up every measurement
+// in a retention policy into another retention policy.
+type CreateDownsampleStatement struct {
+	// Name of the database the downsample is on.
+	Database string
+
+	// Retention policies to read from and write into.
+	Source string
+	Target string
+
+	// Aggregate functions to apply to each numeric field.
+	Aggregates []string
+
+	// Interval to aggregate over.
+	Interval time.Duration
+}
+
+// String returns a string representation of the statement.
+func (s *CreateDownsampleStatement) String() string {
+	return fmt.Sprintf("CREATE DOWNSAMPLE ON %s FROM %s TO %s AGGREGATE %s EVERY %s", s.Database, s.Source, s.Target,
+		strings.Join(s.Aggregates, ", "), FormatDuration(s.Interval))
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a CreateDownsampleStatement.
+func (s *CreateDownsampleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// DropDownsampleStatement represents a command for removing a downsample.
+type DropDownsampleStatement struct {
+	// Name of the database the downsample is on.
+	Database string
+
+	// Retention policies the downsample reads from and writes into.
+	Source string
+	Target string
+}
+
+// String returns a string representation of the statement.
+func (s *DropDownsampleStatement) String() string {
+	return fmt.Sprintf("DROP DOWNSAMPLE ON %s FROM %s TO %s", s.Database, s.Source, s.Target)
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a DropDownsampleStatement.
+func (s *DropDownsampleStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowDataNodesStatement represents a command for listing all data nodes in the cluster.
+type ShowDataNodesStatement struct{}
+
+// String returns a string representation of the statement.
+func (s *ShowDataNodesStatement) String() string { return "SHOW DATA NODES" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowDataNodesStatement.
+func (s *ShowDataNodesStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// DropDataNodeStatement represents a command for removing a data node from the cluster.
+type DropDataNodeStatement struct {
+	// ID of the data node to be dropped.
+	ID uint64
+}
+
+// String returns a string representation of the statement.
+func (s *DropDataNodeStatement) String() string {
+	return fmt.Sprintf("DROP DATA NODE %d", s.ID)
+}
+
+// RequiredPrivileges returns the privilege required to execute a DropDataNodeStatement.
+func (s *DropDataNodeStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowBrokersStatement represents a command for listing all brokers in the cluster.
+type ShowBrokersStatement struct{}
+
+// String returns a string representation of the statement.
+func (s *ShowBrokersStatement) String() string { return "SHOW BROKERS" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowBrokersStatement.
+func (s *ShowBrokersStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowShardsStatement represents a command for listing all shards in the cluster.
+type ShowShardsStatement struct{}
+
+// String returns a string representation of the statement.
+func (s *ShowShardsStatement) String() string { return "SHOW SHARDS" }
+
+// RequiredPrivileges returns the privilege required to execute a ShowShardsStatement.
+func (s *ShowShardsStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowShardGroupsStatement represents a command for listing shard groups.
+type ShowShardGroupsStatement struct {
+	// Name of the database to list shard groups for. Lists all databases if blank.
+	Database string
+}
+
+// String returns a string representation of the statement.
+func (s *ShowShardGroupsStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SHOW SHARD GROUPS")
+	if s.Database != "" {
+		_, _ = buf.WriteString(" ON ")
+		_, _ = buf.WriteString(s.Database)
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowShardGroupsStatement.
+func (s *ShowShardGroupsStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// DropShardStatement represents a command for removing a shard from the cluster.
+type DropShardStatement struct {
+	// ID of the shard to be dropped.
+	ID uint64
+}
+
+// String returns a string representation of the statement.
+func (s *DropShardStatement) String() string {
+	return fmt.Sprintf("DROP SHARD %d", s.ID)
+}
+
+// RequiredPrivileges returns the privilege required to execute a DropShardStatement.
+func (s *DropShardStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // DropContinuousQueryStatement represents a command for removing a continuous query.
 type DropContinuousQueryStatement struct {
 	Name string
@@ -1345,6 +1900,37 @@ func (s *ShowTagValuesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 }
 
+// ShowTagValuesCardinalityStatement represents a command for counting the values of each tag key.
+type ShowTagValuesCardinalityStatement struct {
+	// Data source that tag values are counted from.
+	Source Source
+
+	// Tag key(s) to count values for. All keys if empty.
+	TagKeys []string
+}
+
+// String returns a string representation of the statement.
+func (s *ShowTagValuesCardinalityStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SHOW TAG VALUES CARDINALITY")
+
+	if s.Source != nil {
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(s.Source.String())
+	}
+	if len(s.TagKeys) > 0 {
+		_, _ = buf.WriteString(" WITH KEY IN (")
+		_, _ = buf.WriteString(strings.Join(s.TagKeys, ", "))
+		_, _ = buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowTagValuesCardinalityStatement.
+func (s *ShowTagValuesCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+}
+
 // ShowUsersStatement represents a command for listing users.
 type ShowUsersStatement struct{}
 
@@ -1358,6 +1944,19 @@ func (s *ShowUsersStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// ShowRolesStatement represents a command for listing roles.
+type ShowRolesStatement struct{}
+
+// String returns a string representation of the ShowRolesStatement.
+func (s *ShowRolesStatement) String() string {
+	return "SHOW ROLES"
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowRolesStatement.
+func (s *ShowRolesStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowFieldKeysStatement represents a command for listing field keys.
 type ShowFieldKeysStatement struct {
 	// Data source that fields are extracted from.
@@ -1471,14 +2070,16 @@ func (a Dimensions) Normalize() (time.Duration, []string, error) {
 	for _, dim := range a {
 		switch expr := dim.Expr.(type) {
 		case *Call:
-			// Ensure the call is time() and it only has one duration argument.
+			// Ensure the call is time() and it only has a duration and an optional offset.
 			// If we already have a duration
 			if strings.ToLower(expr.Name) != "time" {
 				return 0, nil, errors.New("only time() calls allowed in dimensions")
-			} else if len(expr.Args) != 1 {
-				return 0, nil, errors.New("time dimension expected one argument")
+			} else if len(expr.Args) != 1 && len(expr.Args) != 2 {
+				return 0, nil, errors.New("time dimension expected one or two arguments")
 			} else if lit, ok := expr.Args[0].(*DurationLiteral); !ok {
 				return 0, nil, errors.New("time dimension must have one duration argument")
+			} else if _, ok := expr.Args[len(expr.Args)-1].(*DurationLiteral); !ok {
+				return 0, nil, errors.New("time dimension offset must be a duration")
 			} else if dur != 0 {
 				return 0, nil, errors.New("multiple time dimensions not allowed")
 			} else {
@@ -1488,6 +2089,9 @@ func (a Dimensions) Normalize() (time.Duration, []string, error) {
 		case *VarRef:
 			tags = append(tags, expr.Val)
 
+		case *Wildcard, *RegexLiteral:
+			return 0, nil, errors.New("wildcard and regex dimensions must be expanded to tag keys")
+
 		default:
 			return 0, nil, errors.New("only time and tag dimensions allowed")
 		}
@@ -1544,6 +2148,16 @@ func (m *Merge) String() string {
 	return fmt.Sprintf("merge(%s)", m.Measurements.String())
 }
 
+// SubQuery represents a datasource created from the results of a select statement.
+type SubQuery struct {
+	Statement *SelectStatement
+}
+
+// String returns a string representation of the subquery.
+func (s *SubQuery) String() string {
+	return fmt.Sprintf("(%s)", s.Statement.String())
+}
+
 // VarRef represents a reference to a variable.
 type VarRef struct {
 	Val string
@@ -1666,7 +2280,9 @@ type RegexLiteral struct {
 }
 
 // String returns a string representation of the literal.
-func (r *RegexLiteral) String() string { return r.Val.String() }
+func (r *RegexLiteral) String() string {
+	return "/" + strings.Replace(r.Val.String(), "/", `\/`, -1) + "/"
+}
 
 // Wildcard represents a wild card expression.
 type Wildcard struct{}
@@ -1806,6 +2422,9 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Source)
 		Walk(v, n.Condition)
 
+	case *SubQuery:
+		Walk(v, n.Statement)
+
 	case *ShowSeriesStatement:
 		Walk(v, n.Source)
 		Walk(v, n.Condition)
@@ -1883,6 +2502,9 @@ func Rewrite(r Rewriter, node Node) Node {
 		n.Source = Rewrite(r, n.Source).(Source)
 		n.Condition = Rewrite(r, n.Condition).(Expr)
 
+	case *SubQuery:
+		n.Statement = Rewrite(r, n.Statement).(*SelectStatement)
+
 	case Fields:
 		for i, f := range n {
 			n[i] = Rewrite(r, f).(*Field)
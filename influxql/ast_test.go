@@ -114,6 +114,50 @@ func TestSelectStatement_GroupByInterval(t *testing.T) {
 	}
 }
 
+// Ensure the SELECT statement can extract the GROUP BY interval offset.
+func TestSelectStatement_GroupByOffset(t *testing.T) {
+	s := MustParseSelectStatement("SELECT sum(value) from foo GROUP BY time(1d, 6h)")
+	if d, err := s.GroupByInterval(); err != nil {
+		t.Fatalf("error parsing group by interval: %s", err)
+	} else if d != 24*time.Hour {
+		t.Fatalf("group by interval not equal:\nexp=%s\ngot=%s", 24*time.Hour, d)
+	}
+	if d, err := s.GroupByOffset(); err != nil {
+		t.Fatalf("error parsing group by offset: %s", err)
+	} else if d != 6*time.Hour {
+		t.Fatalf("group by offset not equal:\nexp=%s\ngot=%s", 6*time.Hour, d)
+	}
+}
+
+// Ensure the SELECT statement aligns times to its GROUP BY intervals.
+func TestSelectStatement_GroupByIntervalStart(t *testing.T) {
+	for i, tt := range []struct {
+		q     string
+		t     string
+		start string
+		next  string
+	}{
+		{q: `SELECT sum(value) FROM foo GROUP BY time(10m)`, t: `2000-01-01T00:25:00Z`, start: `2000-01-01T00:20:00Z`, next: `2000-01-01T00:30:00Z`},
+		{q: `SELECT sum(value) FROM foo GROUP BY time(1d, 6h)`, t: `2000-01-02T03:00:00Z`, start: `2000-01-01T06:00:00Z`, next: `2000-01-02T06:00:00Z`},
+		{q: `SELECT sum(value) FROM foo GROUP BY time(1d) tz('America/Chicago')`, t: `2000-01-01T03:00:00Z`, start: `1999-12-31T06:00:00Z`, next: `2000-01-01T06:00:00Z`},
+	} {
+		s := MustParseSelectStatement(tt.q)
+		start, err := s.GroupByIntervalStart(mustParseTime(tt.t))
+		if err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, err)
+		} else if !start.Equal(mustParseTime(tt.start)) {
+			t.Fatalf("%d. start mismatch: exp=%s, got=%s", i, tt.start, start)
+		}
+
+		next, err := s.NextGroupByInterval(start)
+		if err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, err)
+		} else if !next.Equal(mustParseTime(tt.next)) {
+			t.Fatalf("%d. next mismatch: exp=%s, got=%s", i, tt.next, next)
+		}
+	}
+}
+
 // Ensure the SELECT statment can have its start and end time set
 func TestSelectStatement_SetTimeRange(t *testing.T) {
 	q := "SELECT sum(value) from foo GROUP BY time(10m)"
@@ -367,6 +411,24 @@ func TestSelectStatement_RewriteWildcards(t *testing.T) {
 			rewrite: `SELECT value FROM cpu GROUP BY host, region, host, region`,
 		},
 
+		// GROUP BY regex
+		{
+			stmt:    `SELECT value FROM cpu GROUP BY /^reg/`,
+			rewrite: `SELECT value FROM cpu GROUP BY region`,
+		},
+
+		// GROUP BY regex with time
+		{
+			stmt:    `SELECT value FROM cpu GROUP BY time(1m), /o/`,
+			rewrite: `SELECT value FROM cpu GROUP BY time(1m), host, region`,
+		},
+
+		// GROUP BY regex without matches
+		{
+			stmt:    `SELECT value FROM cpu GROUP BY time(1m), /^foo/`,
+			rewrite: `SELECT value FROM cpu GROUP BY time(1m)`,
+		},
+
 		// Combo
 		{
 			stmt:    `SELECT * FROM cpu GROUP BY *`,
@@ -93,6 +93,24 @@ func (p *Planner) Plan(stmt *SelectStatement) (*Executor, error) {
 	e.interval = interval
 	e.tags = tags
 
+	// Determine the interval offset and time zone.
+	if e.offset, err = stmt.GroupByOffset(); err != nil {
+		return nil, err
+	}
+	e.location = stmt.Location
+	if e.location == nil {
+		e.location = time.UTC
+	}
+
+	// Execute the inner statement if the source is a subquery.
+	if sq, ok := stmt.Source.(*SubQuery); ok {
+		rows, err := p.executeSubQuery(sq)
+		if err != nil {
+			return nil, err
+		}
+		e.subRows = rows
+	}
+
 	// Generate a processor for each field.
 	e.processors = make([]Processor, 0)
 	if v, ok := stmt.Fields[0].Expr.(*VarRef); ok { // this is a raw query so we handle it differently
@@ -114,6 +132,26 @@ func (p *Planner) Plan(stmt *SelectStatement) (*Executor, error) {
 	return e, nil
 }
 
+// executeSubQuery plans and executes the statement of a subquery source.
+// The resulting rows are buffered so they can be fed into the outer mappers.
+func (p *Planner) executeSubQuery(sq *SubQuery) ([]*Row, error) {
+	e, err := p.Plan(sq.Statement)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := e.Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*Row
+	for row := range ch {
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 func (p *Planner) planField(e *Executor, f *Field) (Processor, error) {
 	return p.planExpr(e, f.Expr)
 }
@@ -145,6 +183,9 @@ func (p *Planner) planExpr(e *Executor, expr Expr) (Processor, error) {
 // planCall generates a processor for a function call.
 func (p *Planner) planRawQuery(e *Executor, v *VarRef) (Processor, error) {
 	stmt := e.stmt
+	if _, ok := stmt.Source.(*SubQuery); ok {
+		return nil, errors.New("raw fields are not supported on subqueries")
+	}
 	stmt.RawQuery = true
 
 	// Retrieve a list of iterators for the substatement.
@@ -161,7 +202,7 @@ func (p *Planner) planRawQuery(e *Executor, v *VarRef) (Processor, error) {
 	// Create mapper and reducer.
 	mappers := make([]*Mapper, len(itrs))
 	for i, itr := range itrs {
-		mappers[i] = NewMapper(MapRawQuery, itr, e.interval)
+		mappers[i] = e.newMapper(MapRawQuery, itr)
 	}
 	r := NewReducer(ReduceRawQuery, mappers)
 	r.name = lastIdent(stmt.Source.(*Measurement).Name)
@@ -195,7 +236,7 @@ func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
 	}
 
 	// Retrieve a list of iterators for the substatement.
-	itrs, err := e.tx.CreateIterators(stmt)
+	itrs, err := e.createIterators(stmt)
 	if err != nil {
 		return nil, err
 	}
@@ -235,14 +276,25 @@ func (p *Planner) planCall(e *Executor, c *Call) (Processor, error) {
 	// Create mapper and reducer.
 	mappers := make([]*Mapper, len(itrs))
 	for i, itr := range itrs {
-		mappers[i] = NewMapper(mapFn, itr, e.interval)
+		mappers[i] = e.newMapper(mapFn, itr)
 	}
 	r := NewReducer(reduceFn, mappers)
-	r.name = lastIdent(stmt.Source.(*Measurement).Name)
+	r.name = sourceName(stmt.Source)
 
 	return r, nil
 }
 
+// sourceName returns the name of the rows generated from a source.
+func sourceName(src Source) string {
+	switch src := src.(type) {
+	case *Measurement:
+		return lastIdent(src.Name)
+	case *SubQuery:
+		return sourceName(src.Statement.Source)
+	}
+	return ""
+}
+
 // planBinaryExpr generates a processor for a binary expression.
 // A binary expression represents a join operator between two processors.
 func (p *Planner) planBinaryExpr(e *Executor, expr *BinaryExpr) (Processor, error) {
@@ -269,7 +321,10 @@ type Executor struct {
 	stmt       *SelectStatement // original statement
 	processors []Processor      // per-field processors
 	interval   time.Duration    // group by interval
+	offset     time.Duration    // group by interval offset
+	location   *time.Location   // time zone for intervals & timestamps
 	tags       []string         // dimensional tag keys
+	subRows    []*Row           // subquery source rows
 }
 
 // newExecutor returns an executor associated with a transaction and statement.
@@ -280,6 +335,62 @@ func newExecutor(tx Tx, stmt *SelectStatement) *Executor {
 	}
 }
 
+// newMapper returns a mapper aligned to the executor's interval, offset and time zone.
+func (e *Executor) newMapper(fn MapFunc, itr Iterator) *Mapper {
+	m := NewMapper(fn, itr, e.interval)
+	m.offset = e.offset.Nanoseconds()
+	m.location = e.location
+	return m
+}
+
+// createIterators returns iterators for a single field substatement.
+// Subquery sources are read from the buffered rows instead of the transaction.
+func (e *Executor) createIterators(stmt *SelectStatement) ([]Iterator, error) {
+	if _, ok := stmt.Source.(*SubQuery); !ok {
+		return e.tx.CreateIterators(stmt)
+	}
+
+	// Restrict the points to the time range of the outer statement.
+	tmin, tmax := TimeRange(stmt.Condition)
+
+	// Create an iterator for every row that contains the field.
+	name := stmt.Fields[0].Expr.(*VarRef).Val
+	var itrs []Iterator
+	for _, row := range e.subRows {
+		index := -1
+		for i, column := range row.Columns {
+			if column == name {
+				index = i
+				break
+			}
+		}
+		if index < 1 {
+			continue
+		}
+
+		// Encode the tag values for the outer dimensions.
+		values := make([]string, len(e.tags))
+		for i, key := range e.tags {
+			values[i] = row.Tags[key]
+		}
+
+		itrs = append(itrs, &rowIterator{
+			tags:   string(MarshalStrings(values)),
+			values: row.Values,
+			index:  index,
+			tmin:   tmin,
+			tmax:   tmax,
+		})
+	}
+
+	// A subquery without rows has an empty result, so only report a missing field
+	// if the subquery returned rows.
+	if len(itrs) == 0 && len(e.subRows) > 0 {
+		return nil, fmt.Errorf("field not found in subquery: %s", name)
+	}
+	return itrs, nil
+}
+
 // Execute begins execution of the query and returns a channel to receive rows.
 func (e *Executor) Execute() (<-chan *Row, error) {
 	// Open transaction.
@@ -345,20 +456,24 @@ loop:
 		}
 	}
 
+	// Sort rows by their tag sets so series are returned in the same order they are paged.
+	tagSets := make(TagSets, 0, len(rows))
+	for tagset := range rows {
+		tagSets = append(tagSets, tagset)
+	}
+	sort.Sort(tagSets)
+
 	// Normalize rows and values.
 	// Convert all times to timestamps
-	a := make(Rows, 0, len(rows))
-	for _, row := range rows {
+	for _, tagset := range tagSets {
+		row := rows[tagset]
+		row.Values = limitValues(row.Values, e.stmt.Limit, e.stmt.Offset)
 		for _, values := range row.Values {
 			t := time.Unix(0, values[0].(int64))
-			values[0] = t.UTC()
+			values[0] = t.In(e.location)
 		}
-		a = append(a, row)
-	}
-	sort.Sort(a)
 
-	// Send rows to the channel.
-	for _, row := range a {
+		// Send row to the channel.
 		out <- row
 	}
 
@@ -366,6 +481,19 @@ loop:
 	close(out)
 }
 
+// limitValues returns the values of a series after applying a point limit & offset.
+func limitValues(values [][]interface{}, limit, offset int) [][]interface{} {
+	if offset >= len(values) {
+		return nil
+	}
+	values = values[offset:]
+
+	if limit > 0 && limit < len(values) {
+		values = values[:limit]
+	}
+	return values
+}
+
 // creates a new value set if one does not already exist for a given tagset + timestamp.
 func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, name string, timestamp int64, tagset string) (*Row, []interface{}) {
 	// TODO: Add "name" to lookup key.
@@ -408,9 +536,11 @@ func (e *Executor) createRowValuesIfNotExists(rows map[string]*Row, name string,
 
 // Mapper represents an object for processing iterators.
 type Mapper struct {
-	fn       MapFunc  // map function
-	itr      Iterator // iterators
-	interval int64    // grouping interval
+	fn       MapFunc        // map function
+	itr      Iterator       // iterators
+	interval int64          // grouping interval
+	offset   int64          // grouping interval offset
+	location *time.Location // time zone of interval boundaries
 }
 
 // NewMapper returns a new instance of Mapper with a given function and interval.
@@ -442,13 +572,14 @@ func (m *Mapper) run(e *Emitter) {
 	if m.interval > 0 {
 		// Align start time to interval.
 		tmin, _, _ = bufItr.Peek()
-		tmin -= (tmin % m.interval)
+		tmin = m.truncate(tmin)
 	}
 
 	for {
 		// Set the upper bound of the interval.
+		tmax := m.next(tmin)
 		if m.interval > 0 {
-			bufItr.tmax = tmin + m.interval - 1
+			bufItr.tmax = tmax - 1
 		}
 
 		// Exit if there was only one interval or no more data is available.
@@ -460,8 +591,83 @@ func (m *Mapper) run(e *Emitter) {
 		m.fn(bufItr, e, tmin)
 
 		// Move the interval forward.
-		tmin += m.interval
+		tmin = tmax
+	}
+}
+
+// truncate returns the start of the interval containing the timestamp.
+// Intervals are aligned to the wall clock of the mapper's time zone, shifted by the offset.
+func (m *Mapper) truncate(t int64) int64 {
+	local := m.toLocal(t) - m.offset
+	mod := local % m.interval
+	if mod < 0 {
+		mod += m.interval
+	}
+	return m.fromLocal(local - mod + m.offset)
+}
+
+// next returns the start of the interval following the one starting at tmin.
+// The interval may be shorter or longer than the grouping interval across DST transitions.
+func (m *Mapper) next(tmin int64) int64 {
+	if m.location == nil || m.location == time.UTC {
+		return tmin + m.interval
 	}
+	return m.fromLocal(m.toLocal(tmin) + m.interval)
+}
+
+// toLocal converts a timestamp to nanoseconds since the epoch on the local wall clock.
+func (m *Mapper) toLocal(t int64) int64 {
+	if m.location == nil {
+		return t
+	}
+	_, zone := time.Unix(0, t).In(m.location).Zone()
+	return t + int64(zone)*int64(time.Second)
+}
+
+// fromLocal converts nanoseconds since the epoch on the local wall clock to a timestamp.
+func (m *Mapper) fromLocal(local int64) int64 {
+	if m.location == nil {
+		return local
+	}
+
+	// Guess using the offset at the wall clock time and then correct using the
+	// offset at the guessed time in case a DST transition lies in between.
+	_, zone := time.Unix(0, local).In(m.location).Zone()
+	_, zone = time.Unix(0, local-int64(zone)*int64(time.Second)).In(m.location).Zone()
+	return local - int64(zone)*int64(time.Second)
+}
+
+// rowIterator represents an iterator over a single column of a row.
+// It is used to feed the output of a subquery into the mappers of the outer statement.
+type rowIterator struct {
+	tags   string          // encoded dimensional values
+	values [][]interface{} // remaining row values
+	index  int             // column index
+	tmin   time.Time       // minimum time, if set
+	tmax   time.Time       // maximum time, if set
+}
+
+// Tags returns the encoded dimensional values for the iterator.
+func (i *rowIterator) Tags() string { return i.tags }
+
+// Next returns the next timestamp and column value from the row.
+// Values that are nil or outside of the time range are skipped.
+func (i *rowIterator) Next() (key int64, data []byte, value interface{}) {
+	for len(i.values) > 0 {
+		values := i.values[0]
+		i.values = i.values[1:]
+
+		t, ok := values[0].(time.Time)
+		if !ok || values[i.index] == nil {
+			continue
+		} else if !i.tmin.IsZero() && t.Before(i.tmin) {
+			continue
+		} else if !i.tmax.IsZero() && t.After(i.tmax) {
+			continue
+		}
+		return t.UnixNano(), nil, values[i.index]
+	}
+	return 0, nil, nil
 }
 
 // bufIterator represents a buffer iterator.
@@ -1295,6 +1501,22 @@ func (p Rows) Less(i, j int) bool {
 
 func (p Rows) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
 
+// TagSets represents a list of encoded tag sets.
+// Tag sets are sorted by their decoded values in dimension order.
+type TagSets []string
+
+func (a TagSets) Len() int      { return len(a) }
+func (a TagSets) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a TagSets) Less(i, j int) bool {
+	x, y := UnmarshalStrings([]byte(a[i])), UnmarshalStrings([]byte(a[j]))
+	for k := 0; k < len(x) && k < len(y); k++ {
+		if x[k] != y[k] {
+			return x[k] < y[k]
+		}
+	}
+	return len(x) < len(y)
+}
+
 // MarshalStrings encodes an array of strings into a byte slice.
 func MarshalStrings(a []string) (ret []byte) {
 	for _, s := range a {
@@ -634,6 +634,136 @@ func TestPlanner_Plan_GroupByIntervalAndTag(t *testing.T) {
 	}
 }
 
+// Ensure the planner aligns intervals to a time zone across a DST transition.
+func TestPlanner_Plan_GroupByIntervalTimeZone(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-03-24T23:30:00Z", float64(1)},
+				{"2000-03-25T22:30:00Z", float64(2)},
+				{"2000-03-26T12:00:00Z", float64(3)},
+				{"2000-03-26T22:30:00Z", float64(4)},
+			})}, nil
+	}
+
+	// Expected resultset.
+	exp := minify(`[{
+		"name":"cpu",
+		"columns":["time","sum"],
+		"values":[
+			["2000-03-25T00:00:00+01:00",3],
+			["2000-03-26T00:00:00+01:00",3],
+			["2000-03-27T00:00:00+02:00",4]
+		]
+	}]`)
+
+	// Query for daily sums in Berlin local time.
+	rs := MustPlanAndExecute(NewDB(tx), "2000-03-28T00:00:00Z", `
+		SELECT sum(value)
+		FROM cpu
+		WHERE time >= now() - 5d
+		GROUP BY time(1d)
+		tz('Europe/Berlin')`)
+
+	// Compare resultsets.
+	if act := jsonify(rs); exp != act {
+		t.Fatalf("unexpected resultset:\n\nexp=%s\n\ngot=%s\n\n", exp, act)
+	}
+}
+
+// Ensure the planner shifts interval boundaries by the GROUP BY time() offset.
+func TestPlanner_Plan_GroupByIntervalOffset(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator(nil, []Point{
+				{"2000-01-01T05:00:00Z", float64(1)},
+				{"2000-01-01T06:00:00Z", float64(2)},
+				{"2000-01-02T05:59:59Z", float64(3)},
+			})}, nil
+	}
+
+	// Expected resultset.
+	exp := minify(`[{
+		"name":"cpu",
+		"columns":["time","sum"],
+		"values":[
+			["1999-12-31T06:00:00Z",1],
+			["2000-01-01T06:00:00Z",5]
+		]
+	}]`)
+
+	// Query for daily sums starting at 6am.
+	rs := MustPlanAndExecute(NewDB(tx), "2000-01-03T00:00:00Z", `
+		SELECT sum(value)
+		FROM cpu
+		WHERE time >= now() - 3d
+		GROUP BY time(1d, 6h)`)
+
+	// Compare resultsets.
+	if act := jsonify(rs); exp != act {
+		t.Fatalf("unexpected resultset:\n\nexp=%s\n\ngot=%s\n\n", exp, act)
+	}
+}
+
+// Ensure the planner can plan and execute an aggregate over a subquery.
+func TestPlanner_Plan_SubQuery(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return []influxql.Iterator{
+			NewIterator([]string{"servera"}, []Point{
+				{"2000-01-01T09:00:00Z", float64(10)},
+				{"2000-01-01T09:00:10Z", float64(20)},
+				{"2000-01-01T09:30:00Z", float64(40)},
+				{"2000-01-01T11:00:00Z", float64(30)},
+			}),
+			NewIterator([]string{"serverb"}, []Point{
+				{"2000-01-01T09:00:00Z", float64(100)},
+				{"2000-01-01T11:00:00Z", float64(2)},
+				{"2000-01-01T11:00:10Z", float64(4)},
+			})}, nil
+	}
+
+	// Find the maximum of the per-host means for every hour.
+	rs := MustPlanAndExecute(NewDB(tx), "2000-01-01T12:00:00Z", `
+		SELECT max(m)
+		FROM (SELECT mean(value) AS m FROM cpu WHERE time >= now() - 3h GROUP BY time(30m), host)
+		GROUP BY time(1h)`)
+
+	// Expected resultset.
+	exp := minify(`[{
+		"name":"cpu",
+		"columns":["time","max"],
+		"values":[
+			["2000-01-01T09:00:00Z",100],
+			["2000-01-01T11:00:00Z",30]
+		]
+	}]`)
+
+	// Compare resultsets.
+	if act := jsonify(rs); exp != act {
+		t.Fatalf("unexpected resultset:\n\nexp=%s\n\ngot=%s\n\n", exp, act)
+	}
+}
+
+// Ensure an aggregate over a subquery without rows returns an empty result.
+func TestPlanner_Plan_SubQuery_NoRows(t *testing.T) {
+	tx := NewTx()
+	tx.CreateIteratorsFunc = func(stmt *influxql.SelectStatement) ([]influxql.Iterator, error) {
+		return nil, nil
+	}
+
+	rs := MustPlanAndExecute(NewDB(tx), "2000-01-01T12:00:00Z", `
+		SELECT max(m)
+		FROM (SELECT mean(value) AS m FROM cpu WHERE time >= now() - 3h GROUP BY time(30m), host)
+		GROUP BY time(1h)`)
+
+	if len(rs) != 0 {
+		t.Fatalf("unexpected resultset: %s", jsonify(rs))
+	}
+}
+
 // Ensure the planner sends the correct simplified statements to the iterator creator.
 func TestPlanner_CreateIterators(t *testing.T) {
 	var flag0, flag1 bool
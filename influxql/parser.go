@@ -82,11 +82,23 @@ func (p *Parser) ParseStatement() (Statement, error) {
 	case DROP:
 		return p.parseDropStatement()
 	case GRANT:
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok == ROLE {
+			return p.parseGrantRoleStatement()
+		}
+		p.unscan()
 		return p.parseGrantStatement()
 	case REVOKE:
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok == ROLE {
+			return p.parseRevokeRoleStatement()
+		}
+		p.unscan()
 		return p.parseRevokeStatement()
 	case ALTER:
 		return p.parseAlterStatement()
+	case SET:
+		return p.parseSetPasswordUserStatement()
+	case BACKFILL:
+		return p.parseBackfillContinuousQueryStatement()
 	default:
 		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
 	}
@@ -97,8 +109,16 @@ func (p *Parser) ParseStatement() (Statement, error) {
 func (p *Parser) parseShowStatement() (Statement, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	switch tok {
+	case BROKERS:
+		return p.parseShowBrokersStatement()
 	case CONTINUOUS:
 		return p.parseShowContinuousQueriesStatement()
+	case DATA:
+		tok, pos, lit := p.scanIgnoreWhitespace()
+		if tok == NODES {
+			return p.parseShowDataNodesStatement()
+		}
+		return nil, newParseError(tokstr(tok, lit), []string{"NODES"}, pos)
 	case DATABASES:
 		return p.parseShowDatabasesStatement()
 	case FIELD:
@@ -107,6 +127,8 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 			return p.parseShowFieldKeysStatement()
 		}
 		return nil, newParseError(tokstr(tok, lit), []string{"KEYS", "VALUES"}, pos)
+	case GRANTS:
+		return p.parseShowGrantsForUserStatement()
 	case MEASUREMENTS:
 		return p.parseShowMeasurementsStatement()
 	case RETENTION:
@@ -115,13 +137,33 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 			return p.parseShowRetentionPoliciesStatement()
 		}
 		return nil, newParseError(tokstr(tok, lit), []string{"POLICIES"}, pos)
+	case ROLES:
+		return p.parseShowRolesStatement()
 	case SERIES:
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok == CARDINALITY {
+			return p.parseShowSeriesCardinalityStatement()
+		}
+		p.unscan()
 		return p.parseShowSeriesStatement()
+	case SERVERS:
+		return p.parseShowDataNodesStatement()
+	case SHARD:
+		tok, pos, lit := p.scanIgnoreWhitespace()
+		if tok == GROUPS {
+			return p.parseShowShardGroupsStatement()
+		}
+		return nil, newParseError(tokstr(tok, lit), []string{"GROUPS"}, pos)
+	case SHARDS:
+		return p.parseShowShardsStatement()
 	case TAG:
 		tok, pos, lit := p.scanIgnoreWhitespace()
 		if tok == KEYS {
 			return p.parseShowTagKeysStatement()
 		} else if tok == VALUES {
+			if tok, _, _ := p.scanIgnoreWhitespace(); tok == CARDINALITY {
+				return p.parseShowTagValuesCardinalityStatement()
+			}
+			p.unscan()
 			return p.parseShowTagValuesStatement()
 		}
 		return nil, newParseError(tokstr(tok, lit), []string{"KEYS", "VALUES"}, pos)
@@ -129,7 +171,7 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return p.parseShowUsersStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASES", "FIELD", "MEASUREMENTS", "RETENTION", "SERIES", "TAG", "USERS"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"BROKERS", "CONTINUOUS", "DATA", "DATABASES", "FIELD", "GRANTS", "MEASUREMENTS", "RETENTION", "ROLES", "SERIES", "SERVERS", "SHARD", "SHARDS", "TAG", "USERS"}, pos)
 }
 
 // parseCreateStatement parses a string and returns a create statement.
@@ -140,8 +182,12 @@ func (p *Parser) parseCreateStatement() (Statement, error) {
 		return p.parseCreateContinuousQueryStatement()
 	} else if tok == DATABASE {
 		return p.parseCreateDatabaseStatement()
+	} else if tok == DOWNSAMPLE {
+		return p.parseCreateDownsampleStatement()
 	} else if tok == USER {
 		return p.parseCreateUserStatement()
+	} else if tok == ROLE {
+		return p.parseCreateRoleStatement()
 	} else if tok == RETENTION {
 		tok, pos, lit = p.scanIgnoreWhitespace()
 		if tok != POLICY {
@@ -150,7 +196,7 @@ func (p *Parser) parseCreateStatement() (Statement, error) {
 		return p.parseCreateRetentionPolicyStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASE", "USER", "RETENTION"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASE", "DOWNSAMPLE", "USER", "ROLE", "RETENTION"}, pos)
 }
 
 // parseDropStatement parses a string and returns a drop statement.
@@ -163,13 +209,24 @@ func (p *Parser) parseDropStatement() (Statement, error) {
 		return p.parseDropMeasurementStatement()
 	} else if tok == CONTINUOUS {
 		return p.parseDropContinuousQueryStatement()
+	} else if tok == DATA {
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != NODE {
+			return nil, newParseError(tokstr(tok, lit), []string{"NODE"}, pos)
+		}
+		return p.parseDropDataNodeStatement()
 	} else if tok == DATABASE {
 		return p.parseDropDatabaseStatement()
+	} else if tok == DOWNSAMPLE {
+		return p.parseDropDownsampleStatement()
 	} else if tok == RETENTION {
 		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != POLICY {
 			return nil, newParseError(tokstr(tok, lit), []string{"POLICY"}, pos)
 		}
 		return p.parseDropRetentionPolicyStatement()
+	} else if tok == ROLE {
+		return p.parseDropRoleStatement()
+	} else if tok == SHARD {
+		return p.parseDropShardStatement()
 	} else if tok == USER {
 		return p.parseDropUserStatement()
 	}
@@ -186,9 +243,168 @@ func (p *Parser) parseAlterStatement() (Statement, error) {
 			return nil, newParseError(tokstr(tok, lit), []string{"POLICY"}, pos)
 		}
 		return p.parseAlterRetentionPolicyStatement()
+	} else if tok == USER {
+		return p.parseAlterUserStatement()
+	}
+
+	return nil, newParseError(tokstr(tok, lit), []string{"RETENTION", "USER"}, pos)
+}
+
+// parseAlterUserStatement parses a string and returns an AlterUserStatement.
+// This function assumes the "ALTER USER" tokens have already been consumed.
+func (p *Parser) parseAlterUserStatement() (*AlterUserStatement, error) {
+	stmt := &AlterUserStatement{}
+
+	// Parse the name of the user to alter.
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name
+
+	// Parse "WITH ADMIN" or "WITHOUT ADMIN".
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	switch tok {
+	case WITH:
+		stmt.Admin = true
+	case WITHOUT:
+		stmt.Admin = false
+	default:
+		return nil, newParseError(tokstr(tok, lit), []string{"WITH", "WITHOUT"}, pos)
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != ADMIN {
+		return nil, newParseError(tokstr(tok, lit), []string{"ADMIN"}, pos)
+	}
+
+	return stmt, nil
+}
+
+// parseSetPasswordUserStatement parses a string and returns a SetPasswordUserStatement.
+// This function assumes the SET token has already been consumed.
+func (p *Parser) parseSetPasswordUserStatement() (*SetPasswordUserStatement, error) {
+	stmt := &SetPasswordUserStatement{}
+
+	// Consume "PASSWORD FOR" tokens.
+	if err := p.parseTokens([]Token{PASSWORD, FOR}); err != nil {
+		return nil, err
+	}
+
+	// Parse the name of the user.
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name
+
+	// Consume the "=" token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != EQ {
+		return nil, newParseError(tokstr(tok, lit), []string{"="}, pos)
+	}
+
+	// Parse the new password.
+	if stmt.Password, err = p.parseString(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// parseResample parses the EVERY and FOR durations of a RESAMPLE clause.
+// This function assumes the RESAMPLE token has already been consumed.
+func (p *Parser) parseResample() (every, lookback time.Duration, err error) {
+	// Parse optional "EVERY <duration>".
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok == EVERY {
+		if every, err = p.parseDuration(); err != nil {
+			return 0, 0, err
+		} else if every == 0 {
+			return 0, 0, &ParseError{Message: "EVERY duration must be greater than zero", Pos: pos}
+		}
+		tok, pos, lit = p.scanIgnoreWhitespace()
+	}
+
+	// Parse optional "FOR <duration>".
+	if tok == FOR {
+		if lookback, err = p.parseDuration(); err != nil {
+			return 0, 0, err
+		} else if lookback == 0 {
+			return 0, 0, &ParseError{Message: "FOR duration must be greater than zero", Pos: pos}
+		}
+		return every, lookback, nil
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"RETENTION"}, pos)
+	// At least one of the durations is required.
+	if every == 0 {
+		return 0, 0, newParseError(tokstr(tok, lit), []string{"EVERY", "FOR"}, pos)
+	}
+	p.unscan()
+	return every, 0, nil
+}
+
+// parseBackfillContinuousQueryStatement parses a string and returns a BackfillContinuousQueryStatement.
+// This function assumes the BACKFILL token has already been consumed.
+func (p *Parser) parseBackfillContinuousQueryStatement() (*BackfillContinuousQueryStatement, error) {
+	stmt := &BackfillContinuousQueryStatement{}
+
+	// Expect "CONTINUOUS QUERY" tokens.
+	if err := p.parseTokens([]Token{CONTINUOUS, QUERY}); err != nil {
+		return nil, err
+	}
+
+	// Read the name of the query to backfill.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Expect an "ON" keyword.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != ON {
+		return nil, newParseError(tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Read the name of the database the query is on.
+	if ident, err = p.parseIdent(); err != nil {
+		return nil, err
+	}
+	stmt.Database = ident
+
+	// Parse the time range: "FROM '<start>' TO '<end>'".
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
+		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
+	}
+	if stmt.StartTime, err = p.parseTime(); err != nil {
+		return nil, err
+	}
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != TO {
+		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
+	}
+	if stmt.EndTime, err = p.parseTime(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// parseTime parses a date or date time string.
+func (p *Parser) parseTime() (time.Time, error) {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != STRING {
+		return time.Time{}, newParseError(tokstr(tok, lit), []string{"string"}, pos)
+	}
+	p.unscan()
+
+	// Parse the string as a literal and make sure it's a time.
+	expr, err := p.parseUnaryExpr()
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, ok := expr.(*TimeLiteral)
+	if !ok {
+		return time.Time{}, &ParseError{Message: "invalid time: " + lit, Pos: pos}
+	}
+	return t.Val, nil
 }
 
 // parseCreateRetentionPolicyStatement parses a string and returns a create retention policy statement.
@@ -240,6 +456,15 @@ func (p *Parser) parseCreateRetentionPolicyStatement() (*CreateRetentionPolicySt
 	}
 	stmt.Replication = n
 
+	// Parse optional SIZE token.
+	if tok, pos, lit = p.scanIgnoreWhitespace(); tok == SIZE {
+		if stmt.MaxSize, err = p.parseSize(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
 	// Parse optional DEFAULT token.
 	if tok, pos, lit = p.scanIgnoreWhitespace(); tok == DEFAULT {
 		stmt.Default = true
@@ -274,8 +499,8 @@ func (p *Parser) parseAlterRetentionPolicyStatement() (*AlterRetentionPolicyStat
 	}
 	stmt.Database = ident
 
-	// Loop through option tokens (DURATION, REPLICATION, DEFAULT, etc.).
-	maxNumOptions := 3
+	// Loop through option tokens (DURATION, REPLICATION, SIZE, DEFAULT, etc.).
+	maxNumOptions := 4
 Loop:
 	for i := 0; i < maxNumOptions; i++ {
 		tok, pos, lit := p.scanIgnoreWhitespace()
@@ -292,11 +517,17 @@ Loop:
 				return nil, err
 			}
 			stmt.Replication = &n
+		case SIZE:
+			n, err := p.parseSize()
+			if err != nil {
+				return nil, err
+			}
+			stmt.MaxSize = &n
 		case DEFAULT:
 			stmt.Default = true
 		default:
 			if i < 1 {
-				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "RETENTION", "DEFAULT"}, pos)
+				return nil, newParseError(tokstr(tok, lit), []string{"DURATION", "RETENTION", "SIZE", "DEFAULT"}, pos)
 			}
 			p.unscan()
 			break Loop
@@ -306,6 +537,51 @@ Loop:
 	return stmt, nil
 }
 
+// parseSize parses a number of bytes with an optional KB, MB, GB, or TB unit.
+// A size of zero means unlimited.
+func (p *Parser) parseSize() (int64, error) {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != NUMBER {
+		return 0, newParseError(tokstr(tok, lit), []string{"size"}, pos)
+	}
+
+	// Return an error if the number has a fractional part.
+	if strings.Contains(lit, ".") {
+		return 0, &ParseError{Message: "number must be an integer", Pos: pos}
+	}
+
+	// Convert string to int.
+	n, err := strconv.ParseInt(lit, 10, 64)
+	if err != nil {
+		return 0, &ParseError{Message: err.Error(), Pos: pos}
+	}
+
+	// Apply the optional unit.
+	tok, _, lit = p.scanIgnoreWhitespace()
+	if tok == IDENT {
+		var unit int64
+		switch strings.ToUpper(lit) {
+		case "KB":
+			unit = 1 << 10
+		case "MB":
+			unit = 1 << 20
+		case "GB":
+			unit = 1 << 30
+		case "TB":
+			unit = 1 << 40
+		}
+		if unit != 0 {
+			if n > math.MaxInt64/unit {
+				return 0, &ParseError{Message: "size out of range", Pos: pos}
+			}
+			return n * unit, nil
+		}
+	}
+	p.unscan()
+
+	return n, nil
+}
+
 // parseInt parses a string and returns an integer literal.
 func (p *Parser) parseInt(min, max int) (int, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
@@ -348,6 +624,22 @@ func (p *Parser) parseUInt32() (uint32, error) {
 	return uint32(n), nil
 }
 
+// parseUInt64 parses a string and returns a 64-bit unsigned integer literal.
+func (p *Parser) parseUInt64() (uint64, error) {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != NUMBER {
+		return 0, newParseError(tokstr(tok, lit), []string{"number"}, pos)
+	}
+
+	// Convert string to unsigned 64-bit integer
+	n, err := strconv.ParseUint(lit, 10, 64)
+	if err != nil {
+		return 0, &ParseError{Message: err.Error(), Pos: pos}
+	}
+
+	return n, nil
+}
+
 // parseDuration parses a string and returns a duration literal.
 // This function assumes the DURATION token has already been consumed.
 func (p *Parser) parseDuration() (time.Duration, error) {
@@ -426,11 +718,10 @@ func (p *Parser) parseRevokeStatement() (*RevokeStatement, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	if tok == ON {
 		// Parse the name of the thing we're revoking a privilege to use.
-		lit, err := p.parseIdent()
+		stmt.On, stmt.RetentionPolicy, stmt.Measurement, stmt.MeasurementRegex, err = p.parsePrivilegeTarget()
 		if err != nil {
 			return nil, err
 		}
-		stmt.On = lit
 
 		tok, pos, lit = p.scanIgnoreWhitespace()
 	} else if priv != AllPrivileges {
@@ -444,7 +735,15 @@ func (p *Parser) parseRevokeStatement() (*RevokeStatement, error) {
 		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
 	}
 
-	// Parse the name of the user we're revoking the privilege from.
+	// Parse the name of the user or role we're revoking the privilege from.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ROLE {
+		if stmt.Role, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+		return stmt, nil
+	}
+	p.unscan()
+
 	lit, err = p.parseIdent()
 	if err != nil {
 		return nil, err
@@ -470,11 +769,10 @@ func (p *Parser) parseGrantStatement() (*GrantStatement, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	if tok == ON {
 		// Parse the name of the thing we're granting a privilege to use.
-		lit, err := p.parseIdent()
+		stmt.On, stmt.RetentionPolicy, stmt.Measurement, stmt.MeasurementRegex, err = p.parsePrivilegeTarget()
 		if err != nil {
 			return nil, err
 		}
-		stmt.On = lit
 
 		tok, pos, lit = p.scanIgnoreWhitespace()
 	} else if priv != AllPrivileges {
@@ -488,7 +786,15 @@ func (p *Parser) parseGrantStatement() (*GrantStatement, error) {
 		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
 	}
 
-	// Parse the name of the user we're granting the privilege to.
+	// Parse the name of the user or role we're granting the privilege to.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ROLE {
+		if stmt.Role, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+		return stmt, nil
+	}
+	p.unscan()
+
 	lit, err = p.parseIdent()
 	if err != nil {
 		return nil, err
@@ -498,6 +804,89 @@ func (p *Parser) parseGrantStatement() (*GrantStatement, error) {
 	return stmt, nil
 }
 
+// parseGrantRoleStatement parses a string and returns a GrantRoleStatement.
+// This function assumes the "GRANT ROLE" tokens have already been consumed.
+func (p *Parser) parseGrantRoleStatement() (*GrantRoleStatement, error) {
+	role, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != TO {
+		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
+	}
+
+	user, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	return &GrantRoleStatement{Role: role, User: user}, nil
+}
+
+// parseRevokeRoleStatement parses a string and returns a RevokeRoleStatement.
+// This function assumes the "REVOKE ROLE" tokens have already been consumed.
+func (p *Parser) parseRevokeRoleStatement() (*RevokeRoleStatement, error) {
+	role, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
+		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
+	}
+
+	user, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	return &RevokeRoleStatement{Role: role, User: user}, nil
+}
+
+// parsePrivilegeTarget parses the thing a privilege is granted on. This is
+// either a database name or a "db.rp.measurement" pattern where the retention
+// policy may be left empty to match every policy and the measurement may be a
+// regex, such as mydb../app_.*/ or "mydb"."default"."cpu".
+func (p *Parser) parsePrivilegeTarget() (database, policy, measurement string, regex *RegexLiteral, err error) {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != IDENT {
+		return "", "", "", nil, newParseError(tokstr(tok, lit), []string{"identifier"}, pos)
+	}
+
+	// A trailing dot means the measurement is a regex. Append an empty quoted
+	// segment so the identifier still splits into three segments.
+	isRegex := strings.HasSuffix(lit, ".")
+	ident := lit
+	if isRegex {
+		ident += `""`
+	}
+
+	segments, err := SplitIdent(ident)
+	if err != nil {
+		return "", "", "", nil, &ParseError{Message: "invalid privilege target: " + lit, Pos: pos}
+	}
+
+	// A single segment is a database-wide privilege.
+	if len(segments) == 1 {
+		return lit, "", "", nil, nil
+	} else if len(segments) != 3 || segments[0] == "" || (!isRegex && segments[2] == "") {
+		return "", "", "", nil, &ParseError{Message: "invalid privilege target: " + lit, Pos: pos}
+	}
+
+	// Push the slash back onto the reader so the regex can be scanned.
+	if isRegex {
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != DIV {
+			return "", "", "", nil, newParseError(tokstr(tok, lit), []string{"regex"}, pos)
+		}
+		p.s.s.r.unread()
+		expr, err := p.parseRegex()
+		if err != nil {
+			return "", "", "", nil, err
+		}
+		return segments[0], segments[1], "", expr.(*RegexLiteral), nil
+	}
+	return segments[0], segments[1], segments[2], nil, nil
+}
+
 // parsePrivilege parses a string and returns a Privilege
 func (p *Parser) parsePrivilege() (Privilege, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
@@ -566,9 +955,55 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 		return nil, err
 	}
 
+	// Parse series limit: "SLIMIT <n>".
+	if stmt.SLimit, err = p.parseOptionalTokenAndInt(SLIMIT); err != nil {
+		return nil, err
+	}
+
+	// Parse series offset: "SOFFSET <n>".
+	if stmt.SOffset, err = p.parseOptionalTokenAndInt(SOFFSET); err != nil {
+		return nil, err
+	}
+
+	// Parse time zone: "tz('<name>')".
+	if stmt.Location, err = p.parseLocation(); err != nil {
+		return nil, err
+	}
+
 	return stmt, nil
 }
 
+// parseLocation parses the optional "tz()" clause of a select statement.
+func (p *Parser) parseLocation() (*time.Location, error) {
+	// Check if the tz() call exists.
+	if tok, _, lit := p.scanIgnoreWhitespace(); tok != IDENT || strings.ToLower(lit) != "tz" {
+		p.unscan()
+		return nil, nil
+	}
+
+	// Expect a left paren.
+	if tok, pos, lit := p.scan(); tok != LPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	// Scan the time zone name.
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != STRING {
+		return nil, newParseError(tokstr(tok, lit), []string{"string"}, pos)
+	}
+	loc, err := time.LoadLocation(lit)
+	if err != nil {
+		return nil, &ParseError{Message: "unknown time zone: " + lit, Pos: pos}
+	}
+
+	// Expect a closing right paren.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != RPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return loc, nil
+}
+
 // targetRequirement specifies whether or not a target clause is required.
 type targetRequirement int
 
@@ -674,6 +1109,24 @@ func (p *Parser) parseShowSeriesStatement() (*ShowSeriesStatement, error) {
 	return stmt, nil
 }
 
+// parseShowSeriesCardinalityStatement parses a string and returns a ShowSeriesCardinalityStatement.
+// This function assumes the "SHOW SERIES CARDINALITY" tokens have already been consumed.
+func (p *Parser) parseShowSeriesCardinalityStatement() (*ShowSeriesCardinalityStatement, error) {
+	stmt := &ShowSeriesCardinalityStatement{}
+	var err error
+
+	// Parse optional FROM.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == FROM {
+		if stmt.Source, err = p.parseSource(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	return stmt, nil
+}
+
 // parseShowMeasurementsStatement parses a string and returns a ShowSeriesStatement.
 // This function assumes the "SHOW MEASUREMENTS" tokens have already been consumed.
 func (p *Parser) parseShowMeasurementsStatement() (*ShowMeasurementsStatement, error) {
@@ -798,6 +1251,34 @@ func (p *Parser) parseShowTagValuesStatement() (*ShowTagValuesStatement, error)
 	return stmt, nil
 }
 
+// parseShowTagValuesCardinalityStatement parses a string and returns a ShowTagValuesCardinalityStatement.
+// This function assumes the "SHOW TAG VALUES CARDINALITY" tokens have already been consumed.
+func (p *Parser) parseShowTagValuesCardinalityStatement() (*ShowTagValuesCardinalityStatement, error) {
+	stmt := &ShowTagValuesCardinalityStatement{}
+	var err error
+
+	// Parse optional source.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == FROM {
+		if stmt.Source, err = p.parseSource(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	// Parse optional WITH KEY.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == WITH {
+		p.unscan()
+		if stmt.TagKeys, err = p.parseTagKeys(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	return stmt, nil
+}
+
 // parseTagKeys parses a string and returns a list of tag keys.
 func (p *Parser) parseTagKeys() ([]string, error) {
 	var err error
@@ -845,6 +1326,26 @@ func (p *Parser) parseShowUsersStatement() (*ShowUsersStatement, error) {
 	return &ShowUsersStatement{}, nil
 }
 
+// parseShowRolesStatement parses a string and returns a ShowRolesStatement.
+// This function assumes the "SHOW ROLES" tokens have been consumed.
+func (p *Parser) parseShowRolesStatement() (*ShowRolesStatement, error) {
+	return &ShowRolesStatement{}, nil
+}
+
+// parseShowGrantsForUserStatement parses a string and returns a ShowGrantsForUserStatement.
+// This function assumes the "SHOW GRANTS" tokens have already been consumed.
+func (p *Parser) parseShowGrantsForUserStatement() (*ShowGrantsForUserStatement, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FOR {
+		return nil, newParseError(tokstr(tok, lit), []string{"FOR"}, pos)
+	}
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	return &ShowGrantsForUserStatement{Name: name}, nil
+}
+
 // parseShowFieldKeysStatement parses a string and returns a ShowSeriesStatement.
 // This function assumes the "SHOW FIELD KEYS" tokens have already been consumed.
 func (p *Parser) parseShowFieldKeysStatement() (*ShowFieldKeysStatement, error) {
@@ -924,17 +1425,22 @@ func (p *Parser) parseDropSeriesStatement() (*DropSeriesStatement, error) {
 	return stmt, nil
 }
 
-// parseShowContinuousQueriesStatement parses a string and returns a ShowContinuousQueriesStatement.
+// parseShowContinuousQueriesStatement parses a string and returns a ShowContinuousQueriesStatement
+// or a ShowContinuousQueryStatsStatement.
 // This function assumes the "SHOW CONTINUOUS" tokens have already been consumed.
-func (p *Parser) parseShowContinuousQueriesStatement() (*ShowContinuousQueriesStatement, error) {
-	stmt := &ShowContinuousQueriesStatement{}
-
-	// Expect a "QUERIES" token.
-	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != QUERIES {
-		return nil, newParseError(tokstr(tok, lit), []string{"QUERIES"}, pos)
+func (p *Parser) parseShowContinuousQueriesStatement() (Statement, error) {
+	// Expect a "QUERIES" or "QUERY STATS" token.
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok == QUERY {
+		if tok, pos, lit := p.scanIgnoreWhitespace(); tok != STATS {
+			return nil, newParseError(tokstr(tok, lit), []string{"STATS"}, pos)
+		}
+		return &ShowContinuousQueryStatsStatement{}, nil
+	} else if tok != QUERIES {
+		return nil, newParseError(tokstr(tok, lit), []string{"QUERIES", "QUERY"}, pos)
 	}
 
-	return stmt, nil
+	return &ShowContinuousQueriesStatement{}, nil
 }
 
 // parseShowDatabasesStatement parses a string and returns a ShowDatabasesStatement.
@@ -944,6 +1450,48 @@ func (p *Parser) parseShowDatabasesStatement() (*ShowDatabasesStatement, error)
 	return stmt, nil
 }
 
+// parseShowDataNodesStatement parses a string and returns a ShowDataNodesStatement.
+// This function assumes the "SHOW DATA NODES" or "SHOW SERVERS" tokens have already been consumed.
+func (p *Parser) parseShowDataNodesStatement() (*ShowDataNodesStatement, error) {
+	stmt := &ShowDataNodesStatement{}
+	return stmt, nil
+}
+
+// parseShowBrokersStatement parses a string and returns a ShowBrokersStatement.
+// This function assumes the "SHOW BROKERS" tokens have already been consumed.
+func (p *Parser) parseShowBrokersStatement() (*ShowBrokersStatement, error) {
+	stmt := &ShowBrokersStatement{}
+	return stmt, nil
+}
+
+// parseShowShardsStatement parses a string and returns a ShowShardsStatement.
+// This function assumes the "SHOW SHARDS" tokens have already been consumed.
+func (p *Parser) parseShowShardsStatement() (*ShowShardsStatement, error) {
+	stmt := &ShowShardsStatement{}
+	return stmt, nil
+}
+
+// parseShowShardGroupsStatement parses a string and returns a ShowShardGroupsStatement.
+// This function assumes the "SHOW SHARD GROUPS" tokens have already been consumed.
+func (p *Parser) parseShowShardGroupsStatement() (*ShowShardGroupsStatement, error) {
+	stmt := &ShowShardGroupsStatement{}
+
+	// Parse optional ON clause.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok != ON {
+		p.unscan()
+		return stmt, nil
+	}
+
+	// Parse the database name.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Database = ident
+
+	return stmt, nil
+}
+
 // parseCreateContinuousQueriesStatement parses a string and returns a CreateContinuousQueryStatement.
 // This function assumes the "CREATE CONTINUOUS" tokens have already been consumed.
 func (p *Parser) parseCreateContinuousQueryStatement() (*CreateContinuousQueryStatement, error) {
@@ -972,6 +1520,15 @@ func (p *Parser) parseCreateContinuousQueryStatement() (*CreateContinuousQuerySt
 	}
 	stmt.Database = ident
 
+	// Parse optional "RESAMPLE [EVERY <duration>] [FOR <duration>]".
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == RESAMPLE {
+		if stmt.ResampleEvery, stmt.ResampleFor, err = p.parseResample(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
 	// Expect a "BEGIN SELECT" tokens.
 	if err := p.parseTokens([]Token{BEGIN, SELECT}); err != nil {
 		return nil, err
@@ -984,6 +1541,11 @@ func (p *Parser) parseCreateContinuousQueryStatement() (*CreateContinuousQuerySt
 	}
 	stmt.Source = source
 
+	// validate that the lookback window covers at least one interval
+	if d, _ := source.GroupByInterval(); stmt.ResampleFor > 0 && stmt.ResampleFor < d {
+		return nil, &ParseError{Message: fmt.Sprintf("FOR duration must be >= GROUP BY time duration: must be a minimum of %s, got %s", FormatDuration(d), FormatDuration(stmt.ResampleFor))}
+	}
+
 	// validate that the statement has a non-zero group by interval if it is aggregated
 	if source.Aggregated() {
 		d, err := source.GroupByInterval()
@@ -1038,6 +1600,36 @@ func (p *Parser) parseDropDatabaseStatement() (*DropDatabaseStatement, error) {
 	return stmt, nil
 }
 
+// parseDropShardStatement parses a string and returns a DropShardStatement.
+// This function assumes the DROP SHARD tokens have already been consumed.
+func (p *Parser) parseDropShardStatement() (*DropShardStatement, error) {
+	stmt := &DropShardStatement{}
+
+	// Parse the ID of the shard to be dropped.
+	id, err := p.parseUInt64()
+	if err != nil {
+		return nil, err
+	}
+	stmt.ID = id
+
+	return stmt, nil
+}
+
+// parseDropDataNodeStatement parses a string and returns a DropDataNodeStatement.
+// This function assumes the DROP DATA NODE tokens have already been consumed.
+func (p *Parser) parseDropDataNodeStatement() (*DropDataNodeStatement, error) {
+	stmt := &DropDataNodeStatement{}
+
+	// Parse the ID of the data node to be dropped.
+	id, err := p.parseUInt64()
+	if err != nil {
+		return nil, err
+	}
+	stmt.ID = id
+
+	return stmt, nil
+}
+
 // parseDropRetentionPolicyStatement parses a string and returns a DropRetentionPolicyStatement.
 // This function assumes the DROP RETENTION POLICY tokens have been consumed.
 func (p *Parser) parseDropRetentionPolicyStatement() (*DropRetentionPolicyStatement, error) {
@@ -1063,6 +1655,105 @@ func (p *Parser) parseDropRetentionPolicyStatement() (*DropRetentionPolicyStatem
 	return stmt, nil
 }
 
+// parseCreateDownsampleStatement parses a string and returns a CreateDownsampleStatement.
+// This function assumes the "CREATE DOWNSAMPLE" tokens have already been consumed.
+func (p *Parser) parseCreateDownsampleStatement() (*CreateDownsampleStatement, error) {
+	stmt := &CreateDownsampleStatement{}
+
+	// Parse the database and retention policies.
+	var err error
+	if stmt.Database, stmt.Source, stmt.Target, err = p.parseDownsampleRetentionPolicies(); err != nil {
+		return nil, err
+	}
+
+	// Consume the required AGGREGATE token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != AGGREGATE {
+		return nil, newParseError(tokstr(tok, lit), []string{"AGGREGATE"}, pos)
+	}
+
+	// Parse the list of aggregate functions.
+	_, pos, _ := p.scanIgnoreWhitespace()
+	p.unscan()
+	if stmt.Aggregates, err = p.parseIdentList(); err != nil {
+		return nil, err
+	}
+	for _, name := range stmt.Aggregates {
+		if !isDownsampleAggregate(name) {
+			return nil, &ParseError{Message: fmt.Sprintf("unsupported aggregate: %s", name), Pos: pos}
+		}
+	}
+
+	// Consume the required EVERY token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != EVERY {
+		return nil, newParseError(tokstr(tok, lit), []string{"EVERY"}, pos)
+	}
+
+	// Parse the interval.
+	_, pos, _ = p.scanIgnoreWhitespace()
+	p.unscan()
+	if stmt.Interval, err = p.parseDuration(); err != nil {
+		return nil, err
+	} else if stmt.Interval == 0 {
+		return nil, &ParseError{Message: "EVERY duration must be greater than zero", Pos: pos}
+	}
+
+	return stmt, nil
+}
+
+// parseDropDownsampleStatement parses a string and returns a DropDownsampleStatement.
+// This function assumes the "DROP DOWNSAMPLE" tokens have already been consumed.
+func (p *Parser) parseDropDownsampleStatement() (*DropDownsampleStatement, error) {
+	stmt := &DropDownsampleStatement{}
+
+	// Parse the database and retention policies.
+	var err error
+	if stmt.Database, stmt.Source, stmt.Target, err = p.parseDownsampleRetentionPolicies(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// parseDownsampleRetentionPolicies parses "ON <db> FROM <rp> TO <rp>".
+func (p *Parser) parseDownsampleRetentionPolicies() (database, source, target string, err error) {
+	// Consume the required ON token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != ON {
+		return "", "", "", newParseError(tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Parse the database name.
+	if database, err = p.parseIdent(); err != nil {
+		return "", "", "", err
+	}
+
+	// Consume the required FROM token and parse the source retention policy.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
+		return "", "", "", newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
+	}
+	if source, err = p.parseIdent(); err != nil {
+		return "", "", "", err
+	}
+
+	// Consume the required TO token and parse the target retention policy.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != TO {
+		return "", "", "", newParseError(tokstr(tok, lit), []string{"TO"}, pos)
+	}
+	if target, err = p.parseIdent(); err != nil {
+		return "", "", "", err
+	}
+
+	return database, source, target, nil
+}
+
+// isDownsampleAggregate returns true if the function can be used to downsample a field.
+func isDownsampleAggregate(name string) bool {
+	switch strings.ToLower(name) {
+	case "count", "sum", "mean", "min", "max", "spread", "stddev", "first", "last":
+		return true
+	}
+	return false
+}
+
 // parseCreateUserStatement parses a string and returns a CreateUserStatement.
 // This function assumes the "CREATE USER" tokens have already been consumed.
 func (p *Parser) parseCreateUserStatement() (*CreateUserStatement, error) {
@@ -1102,6 +1793,26 @@ func (p *Parser) parseCreateUserStatement() (*CreateUserStatement, error) {
 	return stmt, nil
 }
 
+// parseCreateRoleStatement parses a string and returns a CreateRoleStatement.
+// This function assumes the "CREATE ROLE" tokens have already been consumed.
+func (p *Parser) parseCreateRoleStatement() (*CreateRoleStatement, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	return &CreateRoleStatement{Name: name}, nil
+}
+
+// parseDropRoleStatement parses a string and returns a DropRoleStatement.
+// This function assumes the "DROP ROLE" tokens have already been consumed.
+func (p *Parser) parseDropRoleStatement() (*DropRoleStatement, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	return &DropRoleStatement{Name: name}, nil
+}
+
 // parseDropUserStatement parses a string and returns a DropUserStatement.
 // This function assumes the DROP USER tokens have already been consumed.
 func (p *Parser) parseDropUserStatement() (*DropUserStatement, error) {
@@ -1240,9 +1951,11 @@ func (p *Parser) parseAlias() (string, error) {
 
 // parseSource parses the "FROM" clause of the query.
 func (p *Parser) parseSource() (Source, error) {
-	// The first token can either be the series name or a join/merge call.
+	// The first token can either be the series name, a join/merge call or a subquery.
 	tok, pos, lit := p.scanIgnoreWhitespace()
-	if tok != IDENT {
+	if tok == LPAREN {
+		return p.parseSubQuery()
+	} else if tok != IDENT {
 		return nil, newParseError(tokstr(tok, lit), []string{"identifier"}, pos)
 	}
 
@@ -1287,6 +2000,30 @@ func (p *Parser) parseSource() (Source, error) {
 	return &Merge{Measurements: measurements}, nil
 }
 
+// parseSubQuery parses a parenthesised select statement used as a source.
+// This function assumes the opening LPAREN token has already been consumed.
+func (p *Parser) parseSubQuery() (*SubQuery, error) {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != SELECT {
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	// Parse the inner statement. Subqueries cannot write into a target.
+	stmt, err := p.parseSelectStatement(targetNotRequired)
+	if err != nil {
+		return nil, err
+	} else if stmt.Target != nil {
+		return nil, &ParseError{Message: "subqueries cannot contain an INTO clause", Pos: pos}
+	}
+
+	// Expect a closing right paren.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != RPAREN {
+		return nil, newParseError(tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return &SubQuery{Statement: stmt}, nil
+}
+
 // parseCondition parses the "WHERE" clause of the query, if it exists.
 func (p *Parser) parseCondition() (Expr, error) {
 	// Check if the WHERE token exists.
@@ -1339,6 +2076,19 @@ func (p *Parser) parseDimensions() (Dimensions, error) {
 
 // parseDimension parses a single dimension.
 func (p *Parser) parseDimension() (*Dimension, error) {
+	// A leading slash is the start of a regex matching tag keys.
+	// Push the slash back onto the reader so the regex can be scanned.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == DIV {
+		p.s.s.r.unread()
+		expr, err := p.parseRegex()
+		if err != nil {
+			return nil, err
+		}
+		p.consumeWhitespace()
+		return &Dimension{Expr: expr}, nil
+	}
+	p.unscan()
+
 	// Parse the expression first.
 	expr, err := p.ParseExpr()
 	if err != nil {
@@ -83,6 +83,34 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with series limit and offset
+		{
+			s: `SELECT field1 FROM myseries GROUP BY host LIMIT 10 OFFSET 5 SLIMIT 2 SOFFSET 1`,
+			stmt: &influxql.SelectStatement{
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "field1"}}},
+				Source:     &influxql.Measurement{Name: "myseries"},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.VarRef{Val: "host"}}},
+				Limit:      10,
+				Offset:     5,
+				SLimit:     2,
+				SOffset:    1,
+			},
+		},
+
+		// SELECT statement with interval offset and time zone
+		{
+			s: `SELECT sum(value) FROM cpu GROUP BY time(1d, 6h) tz('Europe/Berlin')`,
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "sum", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Source: &influxql.Measurement{Name: "cpu"},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.Call{
+					Name: "time",
+					Args: []influxql.Expr{&influxql.DurationLiteral{Val: 24 * time.Hour}, &influxql.DurationLiteral{Val: 6 * time.Hour}},
+				}}},
+				Location: mustLoadLocation("Europe/Berlin"),
+			},
+		},
+
 		// SELECT statement with JOIN
 		{
 			s: `SELECT field1 FROM join(aa,"bb", cc) JOIN cc`,
@@ -112,6 +140,41 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with subquery
+		{
+			s: `SELECT max(m) FROM (SELECT mean(value) AS m FROM cpu GROUP BY time(1m), host) GROUP BY time(1h)`,
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "max", Args: []influxql.Expr{&influxql.VarRef{Val: "m"}}}}},
+				Source: &influxql.SubQuery{
+					Statement: &influxql.SelectStatement{
+						Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}, Alias: "m"}},
+						Source: &influxql.Measurement{Name: "cpu"},
+						Dimensions: []*influxql.Dimension{
+							{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Minute}}}},
+							{Expr: &influxql.VarRef{Val: "host"}},
+						},
+					},
+				},
+				Dimensions: []*influxql.Dimension{
+					{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Hour}}}},
+				},
+			},
+		},
+
+		// SELECT statement with wildcard and regex dimensions
+		{
+			s: `SELECT field1 FROM myseries GROUP BY *, /^host/ ,time(1m)`,
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{Expr: &influxql.VarRef{Val: "field1"}}},
+				Source: &influxql.Measurement{Name: "myseries"},
+				Dimensions: []*influxql.Dimension{
+					{Expr: &influxql.Wildcard{}},
+					{Expr: &influxql.RegexLiteral{Val: regexp.MustCompile(`^host`)}},
+					{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Minute}}}},
+				},
+			},
+		},
+
 		// SELECT statement (lowercase)
 		{
 			s: `select my_field from myseries`,
@@ -183,6 +246,56 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowSeriesStatement{},
 		},
 
+		// SHOW DATA NODES statement
+		{
+			s:    `SHOW DATA NODES`,
+			stmt: &influxql.ShowDataNodesStatement{},
+		},
+
+		// SHOW SERVERS statement
+		{
+			s:    `SHOW SERVERS`,
+			stmt: &influxql.ShowDataNodesStatement{},
+		},
+
+		// SHOW BROKERS statement
+		{
+			s:    `SHOW BROKERS`,
+			stmt: &influxql.ShowBrokersStatement{},
+		},
+
+		// SHOW SHARDS statement
+		{
+			s:    `SHOW SHARDS`,
+			stmt: &influxql.ShowShardsStatement{},
+		},
+
+		// SHOW SHARD GROUPS statement
+		{
+			s:    `SHOW SHARD GROUPS`,
+			stmt: &influxql.ShowShardGroupsStatement{},
+		},
+
+		// SHOW SHARD GROUPS ON ...
+		{
+			s:    `SHOW SHARD GROUPS ON mydb`,
+			stmt: &influxql.ShowShardGroupsStatement{Database: "mydb"},
+		},
+
+		// SHOW SERIES CARDINALITY statement
+		{
+			s:    `SHOW SERIES CARDINALITY`,
+			stmt: &influxql.ShowSeriesCardinalityStatement{},
+		},
+
+		// SHOW SERIES CARDINALITY FROM ...
+		{
+			s: `SHOW SERIES CARDINALITY FROM cpu`,
+			stmt: &influxql.ShowSeriesCardinalityStatement{
+				Source: &influxql.Measurement{Name: "cpu"},
+			},
+		},
+
 		// SHOW SERIES WHERE with ORDER BY and LIMIT
 		{
 			s: `SHOW SERIES WHERE region = 'uswest' ORDER BY ASC, field1, field2 DESC LIMIT 10`,
@@ -254,6 +367,21 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SHOW TAG VALUES CARDINALITY statement
+		{
+			s:    `SHOW TAG VALUES CARDINALITY`,
+			stmt: &influxql.ShowTagValuesCardinalityStatement{},
+		},
+
+		// SHOW TAG VALUES CARDINALITY FROM ... WITH KEY IN ...
+		{
+			s: `SHOW TAG VALUES CARDINALITY FROM cpu WITH KEY IN (region, host)`,
+			stmt: &influxql.ShowTagValuesCardinalityStatement{
+				Source:  &influxql.Measurement{Name: "cpu"},
+				TagKeys: []string{"region", "host"},
+			},
+		},
+
 		// SHOW TAG VALUES FROM ... WITH KEY = ...
 		{
 			s: `SHOW TAG VALUES FROM src WITH KEY = region WHERE region = 'uswest' ORDER BY ASC, field1, field2 DESC LIMIT 10`,
@@ -385,6 +513,23 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowContinuousQueriesStatement{},
 		},
 
+		// BACKFILL CONTINUOUS QUERY statement
+		{
+			s: `BACKFILL CONTINUOUS QUERY myquery ON testdb FROM '2000-01-01' TO '2000-01-02 12:00:00'`,
+			stmt: &influxql.BackfillContinuousQueryStatement{
+				Name:      "myquery",
+				Database:  "testdb",
+				StartTime: mustParseTime("2000-01-01T00:00:00Z"),
+				EndTime:   mustParseTime("2000-01-02T12:00:00Z"),
+			},
+		},
+
+		// SHOW CONTINUOUS QUERY STATS statement
+		{
+			s:    `SHOW CONTINUOUS QUERY STATS`,
+			stmt: &influxql.ShowContinuousQueryStatsStatement{},
+		},
+
 		// CREATE CONTINUOUS QUERY ... INTO <measurement>
 		{
 			s: `CREATE CONTINUOUS QUERY myquery ON testdb BEGIN SELECT count() INTO measure1 FROM myseries GROUP BY time(5m) END`,
@@ -409,6 +554,57 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE CONTINUOUS QUERY ... RESAMPLE EVERY <duration> FOR <duration>
+		{
+			s: `CREATE CONTINUOUS QUERY myquery ON testdb RESAMPLE EVERY 1m FOR 15m BEGIN SELECT count() INTO measure1 FROM myseries GROUP BY time(5m) END`,
+			stmt: &influxql.CreateContinuousQueryStatement{
+				Name:          "myquery",
+				Database:      "testdb",
+				ResampleEvery: time.Minute,
+				ResampleFor:   15 * time.Minute,
+				Source: &influxql.SelectStatement{
+					Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "count"}}},
+					Target: &influxql.Target{Measurement: "measure1"},
+					Source: &influxql.Measurement{Name: "myseries"},
+					Dimensions: []*influxql.Dimension{
+						{
+							Expr: &influxql.Call{
+								Name: "time",
+								Args: []influxql.Expr{
+									&influxql.DurationLiteral{Val: 5 * time.Minute},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		// CREATE CONTINUOUS QUERY ... RESAMPLE FOR <duration>
+		{
+			s: `CREATE CONTINUOUS QUERY myquery ON testdb RESAMPLE FOR 1h BEGIN SELECT count() INTO measure1 FROM myseries GROUP BY time(5m) END`,
+			stmt: &influxql.CreateContinuousQueryStatement{
+				Name:        "myquery",
+				Database:    "testdb",
+				ResampleFor: time.Hour,
+				Source: &influxql.SelectStatement{
+					Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "count"}}},
+					Target: &influxql.Target{Measurement: "measure1"},
+					Source: &influxql.Measurement{Name: "myseries"},
+					Dimensions: []*influxql.Dimension{
+						{
+							Expr: &influxql.Call{
+								Name: "time",
+								Args: []influxql.Expr{
+									&influxql.DurationLiteral{Val: 5 * time.Minute},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
 		// CREATE CONTINUOUS QUERY ... INTO <retention-policy>.<measurement>
 		{
 			s: `CREATE CONTINUOUS QUERY myquery ON testdb BEGIN SELECT count() INTO "1h.policy1"."cpu.load" FROM myseries GROUP BY time(5m) END`,
@@ -495,6 +691,40 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE DOWNSAMPLE statement
+		{
+			s: `CREATE DOWNSAMPLE ON testdb FROM raw TO hourly AGGREGATE mean, max EVERY 1h`,
+			stmt: &influxql.CreateDownsampleStatement{
+				Database:   "testdb",
+				Source:     "raw",
+				Target:     "hourly",
+				Aggregates: []string{"mean", "max"},
+				Interval:   time.Hour,
+			},
+		},
+
+		// DROP DATA NODE statement
+		{
+			s:    `DROP DATA NODE 2`,
+			stmt: &influxql.DropDataNodeStatement{ID: 2},
+		},
+
+		// DROP SHARD statement
+		{
+			s:    `DROP SHARD 12`,
+			stmt: &influxql.DropShardStatement{ID: 12},
+		},
+
+		// DROP DOWNSAMPLE statement
+		{
+			s: `DROP DOWNSAMPLE ON testdb FROM raw TO hourly`,
+			stmt: &influxql.DropDownsampleStatement{
+				Database: "testdb",
+				Source:   "raw",
+				Target:   "hourly",
+			},
+		},
+
 		// DROP CONTINUOUS QUERY statement
 		{
 			s:    `DROP CONTINUOUS QUERY myquery`,
@@ -626,6 +856,115 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// GRANT READ on a measurement
+		{
+			s: `GRANT READ ON "testdb"."policy1"."cpu" TO jdoe`,
+			stmt: &influxql.GrantStatement{
+				Privilege:       influxql.ReadPrivilege,
+				On:              "testdb",
+				RetentionPolicy: "policy1",
+				Measurement:     "cpu",
+				User:            "jdoe",
+			},
+		},
+
+		// GRANT WRITE on measurements matching a regex in any retention policy
+		{
+			s: `GRANT WRITE ON testdb../app_.*/ TO jdoe`,
+			stmt: &influxql.GrantStatement{
+				Privilege:        influxql.WritePrivilege,
+				On:               "testdb",
+				MeasurementRegex: &influxql.RegexLiteral{Val: regexp.MustCompile(`app_.*`)},
+				User:             "jdoe",
+			},
+		},
+
+		// REVOKE READ on a measurement
+		{
+			s: `REVOKE READ ON "testdb".."cpu load" FROM jdoe`,
+			stmt: &influxql.RevokeStatement{
+				Privilege:   influxql.ReadPrivilege,
+				On:          "testdb",
+				Measurement: "cpu load",
+				User:        "jdoe",
+			},
+		},
+
+		// SHOW GRANTS FOR
+		{
+			s:    `SHOW GRANTS FOR jdoe`,
+			stmt: &influxql.ShowGrantsForUserStatement{Name: "jdoe"},
+		},
+
+		// GRANT READ TO ROLE
+		{
+			s: `GRANT READ ON testdb TO ROLE readers`,
+			stmt: &influxql.GrantStatement{
+				Privilege: influxql.ReadPrivilege,
+				On:        "testdb",
+				Role:      "readers",
+			},
+		},
+
+		// REVOKE WRITE FROM ROLE
+		{
+			s: `REVOKE WRITE ON testdb../^app_/ FROM ROLE writers`,
+			stmt: &influxql.RevokeStatement{
+				Privilege:        influxql.WritePrivilege,
+				On:               "testdb",
+				MeasurementRegex: &influxql.RegexLiteral{Val: regexp.MustCompile(`^app_`)},
+				Role:             "writers",
+			},
+		},
+
+		// GRANT ROLE
+		{
+			s:    `GRANT ROLE readers TO jdoe`,
+			stmt: &influxql.GrantRoleStatement{Role: "readers", User: "jdoe"},
+		},
+
+		// REVOKE ROLE
+		{
+			s:    `REVOKE ROLE readers FROM jdoe`,
+			stmt: &influxql.RevokeRoleStatement{Role: "readers", User: "jdoe"},
+		},
+
+		// CREATE ROLE
+		{
+			s:    `CREATE ROLE readers`,
+			stmt: &influxql.CreateRoleStatement{Name: "readers"},
+		},
+
+		// DROP ROLE
+		{
+			s:    `DROP ROLE readers`,
+			stmt: &influxql.DropRoleStatement{Name: "readers"},
+		},
+
+		// SHOW ROLES
+		{
+			s:    `SHOW ROLES`,
+			stmt: &influxql.ShowRolesStatement{},
+		},
+
+		// SET PASSWORD FOR
+		{
+			s:    `SET PASSWORD FOR jdoe = 'secret'`,
+			stmt: &influxql.SetPasswordUserStatement{Name: "jdoe", Password: "secret"},
+		},
+
+		// ALTER USER WITH ADMIN
+		{
+			s:    `ALTER USER jdoe WITH ADMIN`,
+			stmt: &influxql.AlterUserStatement{Name: "jdoe", Admin: true},
+		},
+
+		// ALTER USER WITHOUT ADMIN
+		{
+			s:    `ALTER USER jdoe WITHOUT ADMIN`,
+			stmt: &influxql.AlterUserStatement{Name: "jdoe", Admin: false},
+		},
+
 		// CREATE RETENTION POLICY
 		{
 			s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 2`,
@@ -660,6 +999,31 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE RETENTION POLICY ... SIZE
+		{
+			s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 2m REPLICATION 4 SIZE 10GB DEFAULT`,
+			stmt: &influxql.CreateRetentionPolicyStatement{
+				Name:        "policy1",
+				Database:    "testdb",
+				Duration:    2 * time.Minute,
+				Replication: 4,
+				MaxSize:     10 << 30,
+				Default:     true,
+			},
+		},
+
+		// CREATE RETENTION POLICY ... SIZE in bytes
+		{
+			s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 2m REPLICATION 4 SIZE 1024`,
+			stmt: &influxql.CreateRetentionPolicyStatement{
+				Name:        "policy1",
+				Database:    "testdb",
+				Duration:    2 * time.Minute,
+				Replication: 4,
+				MaxSize:     1024,
+			},
+		},
+
 		// ALTER RETENTION POLICY
 		{
 			s:    `ALTER RETENTION POLICY policy1 ON testdb DURATION 1m REPLICATION 4 DEFAULT`,
@@ -696,6 +1060,17 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: newAlterRetentionPolicyStatement("policy1", "testdb", -1, 4, false),
 		},
 
+		// ALTER RETENTION POLICY with SIZE
+		{
+			s: `ALTER RETENTION POLICY policy1 ON testdb SIZE 500MB`,
+			stmt: func() *influxql.AlterRetentionPolicyStatement {
+				stmt := newAlterRetentionPolicyStatement("policy1", "testdb", -1, -1, false)
+				n := int64(500 << 20)
+				stmt.MaxSize = &n
+				return stmt
+			}(),
+		},
+
 		// Errors
 		{s: ``, err: `found EOF, expected SELECT at line 1, char 1`},
 		{s: `SELECT`, err: `found EOF, expected identifier, string, number, bool at line 1, char 8`},
@@ -709,6 +1084,12 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 FROM myseries OFFSET`, err: `found EOF, expected number at line 1, char 36`},
 		{s: `SELECT field1 FROM myseries OFFSET 10.5`, err: `fractional parts not allowed in OFFSET at line 1, char 36`},
 		{s: `SELECT field1 FROM myseries OFFSET 0`, err: `OFFSET must be > 0 at line 1, char 36`},
+		{s: `SELECT field1 FROM myseries GROUP BY /host`, err: `bad regex:  at line 1, char 37`},
+		{s: `SELECT field1 FROM myseries SLIMIT`, err: `found EOF, expected number at line 1, char 36`},
+		{s: `SELECT field1 FROM myseries SLIMIT 0`, err: `SLIMIT must be > 0 at line 1, char 36`},
+		{s: `SELECT field1 FROM myseries SOFFSET 1.5`, err: `fractional parts not allowed in SOFFSET at line 1, char 37`},
+		{s: `SELECT field1 FROM myseries tz('Mars/Olympus')`, err: `unknown time zone: Mars/Olympus at line 1, char 31`},
+		{s: `SELECT field1 FROM myseries tz(1)`, err: `found 1, expected string at line 1, char 32`},
 		{s: `SELECT field1 FROM myseries ORDER`, err: `found EOF, expected BY at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries ORDER BY /`, err: `found /, expected identifier, ASC, or DESC at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries ORDER BY 1`, err: `found 1, expected identifier, ASC, or DESC at line 1, char 38`},
@@ -716,6 +1097,9 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 FROM 12`, err: `found 12, expected identifier at line 1, char 20`},
 		{s: `SELECT 1000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000 FROM myseries`, err: `unable to parse number at line 1, char 8`},
 		{s: `SELECT 10.5h FROM myseries`, err: `found h, expected FROM at line 1, char 12`},
+		{s: `SELECT field1 FROM (myseries)`, err: `found myseries, expected SELECT at line 1, char 21`},
+		{s: `SELECT field1 FROM (SELECT field1 FROM myseries`, err: `found EOF, expected ) at line 1, char 49`},
+		{s: `SELECT field1 FROM (SELECT field1 INTO foo FROM myseries)`, err: `subqueries cannot contain an INTO clause at line 1, char 21`},
 		{s: `DELETE`, err: `found EOF, expected FROM at line 1, char 8`},
 		{s: `DELETE FROM`, err: `found EOF, expected identifier at line 1, char 13`},
 		{s: `DELETE FROM myseries WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
@@ -723,14 +1107,37 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `DROP SERIES`, err: `found EOF, expected number at line 1, char 13`},
 		{s: `DROP SERIES FROM`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `DROP SERIES FROM src WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
-		{s: `SHOW CONTINUOUS`, err: `found EOF, expected QUERIES at line 1, char 17`},
+		{s: `SHOW CONTINUOUS`, err: `found EOF, expected QUERIES, QUERY at line 1, char 17`},
+		{s: `SHOW CONTINUOUS QUERY`, err: `found EOF, expected STATS at line 1, char 23`},
+		{s: `BACKFILL CONTINUOUS`, err: `found EOF, expected QUERY at line 1, char 21`},
+		{s: `BACKFILL CONTINUOUS QUERY myquery ON testdb`, err: `found EOF, expected FROM at line 1, char 45`},
+		{s: `BACKFILL CONTINUOUS QUERY myquery ON testdb FROM '2000-01-01'`, err: `found EOF, expected TO at line 1, char 62`},
+		{s: `BACKFILL CONTINUOUS QUERY myquery ON testdb FROM 'yesterday' TO '2000-01-01'`, err: `invalid time: yesterday at line 1, char 49`},
 		{s: `SHOW RETENTION`, err: `found EOF, expected POLICIES at line 1, char 16`},
 		{s: `SHOW RETENTION POLICIES`, err: `found EOF, expected identifier at line 1, char 25`},
-		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, FIELD, MEASUREMENTS, RETENTION, SERIES, TAG, USERS at line 1, char 6`},
+		{s: `SHOW FOO`, err: `found FOO, expected BROKERS, CONTINUOUS, DATA, DATABASES, FIELD, GRANTS, MEASUREMENTS, RETENTION, ROLES, SERIES, SERVERS, SHARD, SHARDS, TAG, USERS at line 1, char 6`},
 		{s: `DROP CONTINUOUS`, err: `found EOF, expected QUERY at line 1, char 17`},
 		{s: `DROP CONTINUOUS QUERY`, err: `found EOF, expected identifier at line 1, char 23`},
 		{s: `CREATE CONTINUOUS`, err: `found EOF, expected QUERY at line 1, char 19`},
 		{s: `CREATE CONTINUOUS QUERY`, err: `found EOF, expected identifier at line 1, char 25`},
+		{s: `CREATE CONTINUOUS QUERY myquery ON testdb RESAMPLE BEGIN SELECT count() INTO measure1 FROM myseries GROUP BY time(5m) END`, err: `found BEGIN, expected EVERY, FOR at line 1, char 52`},
+		{s: `CREATE CONTINUOUS QUERY myquery ON testdb RESAMPLE EVERY BEGIN SELECT count() INTO measure1 FROM myseries GROUP BY time(5m) END`, err: `found BEGIN, expected duration at line 1, char 58`},
+		{s: `CREATE CONTINUOUS QUERY myquery ON testdb RESAMPLE FOR 1m BEGIN SELECT count() INTO measure1 FROM myseries GROUP BY time(5m) END`, err: `FOR duration must be >= GROUP BY time duration: must be a minimum of 5m, got 1m at line 1, char 1`},
+		{s: `CREATE DOWNSAMPLE testdb`, err: `found testdb, expected ON at line 1, char 19`},
+		{s: `CREATE DOWNSAMPLE ON testdb FROM raw`, err: `found EOF, expected TO at line 1, char 38`},
+		{s: `CREATE DOWNSAMPLE ON testdb FROM raw TO hourly EVERY 1h`, err: `found EVERY, expected AGGREGATE at line 1, char 48`},
+		{s: `CREATE DOWNSAMPLE ON testdb FROM raw TO hourly AGGREGATE median EVERY 1h`, err: `unsupported aggregate: median at line 1, char 58`},
+		{s: `CREATE DOWNSAMPLE ON testdb FROM raw TO hourly AGGREGATE mean`, err: `found EOF, expected EVERY at line 1, char 63`},
+		{s: `CREATE DOWNSAMPLE ON testdb FROM raw TO hourly AGGREGATE mean EVERY INF`, err: `EVERY duration must be greater than zero at line 1, char 69`},
+		{s: `DROP DOWNSAMPLE ON testdb FROM raw`, err: `found EOF, expected TO at line 1, char 36`},
+		{s: `DROP DATA`, err: `found EOF, expected NODE at line 1, char 11`},
+		{s: `DROP DATA NODE`, err: `found EOF, expected number at line 1, char 16`},
+		{s: `SHOW DATA`, err: `found EOF, expected NODES at line 1, char 11`},
+		{s: `DROP SHARD`, err: `found EOF, expected number at line 1, char 12`},
+		{s: `DROP SHARD foo`, err: `found foo, expected number at line 1, char 12`},
+		{s: `SHOW SHARD`, err: `found EOF, expected GROUPS at line 1, char 12`},
+		{s: `SHOW SHARD GROUPS ON`, err: `found EOF, expected identifier at line 1, char 22`},
+		{s: `SHOW TAG VALUES CARDINALITY WITH region`, err: `found region, expected KEY at line 1, char 34`},
 		{s: `DROP FOO`, err: `found FOO, expected SERIES, CONTINUOUS, MEASUREMENT at line 1, char 6`},
 		{s: `DROP DATABASE`, err: `found EOF, expected identifier at line 1, char 15`},
 		{s: `DROP RETENTION`, err: `found EOF, expected POLICY at line 1, char 16`},
@@ -750,6 +1157,20 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `GRANT READ ON`, err: `found EOF, expected identifier at line 1, char 15`},
 		{s: `GRANT READ ON testdb`, err: `found EOF, expected TO at line 1, char 22`},
 		{s: `GRANT READ ON testdb TO`, err: `found EOF, expected identifier at line 1, char 25`}, {s: `GRANT`, err: `found EOF, expected READ, WRITE, ALL [PRIVILEGES] at line 1, char 7`},
+		{s: `GRANT READ ON "testdb"."cpu" TO jdoe`, err: `invalid privilege target: "testdb"."cpu" at line 1, char 15`},
+		{s: `GRANT READ ON testdb.. TO jdoe`, err: `found TO, expected regex at line 1, char 24`},
+		{s: `SHOW GRANTS`, err: `found EOF, expected FOR at line 1, char 13`},
+		{s: `GRANT READ ON testdb TO ROLE`, err: `found EOF, expected identifier at line 1, char 30`},
+		{s: `GRANT ROLE readers`, err: `found EOF, expected TO at line 1, char 20`},
+		{s: `REVOKE ROLE readers TO jdoe`, err: `found TO, expected FROM at line 1, char 21`},
+		{s: `CREATE ROLE`, err: `found EOF, expected identifier at line 1, char 13`},
+		{s: `DROP ROLE`, err: `found EOF, expected identifier at line 1, char 11`},
+		{s: `SET PASSWORD`, err: `found EOF, expected FOR at line 1, char 14`},
+		{s: `SET PASSWORD FOR jdoe`, err: `found EOF, expected = at line 1, char 23`},
+		{s: `SET PASSWORD FOR jdoe = secret`, err: `found secret, expected string at line 1, char 25`},
+		{s: `ALTER USER jdoe`, err: `found EOF, expected WITH, WITHOUT at line 1, char 17`},
+		{s: `ALTER USER jdoe WITH PASSWORD`, err: `found PASSWORD, expected ADMIN at line 1, char 22`},
+		{s: `SHOW GRANTS FOR`, err: `found EOF, expected identifier at line 1, char 17`},
 		{s: `REVOKE BOGUS`, err: `found BOGUS, expected READ, WRITE, ALL [PRIVILEGES] at line 1, char 8`},
 		{s: `REVOKE READ`, err: `found EOF, expected ON at line 1, char 13`},
 		{s: `REVOKE READ TO jdoe`, err: `found TO, expected ON at line 1, char 13`},
@@ -768,11 +1189,13 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 3.14`, err: `number must be an integer at line 1, char 67`},
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 0`, err: `invalid value 0: must be 1 <= n <= 2147483647 at line 1, char 67`},
 		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION bad`, err: `found bad, expected number at line 1, char 67`},
-		{s: `ALTER`, err: `found EOF, expected RETENTION at line 1, char 7`},
+		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 1 SIZE`, err: `found EOF, expected size at line 1, char 74`},
+		{s: `CREATE RETENTION POLICY policy1 ON testdb DURATION 1h REPLICATION 1 SIZE 1.5GB`, err: `number must be an integer at line 1, char 74`},
+		{s: `ALTER`, err: `found EOF, expected RETENTION, USER at line 1, char 7`},
 		{s: `ALTER RETENTION`, err: `found EOF, expected POLICY at line 1, char 17`},
 		{s: `ALTER RETENTION POLICY`, err: `found EOF, expected identifier at line 1, char 24`},
 		{s: `ALTER RETENTION POLICY policy1`, err: `found EOF, expected ON at line 1, char 32`}, {s: `ALTER RETENTION POLICY policy1 ON`, err: `found EOF, expected identifier at line 1, char 35`},
-		{s: `ALTER RETENTION POLICY policy1 ON testdb`, err: `found EOF, expected DURATION, RETENTION, DEFAULT at line 1, char 42`},
+		{s: `ALTER RETENTION POLICY policy1 ON testdb`, err: `found EOF, expected DURATION, RETENTION, SIZE, DEFAULT at line 1, char 42`},
 	}
 
 	for i, tt := range tests {
@@ -1129,6 +1552,15 @@ func MustParseExpr(s string) influxql.Expr {
 	return expr
 }
 
+// mustLoadLocation loads a time zone by name. Panic on error.
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return loc
+}
+
 // errstring converts an error to its string representation.
 func errstring(err error) string {
 	if err != nil {
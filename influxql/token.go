@@ -55,28 +55,39 @@ const (
 
 	keyword_beg
 	// Keywords
+	ADMIN
+	AGGREGATE
 	ALL
 	ALTER
 	AS
 	ASC
+	BACKFILL
 	BEGIN
+	BROKERS
 	BY
+	CARDINALITY
 	CREATE
 	CONTINUOUS
+	DATA
 	DATABASE
 	DATABASES
 	DEFAULT
 	DELETE
 	DESC
+	DOWNSAMPLE
 	DROP
 	DURATION
 	END
+	EVERY
 	EXISTS
 	EXPLAIN
 	FIELD
+	FOR
 	FROM
 	GRANT
+	GRANTS
 	GROUP
+	GROUPS
 	IF
 	IN
 	INF
@@ -89,6 +100,8 @@ const (
 	SHOW
 	MEASUREMENT
 	MEASUREMENTS
+	NODE
+	NODES
 	OFFSET
 	ON
 	ORDER
@@ -100,10 +113,21 @@ const (
 	QUERY
 	READ
 	REPLICATION
+	RESAMPLE
 	RETENTION
 	REVOKE
+	ROLE
+	ROLES
 	SELECT
 	SERIES
+	SERVERS
+	SET
+	SHARD
+	SHARDS
+	SIZE
+	SLIMIT
+	SOFFSET
+	STATS
 	TAG
 	TO
 	USER
@@ -111,6 +135,7 @@ const (
 	VALUES
 	WHERE
 	WITH
+	WITHOUT
 	WRITE
 	keyword_end
 )
@@ -153,28 +178,39 @@ var tokens = [...]string{
 	SEMICOLON: ";",
 	DOT:       ".",
 
+	ADMIN:        "ADMIN",
+	AGGREGATE:    "AGGREGATE",
 	ALL:          "ALL",
 	ALTER:        "ALTER",
 	AS:           "AS",
 	ASC:          "ASC",
+	BACKFILL:     "BACKFILL",
 	BEGIN:        "BEGIN",
+	BROKERS:      "BROKERS",
 	BY:           "BY",
+	CARDINALITY:  "CARDINALITY",
 	CREATE:       "CREATE",
 	CONTINUOUS:   "CONTINUOUS",
+	DATA:         "DATA",
 	DATABASE:     "DATABASE",
 	DATABASES:    "DATABASES",
 	DEFAULT:      "DEFAULT",
 	DELETE:       "DELETE",
 	DESC:         "DESC",
+	DOWNSAMPLE:   "DOWNSAMPLE",
 	DROP:         "DROP",
 	DURATION:     "DURATION",
 	END:          "END",
+	EVERY:        "EVERY",
 	EXISTS:       "EXISTS",
 	EXPLAIN:      "EXPLAIN",
 	FIELD:        "FIELD",
+	FOR:          "FOR",
 	FROM:         "FROM",
 	GRANT:        "GRANT",
+	GRANTS:       "GRANTS",
 	GROUP:        "GROUP",
+	GROUPS:       "GROUPS",
 	IF:           "IF",
 	IN:           "IN",
 	INF:          "INF",
@@ -187,6 +223,8 @@ var tokens = [...]string{
 	SHOW:         "SHOW",
 	MEASUREMENT:  "MEASUREMENT",
 	MEASUREMENTS: "MEASUREMENTS",
+	NODE:         "NODE",
+	NODES:        "NODES",
 	OFFSET:       "OFFSET",
 	ON:           "ON",
 	ORDER:        "ORDER",
@@ -198,10 +236,21 @@ var tokens = [...]string{
 	QUERY:        "QUERY",
 	READ:         "READ",
 	REPLICATION:  "REPLICATION",
+	RESAMPLE:     "RESAMPLE",
 	RETENTION:    "RETENTION",
 	REVOKE:       "REVOKE",
+	ROLE:         "ROLE",
+	ROLES:        "ROLES",
 	SELECT:       "SELECT",
 	SERIES:       "SERIES",
+	SERVERS:      "SERVERS",
+	SET:          "SET",
+	SHARD:        "SHARD",
+	SHARDS:       "SHARDS",
+	SIZE:         "SIZE",
+	SLIMIT:       "SLIMIT",
+	SOFFSET:      "SOFFSET",
+	STATS:        "STATS",
 	TAG:          "TAG",
 	TO:           "TO",
 	USER:         "USER",
@@ -209,6 +258,7 @@ var tokens = [...]string{
 	VALUES:       "VALUES",
 	WHERE:        "WHERE",
 	WITH:         "WITH",
+	WITHOUT:      "WITHOUT",
 	WRITE:        "WRITE",
 }
 
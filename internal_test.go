@@ -3,7 +3,11 @@ package influxdb
 // This file is run within the "influxdb" package and allows for internal unit tests.
 
 import (
+	"bytes"
+	"log"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -310,6 +314,33 @@ func TestShardGroup_Contains(t *testing.T) {
 	}
 }
 
+// Ensure a continuous query's RESAMPLE EVERY duration overrides the server's run frequency.
+func TestServer_shouldRunContinuousQuery_ResampleEvery(t *testing.T) {
+	s := &Server{ComputeRunsPerInterval: 10, ComputeNoMoreThan: time.Minute}
+
+	for i, tt := range []struct {
+		q       string
+		lastRun time.Duration
+		exp     bool
+	}{
+		{q: `CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`, lastRun: 7 * time.Minute, exp: true},
+		{q: `CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`, lastRun: 5 * time.Minute, exp: false},
+		{q: `CREATE CONTINUOUS QUERY myquery ON foo RESAMPLE EVERY 10m BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`, lastRun: 7 * time.Minute, exp: false},
+		{q: `CREATE CONTINUOUS QUERY myquery ON foo RESAMPLE EVERY 10m BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`, lastRun: 11 * time.Minute, exp: true},
+		{q: `CREATE CONTINUOUS QUERY myquery ON foo RESAMPLE EVERY 30s BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`, lastRun: 45 * time.Second, exp: true},
+	} {
+		cq, err := NewContinuousQuery(tt.q)
+		if err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, err)
+		}
+		cq.lastRun = time.Now().Add(-tt.lastRun)
+
+		if v := s.shouldRunContinuousQuery(cq); v != tt.exp {
+			t.Errorf("%d. %s: mismatch: exp=%v, got=%v", i, tt.q, tt.exp, v)
+		}
+	}
+}
+
 // MustParseExpr parses an expression string and returns its AST representation.
 func MustParseExpr(s string) influxql.Expr {
 	expr, err := influxql.ParseExpr(s)
@@ -322,3 +353,66 @@ func MustParseExpr(s string) influxql.Expr {
 func strref(s string) *string {
 	return &s
 }
+
+// Ensure the rate limiter only keeps state for users and databases with limits.
+func TestRateLimiter_state_NoLimits(t *testing.T) {
+	l := NewRateLimiter()
+	l.DatabaseOverrides["foo"] = RateLimits{RequestsPerSecond: 1}
+
+	if err := l.AllowRequest("john", "bar"); err != nil {
+		t.Fatal(err)
+	} else if len(l.users) != 0 || len(l.dbs) != 0 {
+		t.Fatalf("unexpected state: users=%d, dbs=%d", len(l.users), len(l.dbs))
+	}
+
+	if err := l.AllowRequest("john", "foo"); err != nil {
+		t.Fatal(err)
+	} else if len(l.dbs) != 1 {
+		t.Fatalf("unexpected database state count: %d", len(l.dbs))
+	}
+
+	// Removing the limits removes the state.
+	delete(l.DatabaseOverrides, "foo")
+	if err := l.AllowRequest("john", "foo"); err != nil {
+		t.Fatal(err)
+	} else if len(l.dbs) != 0 {
+		t.Fatalf("unexpected database state count: %d", len(l.dbs))
+	}
+}
+
+// Ensure the state of dropped users and databases is removed.
+func TestRateLimiter_Drop(t *testing.T) {
+	l := NewRateLimiter()
+	l.UserLimits = RateLimits{RequestsPerSecond: 1}
+	l.DatabaseLimits = RateLimits{RequestsPerSecond: 1}
+	if err := l.AllowRequest("john", "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.DropUser("john")
+	l.DropDatabase("foo")
+	if len(l.users) != 0 || len(l.dbs) != 0 {
+		t.Fatalf("unexpected state: users=%d, dbs=%d", len(l.users), len(l.dbs))
+	}
+}
+
+// Ensure dropped points are logged at most once per interval.
+func TestDroppedPoints_Add(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	d := NewDroppedPoints("test ")
+	d.Interval = time.Hour
+	for i := 0; i < 3; i++ {
+		d.Add(2, ErrRateLimitExceeded{Database: "foo", Limit: pointsPerSecondLimit})
+	}
+
+	if n := strings.Count(buf.String(), "\n"); n != 1 {
+		t.Fatalf("unexpected line count: %d", n)
+	} else if !strings.Contains(buf.String(), "test dropped 2 points: rate limit exceeded: database=foo limit=points-per-second") {
+		t.Fatalf("unexpected log: %s", buf.String())
+	} else if d.n != 4 {
+		t.Fatalf("unexpected pending count: %d", d.n)
+	}
+}
@@ -962,6 +962,9 @@ func (r *Replica) closeWriter() {
 	}
 }
 
+// ID returns the replica's identifier.
+func (r *Replica) ID() uint64 { return r.id }
+
 // Topics returns a list of topic names that the replica is subscribed to.
 func (r *Replica) Topics() []uint64 {
 	a := make([]uint64, 0, len(r.topics))
@@ -53,6 +53,9 @@ type Client struct {
 
 	// The logging interface used by the client for out-of-band errors.
 	Logger *log.Logger
+
+	// The client used to send requests to brokers. Uses http.DefaultClient if nil.
+	HTTPClient *http.Client
 }
 
 // NewClient returns a new instance of Client.
@@ -106,6 +109,14 @@ func (c *Client) SetLeaderURL(u *url.URL) {
 	c.config.Leader = v
 }
 
+// httpClient returns the HTTP client used to send requests to brokers.
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
 // SetLogOutput sets writer for all Client log output.
 func (c *Client) SetLogOutput(w io.Writer) {
 	c.Logger = log.New(w, "[messaging] ", log.LstdFlags)
@@ -208,7 +219,7 @@ func (c *Client) Publish(m *Message) (uint64, error) {
 			"type":    {strconv.FormatUint(uint64(m.Type), 10)},
 			"topicID": {strconv.FormatUint(m.TopicID, 10)},
 		}.Encode()
-		resp, err = http.Post(u.String(), "application/octet-stream", bytes.NewReader(m.Data))
+		resp, err = c.httpClient().Post(u.String(), "application/octet-stream", bytes.NewReader(m.Data))
 		if err != nil {
 			return 0, err
 		}
@@ -254,7 +265,7 @@ func (c *Client) CreateReplica(id uint64, u *url.URL) error {
 			"id":  {strconv.FormatUint(id, 10)},
 			"url": {u.String()},
 		}.Encode()
-		resp, err = http.Post(u.String(), "application/octet-stream", nil)
+		resp, err = c.httpClient().Post(u.String(), "application/octet-stream", nil)
 		if err != nil {
 			return err
 		}
@@ -288,7 +299,7 @@ func (c *Client) DeleteReplica(id uint64) error {
 		u.Path = "/messaging/replicas"
 		u.RawQuery = url.Values{"id": {strconv.FormatUint(id, 10)}}.Encode()
 		req, _ := http.NewRequest("DELETE", u.String(), nil)
-		resp, err = http.DefaultClient.Do(req)
+		resp, err = c.httpClient().Do(req)
 		if err != nil {
 			return err
 		}
@@ -324,7 +335,7 @@ func (c *Client) Subscribe(replicaID, topicID uint64) error {
 			"replicaID": {strconv.FormatUint(replicaID, 10)},
 			"topicID":   {strconv.FormatUint(topicID, 10)},
 		}.Encode()
-		resp, err = http.Post(u.String(), "application/octet-stream", nil)
+		resp, err = c.httpClient().Post(u.String(), "application/octet-stream", nil)
 		if err != nil {
 			return err
 		}
@@ -361,7 +372,7 @@ func (c *Client) Unsubscribe(replicaID, topicID uint64) error {
 			"topicID":   {strconv.FormatUint(topicID, 10)},
 		}.Encode()
 		req, _ := http.NewRequest("DELETE", u.String(), nil)
-		resp, err = http.DefaultClient.Do(req)
+		resp, err = c.httpClient().Do(req)
 		if err != nil {
 			return err
 		}
@@ -416,7 +427,7 @@ func (c *Client) streamer(done chan chan struct{}) {
 func (c *Client) streamFromURL(u *url.URL, done chan chan struct{}) error {
 	// Set the replica id on the URL and open the stream.
 	u.RawQuery = url.Values{"replicaID": {strconv.FormatUint(c.replicaID, 10)}}.Encode()
-	resp, err := http.Get(u.String())
+	resp, err := c.httpClient().Get(u.String())
 	if err != nil {
 		time.Sleep(c.ReconnectTimeout)
 		return nil
@@ -45,6 +45,7 @@ func (m *metastore) init() error {
 		_, _ = tx.CreateBucketIfNotExists([]byte("DataNodes"))
 		_, _ = tx.CreateBucketIfNotExists([]byte("Databases"))
 		_, _ = tx.CreateBucketIfNotExists([]byte("Users"))
+		_, _ = tx.CreateBucketIfNotExists([]byte("Roles"))
 		return nil
 	})
 }
@@ -314,6 +315,27 @@ func (tx *metatx) deleteUser(name string) error {
 	return tx.Bucket([]byte("Users")).Delete([]byte(name))
 }
 
+// roles returns a list of all roles from the metastore.
+func (tx *metatx) roles() (a []*Role) {
+	c := tx.Bucket([]byte("Roles")).Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		r := &Role{}
+		mustUnmarshalJSON(v, &r)
+		a = append(a, r)
+	}
+	return
+}
+
+// saveRole persists a role to the metastore.
+func (tx *metatx) saveRole(r *Role) error {
+	return tx.Bucket([]byte("Roles")).Put([]byte(r.Name), mustMarshalJSON(r))
+}
+
+// deleteRole removes the role from the metastore.
+func (tx *metatx) deleteRole(name string) error {
+	return tx.Bucket([]byte("Roles")).Delete([]byte(name))
+}
+
 // u64tob converts a uint64 into an 8-byte slice.
 func u64tob(v uint64) []byte {
 	b := make([]byte, 8)
@@ -12,7 +12,18 @@ import (
 )
 
 // HTTPTransport represents a transport for sending RPCs over the HTTP protocol.
-type HTTPTransport struct{}
+type HTTPTransport struct {
+	// The client used to send requests. Uses http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// client returns the HTTP client used to send requests.
+func (t *HTTPTransport) client() *http.Client {
+	if t.Client == nil {
+		return http.DefaultClient
+	}
+	return t.Client
+}
 
 // Join requests membership into a node's cluster.
 func (t *HTTPTransport) Join(uri *url.URL, nodeURL *url.URL) (uint64, uint64, *Config, error) {
@@ -22,7 +33,7 @@ func (t *HTTPTransport) Join(uri *url.URL, nodeURL *url.URL) (uint64, uint64, *C
 	u.RawQuery = (&url.Values{"url": {nodeURL.String()}}).Encode()
 
 	// Send HTTP request.
-	resp, err := http.Get(u.String())
+	resp, err := t.client().Get(u.String())
 	if err != nil {
 		return 0, 0, nil, err
 	}
@@ -62,7 +73,7 @@ func (t *HTTPTransport) Leave(uri *url.URL, id uint64) error {
 	u.RawQuery = (&url.Values{"id": {strconv.FormatUint(id, 10)}}).Encode()
 
 	// Send HTTP request.
-	resp, err := http.Get(u.String())
+	resp, err := t.client().Get(u.String())
 	if err != nil {
 		return err
 	}
@@ -90,7 +101,7 @@ func (t *HTTPTransport) Heartbeat(uri *url.URL, term, commitIndex, leaderID uint
 	u.RawQuery = v.Encode()
 
 	// Send HTTP request.
-	resp, err := http.Get(u.String())
+	resp, err := t.client().Get(u.String())
 	if err != nil {
 		return 0, err
 	}
@@ -125,7 +136,7 @@ func (t *HTTPTransport) ReadFrom(uri *url.URL, id, term, index uint64) (io.ReadC
 	u.RawQuery = v.Encode()
 
 	// Send HTTP request.
-	resp, err := http.Get(u.String())
+	resp, err := t.client().Get(u.String())
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +165,7 @@ func (t *HTTPTransport) RequestVote(uri *url.URL, term, candidateID, lastLogInde
 	u.RawQuery = v.Encode()
 
 	// Send HTTP request.
-	resp, err := http.Get(u.String())
+	resp, err := t.client().Get(u.String())
 	if err != nil {
 		return err
 	}
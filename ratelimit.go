@@ -0,0 +1,333 @@
+package influxdb
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimits restricts the rate of requests, points written and queries
+// that a single user or database may issue. Zero means unlimited.
+type RateLimits struct {
+	PointsPerSecond      int // maximum number of points written per second
+	RequestsPerSecond    int // maximum number of write and query requests per second
+	MaxConcurrentQueries int // maximum number of queries executing at one time
+}
+
+// ErrRateLimitExceeded is returned when a request exceeds the rate limits of
+// its user or database.
+type ErrRateLimitExceeded struct {
+	User       string // only set if the user's limit was exceeded
+	Database   string // only set if the database's limit was exceeded
+	Limit      string
+	RetryAfter time.Duration
+}
+
+// Error returns the text of the error.
+func (e ErrRateLimitExceeded) Error() string {
+	if e.User != "" {
+		return fmt.Sprintf("rate limit exceeded: user=%s limit=%s", e.User, e.Limit)
+	}
+	return fmt.Sprintf("rate limit exceeded: database=%s limit=%s", e.Database, e.Limit)
+}
+
+// Rate limit names used in ErrRateLimitExceeded.
+const (
+	pointsPerSecondLimit      = "points-per-second"
+	requestsPerSecondLimit    = "requests-per-second"
+	maxConcurrentQueriesLimit = "max-concurrent-queries"
+)
+
+// RateLimiter enforces rate limits per user and per database.
+// Each user and each database is limited separately and a request must
+// be within the limits of both. A nil RateLimiter allows everything.
+type RateLimiter struct {
+	mu      sync.Mutex
+	users   map[string]*rateLimitState
+	dbs     map[string]*rateLimitState
+	nowFunc func() time.Time
+
+	// Limits applied to each user and database without an override.
+	UserLimits     RateLimits
+	DatabaseLimits RateLimits
+
+	// Limits that override the defaults for individual users and databases.
+	UserOverrides     map[string]RateLimits
+	DatabaseOverrides map[string]RateLimits
+}
+
+// NewRateLimiter returns a new instance of RateLimiter.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		users:             make(map[string]*rateLimitState),
+		dbs:               make(map[string]*rateLimitState),
+		nowFunc:           time.Now,
+		UserOverrides:     make(map[string]RateLimits),
+		DatabaseOverrides: make(map[string]RateLimits),
+	}
+}
+
+// AllowRequest takes a request from the user's and the database's allowance.
+// An empty user or database is not limited.
+func (l *RateLimiter) AllowRequest(user, database string) error {
+	return l.take(user, database, tokens{requestsPerSecondLimit, 1})
+}
+
+// AllowPoints takes n points from the user's and the database's allowance.
+// An empty user or database is not limited.
+func (l *RateLimiter) AllowPoints(user, database string, n int) error {
+	return l.take(user, database, tokens{pointsPerSecondLimit, n})
+}
+
+// AllowWrite takes a request and n points from the user's and the database's allowance.
+// Nothing is taken unless both the request and the points are within the limits.
+// An empty user or database is not limited.
+func (l *RateLimiter) AllowWrite(user, database string, n int) error {
+	return l.take(user, database, tokens{requestsPerSecondLimit, 1}, tokens{pointsPerSecondLimit, n})
+}
+
+// BeginQuery reserves a query slot for the user and the database.
+// Every successful call must be followed by a call to EndQuery.
+func (l *RateLimiter) BeginQuery(user, database string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, d := l.state(user, database)
+	if u != nil && u.limits.MaxConcurrentQueries > 0 && u.queries >= u.limits.MaxConcurrentQueries {
+		return ErrRateLimitExceeded{User: user, Limit: maxConcurrentQueriesLimit, RetryAfter: time.Second}
+	}
+	if d != nil && d.limits.MaxConcurrentQueries > 0 && d.queries >= d.limits.MaxConcurrentQueries {
+		return ErrRateLimitExceeded{Database: database, Limit: maxConcurrentQueriesLimit, RetryAfter: time.Second}
+	}
+
+	if u != nil {
+		u.queries++
+	}
+	if d != nil {
+		d.queries++
+	}
+	return nil
+}
+
+// EndQuery releases a query slot reserved by BeginQuery.
+func (l *RateLimiter) EndQuery(user, database string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, d := l.state(user, database)
+	if u != nil && u.queries > 0 {
+		u.queries--
+	}
+	if d != nil && d.queries > 0 {
+		d.queries--
+	}
+}
+
+// DropUser removes the state of a user, such as when the user is deleted.
+func (l *RateLimiter) DropUser(name string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.users, name)
+}
+
+// DropDatabase removes the state of a database, such as when the database is dropped.
+func (l *RateLimiter) DropDatabase(name string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.dbs, name)
+}
+
+// tokens represents a number of tokens to take from the named bucket.
+type tokens struct {
+	limit string
+	n     int
+}
+
+// take removes tokens from the named buckets of both the user and the database.
+// Nothing is taken unless every bucket has enough tokens.
+func (l *RateLimiter) take(user, database string, a ...tokens) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.nowFunc()
+	u, d := l.state(user, database)
+
+	for _, t := range a {
+		if u != nil {
+			if wait := u.bucket(t.limit).wait(now, t.n); wait > 0 {
+				return ErrRateLimitExceeded{User: user, Limit: t.limit, RetryAfter: wait}
+			}
+		}
+		if d != nil {
+			if wait := d.bucket(t.limit).wait(now, t.n); wait > 0 {
+				return ErrRateLimitExceeded{Database: database, Limit: t.limit, RetryAfter: wait}
+			}
+		}
+	}
+
+	for _, t := range a {
+		if u != nil {
+			u.bucket(t.limit).take(t.n)
+		}
+		if d != nil {
+			d.bucket(t.limit).take(t.n)
+		}
+	}
+	return nil
+}
+
+// state returns the state of the user and the database. Either is nil if its
+// name is blank or it has no limits.
+func (l *RateLimiter) state(user, database string) (u, d *rateLimitState) {
+	if user != "" {
+		limits, ok := l.UserOverrides[user]
+		if !ok {
+			limits = l.UserLimits
+		}
+		u = findRateLimitState(l.users, user, limits)
+	}
+	if database != "" {
+		limits, ok := l.DatabaseOverrides[database]
+		if !ok {
+			limits = l.DatabaseLimits
+		}
+		d = findRateLimitState(l.dbs, database, limits)
+	}
+	return
+}
+
+// findRateLimitState returns the state for name, creating it if necessary.
+// The state is reset if its limits have changed. Returns nil, and removes any
+// existing state, if there are no limits.
+func findRateLimitState(m map[string]*rateLimitState, name string, limits RateLimits) *rateLimitState {
+	if limits == (RateLimits{}) {
+		delete(m, name)
+		return nil
+	}
+
+	s := m[name]
+	if s == nil || s.limits != limits {
+		s = &rateLimitState{
+			limits:   limits,
+			points:   newTokenBucket(limits.PointsPerSecond),
+			requests: newTokenBucket(limits.RequestsPerSecond),
+		}
+		if old := m[name]; old != nil {
+			s.queries = old.queries
+		}
+		m[name] = s
+	}
+	return s
+}
+
+// rateLimitState holds the allowance of a single user or database.
+type rateLimitState struct {
+	limits   RateLimits
+	points   *tokenBucket
+	requests *tokenBucket
+	queries  int
+}
+
+// bucket returns the token bucket for the named limit.
+func (s *rateLimitState) bucket(limit string) *tokenBucket {
+	if limit == pointsPerSecondLimit {
+		return s.points
+	}
+	return s.requests
+}
+
+// tokenBucket refills at a fixed rate per second and holds at most one second of tokens.
+// A nil bucket is unlimited.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket, or nil if rate is zero.
+func newTokenBucket(rate int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{rate: float64(rate), tokens: float64(rate)}
+}
+
+// wait refills the bucket and returns how long until n tokens can be taken.
+// Batches larger than the bucket are allowed once it is full.
+func (b *tokenBucket) wait(now time.Time, n int) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.rate, b.tokens+b.rate*now.Sub(b.last).Seconds())
+	}
+	b.last = now
+
+	need := math.Min(float64(n), b.rate)
+	if b.tokens >= need {
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
+
+// take removes n tokens from the bucket. The bucket may go negative.
+func (b *tokenBucket) take(n int) {
+	if b == nil {
+		return
+	}
+	b.tokens -= float64(n)
+}
+
+// DroppedPoints counts the points an input drops because of rate limits and logs
+// a summary at most once per interval, so an overloaded input doesn't flood the log.
+type DroppedPoints struct {
+	mu   sync.Mutex
+	n    int
+	last time.Time
+
+	Prefix   string        // prepended to each message
+	Interval time.Duration // minimum time between messages
+}
+
+// NewDroppedPoints returns a new instance of DroppedPoints that logs messages with prefix.
+func NewDroppedPoints(prefix string) *DroppedPoints {
+	return &DroppedPoints{Prefix: prefix, Interval: 10 * time.Second}
+}
+
+// Add counts n dropped points. If the interval has passed since the last message
+// then the points dropped since then are logged with err as the reason.
+func (d *DroppedPoints) Add(n int, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.n += n
+	now := time.Now()
+	if now.Sub(d.last) < d.Interval {
+		return
+	}
+	log.Printf("%sdropped %d points: %s", d.Prefix, d.n, err)
+	d.n, d.last = 0, now
+}
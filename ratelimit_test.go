@@ -0,0 +1,112 @@
+package influxdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb"
+)
+
+// Ensure points are limited per database and per user.
+func TestRateLimiter_AllowPoints(t *testing.T) {
+	l := influxdb.NewRateLimiter()
+	l.DatabaseLimits = influxdb.RateLimits{PointsPerSecond: 10}
+	l.UserOverrides["john"] = influxdb.RateLimits{PointsPerSecond: 5}
+
+	// Use up the allowance of the database.
+	if err := l.AllowPoints("", "foo", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := l.AllowPoints("", "foo", 1).(influxdb.ErrRateLimitExceeded); !ok {
+		t.Fatalf("expected rate limit error")
+	} else if err.Database != "foo" || err.Limit != "points-per-second" || err.RetryAfter <= 0 || err.RetryAfter > time.Second {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	// Other databases have their own allowance.
+	if err := l.AllowPoints("", "bar", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user's limit is checked along with the database's.
+	if err := l.AllowPoints("john", "baz", 5); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := l.AllowPoints("john", "bat", 1).(influxdb.ErrRateLimitExceeded); !ok || err.User != "john" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	// Users without a limit are only limited by the database.
+	if err := l.AllowPoints("susy", "bat", 10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a batch larger than the limit is allowed when the allowance is full.
+func TestRateLimiter_AllowPoints_LargeBatch(t *testing.T) {
+	l := influxdb.NewRateLimiter()
+	l.DatabaseLimits = influxdb.RateLimits{PointsPerSecond: 10}
+
+	if err := l.AllowPoints("", "foo", 25); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := l.AllowPoints("", "foo", 1).(influxdb.ErrRateLimitExceeded); !ok {
+		t.Fatalf("expected rate limit error")
+	} else if err.RetryAfter <= time.Second {
+		t.Fatalf("unexpected retry after: %s", err.RetryAfter)
+	}
+}
+
+// Ensure a write takes nothing unless both the request and the points are within the limits.
+func TestRateLimiter_AllowWrite(t *testing.T) {
+	l := influxdb.NewRateLimiter()
+	l.DatabaseLimits = influxdb.RateLimits{PointsPerSecond: 10, RequestsPerSecond: 1}
+
+	if err := l.AllowWrite("", "foo", 5); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := l.AllowWrite("", "foo", 5).(influxdb.ErrRateLimitExceeded); !ok || err.Limit != "requests-per-second" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	// The rejected write didn't take any points.
+	if err := l.AllowPoints("", "foo", 5); err != nil {
+		t.Fatal(err)
+	} else if _, ok := l.AllowPoints("", "foo", 1).(influxdb.ErrRateLimitExceeded); !ok {
+		t.Fatalf("expected rate limit error")
+	}
+}
+
+// Ensure the number of concurrent queries is limited.
+func TestRateLimiter_BeginQuery(t *testing.T) {
+	l := influxdb.NewRateLimiter()
+	l.UserLimits = influxdb.RateLimits{MaxConcurrentQueries: 1}
+
+	if err := l.BeginQuery("john", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := l.BeginQuery("john", "bar").(influxdb.ErrRateLimitExceeded); !ok || err.User != "john" || err.Limit != "max-concurrent-queries" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if err := l.BeginQuery("susy", "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.EndQuery("john", "foo")
+	if err := l.BeginQuery("john", "bar"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure a nil rate limiter allows everything.
+func TestRateLimiter_Nil(t *testing.T) {
+	var l *influxdb.RateLimiter
+	if err := l.AllowRequest("john", "foo"); err != nil {
+		t.Fatal(err)
+	} else if err := l.AllowPoints("john", "foo", 1000); err != nil {
+		t.Fatal(err)
+	} else if err := l.BeginQuery("john", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	l.EndQuery("john", "foo")
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"log"
 	"net/http"
@@ -62,12 +63,21 @@ type Server struct {
 	dataNodes map[uint64]*DataNode // data nodes by id
 	databases map[string]*database // databases by name
 	users     map[string]*User     // user by name
+	roles     map[string]*Role     // role by name
 
 	shards map[uint64]*Shard // shards by shard id
 
 	Logger     *log.Logger
 	WriteTrace bool // Detailed logging of write path
 
+	// The broker running in the same process as the server, if any.
+	// Used to report the state of the broker cluster.
+	Broker *messaging.Broker
+
+	// The client used to send requests to other nodes in the cluster.
+	// Uses http.DefaultClient if nil.
+	HTTPClient *http.Client
+
 	authenticationEnabled bool
 
 	// continuous query settings
@@ -76,6 +86,31 @@ type Server struct {
 	ComputeRunsPerInterval int
 	ComputeNoMoreThan      time.Duration
 
+	// The maximum amount of time a continuous query will go back to compute
+	// intervals missed since its last completed interval. Zero disables catch-up.
+	MaxCatchUpWindow time.Duration
+
+	// The amount of time to wait between intervals when backfilling a continuous
+	// query, to limit the load put on the cluster.
+	BackfillDelay time.Duration
+
+	// Series cardinality limits applied to every database, and limits that
+	// override them for individual databases by name. The limits are checked
+	// on the node that receives a write, so concurrent writes through other
+	// nodes may exceed them slightly.
+	SeriesLimits         SeriesLimits
+	DatabaseSeriesLimits map[string]SeriesLimits
+
+	// Maximum number of bytes of shard data kept for every database, and sizes
+	// that override it for individual databases by name. Zero means unlimited.
+	// Sizes are only enforced while the cluster has a single data node.
+	MaxDatabaseSize  int64
+	DatabaseMaxSizes map[string]int64
+
+	// Rate limiter shared with the API and inputs, if set. The state of users
+	// and databases is removed from it when they are dropped.
+	RateLimiter *RateLimiter
+
 	// This is the last time this data node has run continuous queries.
 	// Keep this state in memory so if a broker makes a request in another second
 	// to compute, it won't rerun CQs that have already been run. If this data node
@@ -92,6 +127,7 @@ func NewServer() *Server {
 		dataNodes: make(map[uint64]*DataNode),
 		databases: make(map[string]*database),
 		users:     make(map[string]*User),
+		roles:     make(map[string]*Role),
 
 		shards: make(map[uint64]*Shard),
 		Logger: log.New(os.Stderr, "[server] ", log.LstdFlags),
@@ -264,12 +300,30 @@ func (s *Server) load() error {
 			}
 		}
 
+		// Qualify the measurements of continuous queries so they can run.
+		for _, db := range s.databases {
+			for _, cq := range db.continuousQueries {
+				if err := s.normalizeStatement(cq.cq.Source, cq.cq.Database); err != nil {
+					return fmt.Errorf("cannot normalize continuous query: %s, err=%s", cq.cq.Name, err)
+				}
+			}
+		}
+
 		// Load users.
 		s.users = make(map[string]*User)
 		for _, u := range tx.users() {
 			s.users[u.Name] = u
 		}
 
+		// Load roles and attach them to their users.
+		s.roles = make(map[string]*Role)
+		for _, r := range tx.roles() {
+			s.roles[r.Name] = r
+		}
+		for _, u := range s.users {
+			s.resolveRoles(u)
+		}
+
 		return nil
 	})
 }
@@ -294,23 +348,120 @@ func (s *Server) StartRetentionPolicyEnforcement(checkInterval time.Duration) er
 	return nil
 }
 
-// EnforceRetentionPolicies ensures that data that is aging-out due to retention policies
-// is removed from the server.
+// EnforceRetentionPolicies ensures that data that is aging-out due to retention policies,
+// or that exceeds the maximum size of a policy or database, is removed from the server.
 func (s *Server) EnforceRetentionPolicies() {
 	log.Println("retention policy enforcement check commencing")
 
-	// Check all shard groups.
+	type group struct {
+		database string
+		policy   string
+		id       uint64
+	}
+
+	// Check all shard groups. Deletions are requested after the lock is
+	// released since the delete command is applied under the same lock.
+	// A group can be due for deletion for more than one reason so each
+	// group is only requested once.
+	var groups []group
+	removed := make(map[uint64]bool)
+	remove := func(database, policy string, g *ShardGroup, reason string) {
+		if removed[g.ID] {
+			return
+		}
+		log.Printf("shard group %d, retention policy %s, database %s %s, due for deletion",
+			g.ID, policy, database, reason)
+		removed[g.ID] = true
+		groups = append(groups, group{database, policy, g.ID})
+	}
+
+	// Remove expired groups and copy the groups of each policy so they can be
+	// sized after the lock is released, since reading the shard stores can take
+	// a while.
+	type policyGroups struct {
+		name    string
+		maxSize int64
+		groups  []*ShardGroup
+		newest  *ShardGroup
+	}
+	type databaseGroups struct {
+		name     string
+		maxSize  int64
+		policies []policyGroups
+	}
+	var dbs []databaseGroups
+	s.mu.RLock()
+	clustered := len(s.dataNodes) > 1
 	for _, db := range s.databases {
+		dbg := databaseGroups{name: db.name, maxSize: s.maxDatabaseSize(db.name)}
 		for _, rp := range db.policies {
 			for _, g := range rp.shardGroups {
 				if rp.Duration != 0 && g.EndTime.Add(rp.Duration).Before(time.Now().UTC()) {
-					log.Printf("shard group %d, retention policy %s, database %s due for deletion",
-						g.ID, rp.Name, db.name)
-					if err := s.DeleteShardGroup(db.name, rp.Name, g.ID); err != nil {
-						log.Printf("failed to request deletion of shard group %d: %s", g.ID, err.Error())
-					}
+					remove(db.name, rp.Name, g, "expired")
+				}
+			}
+			dbg.policies = append(dbg.policies, policyGroups{
+				name:    rp.Name,
+				maxSize: rp.MaxSize,
+				groups:  append([]*ShardGroup(nil), rp.shardGroups...),
+				newest:  rp.newestShardGroup(),
+			})
+		}
+		dbs = append(dbs, dbg)
+	}
+	s.mu.RUnlock()
+
+	// Enforce the maximum sizes of policies and databases. Each node only knows
+	// the size of the shards it stores, so sizes are only enforced on a single node.
+	for _, db := range dbs {
+		if clustered {
+			if db.maxSize > 0 {
+				log.Printf("database %s max size ignored, not supported with multiple data nodes", db.name)
+			}
+			for _, rp := range db.policies {
+				if rp.maxSize > 0 {
+					log.Printf("retention policy %s, database %s max size ignored, not supported with multiple data nodes", rp.name, db.name)
+				}
+			}
+			continue
+		}
+
+		sizes := make(map[uint64]int64)
+		for _, rp := range db.policies {
+			for _, g := range rp.groups {
+				sizes[g.ID] = g.size()
+			}
+		}
+
+		// The newest group of each policy is never removed.
+		var all []*ShardGroup
+		policies := make(map[uint64]string)
+		keep := make(map[uint64]bool)
+		for _, rp := range db.policies {
+			if rp.newest != nil {
+				keep[rp.newest.ID] = true
+			}
+			if rp.maxSize > 0 {
+				for _, g := range oversizedShardGroups(rp.groups, sizes, rp.maxSize, removed, keep) {
+					remove(db.name, rp.name, g, fmt.Sprintf("exceeds policy max size %d", rp.maxSize))
 				}
 			}
+			for _, g := range rp.groups {
+				all = append(all, g)
+				policies[g.ID] = rp.name
+			}
+		}
+
+		if db.maxSize > 0 {
+			for _, g := range oversizedShardGroups(all, sizes, db.maxSize, removed, keep) {
+				remove(db.name, policies[g.ID], g, fmt.Sprintf("exceeds database max size %d", db.maxSize))
+			}
+		}
+	}
+
+	for _, g := range groups {
+		if err := s.DeleteShardGroup(g.database, g.policy, g.id); err != nil {
+			log.Printf("failed to request deletion of shard group %d: %s", g.id, err.Error())
 		}
 	}
 }
@@ -428,6 +579,14 @@ func (s *Server) Initialize(u *url.URL) error {
 	return nil
 }
 
+// httpClient returns the client used to send requests to other nodes in the cluster.
+func (s *Server) httpClient() *http.Client {
+	if s.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return s.HTTPClient
+}
+
 // This is the same struct we use in the httpd package, but
 // it seems overkill to export it and share it
 type dataNodeJSON struct {
@@ -450,7 +609,7 @@ func (s *Server) Join(u *url.URL, joinURL *url.URL) error {
 	// Send request.
 	joinURL = copyURL(joinURL)
 	joinURL.Path = "/data_nodes"
-	resp, err := http.Post(joinURL.String(), "application/octet-stream", &buf)
+	resp, err := s.httpClient().Post(joinURL.String(), "application/octet-stream", &buf)
 	if err != nil {
 		return err
 	}
@@ -470,7 +629,7 @@ func (s *Server) Join(u *url.URL, joinURL *url.URL) error {
 
 	// Download the metastore from joining server.
 	joinURL.Path = "/metastore"
-	resp, err = http.Get(joinURL.String())
+	resp, err = s.httpClient().Get(joinURL.String())
 	if err != nil {
 		return err
 	}
@@ -697,6 +856,7 @@ func (s *Server) applyDropDatabase(m *messaging.Message) (err error) {
 
 	// Delete the database entry.
 	delete(s.databases, c.Name)
+	s.RateLimiter.DropDatabase(c.Name)
 	return
 }
 
@@ -917,6 +1077,57 @@ func (s *Server) applyDeleteShardGroup(m *messaging.Message) (err error) {
 	return
 }
 
+// DeleteShard removes a single shard and its shard group. Shards can only be
+// removed from groups containing a single shard since series are assigned to
+// shards by the number of shards in the group.
+func (s *Server) DeleteShard(id uint64) error {
+	c := &deleteShardCommand{ID: id}
+	_, err := s.broadcast(deleteShardMessageType, c)
+	return err
+}
+
+// applyDeleteShard deletes a shard's data from disk and removes it from the metastore.
+func (s *Server) applyDeleteShard(m *messaging.Message) (err error) {
+	var c deleteShardCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Find the shard and the group that owns it.
+	for _, db := range s.databases {
+		for _, rp := range db.policies {
+			for _, g := range rp.shardGroups {
+				for _, sh := range g.Shards {
+					if sh.ID != c.ID {
+						continue
+					} else if len(g.Shards) > 1 {
+						return ErrShardGroupHasMultipleShards
+					}
+
+					// Remove the shard's data if it is stored on this server.
+					if sh.store != nil {
+						path := sh.store.Path()
+						_ = sh.close()
+						if err := os.Remove(path); err != nil {
+							log.Printf("error deleting shard %s, group ID %d, policy %s: %s", path, g.ID, rp.Name, err.Error())
+						}
+					}
+
+					// Remove the shard and its group.
+					delete(s.shards, sh.ID)
+					rp.removeShardGroupByID(g.ID)
+
+					// Persist to metastore.
+					err = s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+						return tx.saveDatabase(db)
+					})
+					return
+				}
+			}
+		}
+	}
+
+	return ErrShardNotFound
+}
+
 // User returns a user by username
 // Returns nil if the user does not exist.
 func (s *Server) User(name string) *User {
@@ -945,6 +1156,8 @@ func (s *Server) UserCount() int {
 
 // AdminUserExists returns whether at least 1 admin-level user exists.
 func (s *Server) AdminUserExists() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	for _, u := range s.users {
 		if u.Admin {
 			return true
@@ -1073,12 +1286,22 @@ func (s *Server) applyDeleteUser(m *messaging.Message) error {
 
 	// Delete the user.
 	delete(s.users, c.Username)
+	s.RateLimiter.DropUser(c.Username)
 	return nil
 }
 
 // SetPrivilege grants / revokes a privilege to a user.
 func (s *Server) SetPrivilege(p influxql.Privilege, username string, dbname string) error {
-	c := &setPrivilegeCommand{p, username, dbname}
+	c := &setPrivilegeCommand{Privilege: p, Username: username, Database: dbname}
+	_, err := s.broadcast(setPrivilegeMessageType, c)
+	return err
+}
+
+// SetMeasurementPrivilege grants a user a privilege on the measurements of a
+// database matching m. An empty policy applies to every retention policy.
+// Setting NoPrivileges removes the grant.
+func (s *Server) SetMeasurementPrivilege(p influxql.Privilege, username, dbname, policy string, m Matcher) error {
+	c := &setPrivilegeCommand{Privilege: p, Username: username, Database: dbname, RetentionPolicy: policy, Measurement: &m}
 	_, err := s.broadcast(setPrivilegeMessageType, c)
 	return err
 }
@@ -1097,8 +1320,14 @@ func (s *Server) applySetPrivilege(m *messaging.Message) error {
 		return ErrUserNotFound
 	}
 
+	// If a measurement is set, update the user's measurement-level privilege.
 	// If dbname is empty, update user's Admin flag.
-	if c.Database == "" && (c.Privilege == influxql.AllPrivileges || c.Privilege == influxql.NoPrivileges) {
+	if c.Measurement != nil {
+		if c.Database == "" {
+			return ErrInvalidGrantRevoke
+		}
+		u.MeasurementPrivileges = setMeasurementPrivilege(u.MeasurementPrivileges, c.Database, c.RetentionPolicy, *c.Measurement, c.Privilege)
+	} else if c.Database == "" && (c.Privilege == influxql.AllPrivileges || c.Privilege == influxql.NoPrivileges) {
 		u.Admin = (c.Privilege == influxql.AllPrivileges)
 	} else if c.Database != "" {
 		// Update user's privilege for the database.
@@ -1113,6 +1342,193 @@ func (s *Server) applySetPrivilege(m *messaging.Message) error {
 	})
 }
 
+// Role returns a role by name.
+// Returns nil if the role does not exist.
+func (s *Server) Role(name string) *Role {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.roles[name]
+}
+
+// Roles returns a list of all roles, sorted by name.
+func (s *Server) Roles() (a []*Role) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.roles {
+		a = append(a, r)
+	}
+	sort.Sort(roles(a))
+	return a
+}
+
+// CreateRole creates a role on the server.
+func (s *Server) CreateRole(name string) error {
+	c := &createRoleCommand{Name: name}
+	_, err := s.broadcast(createRoleMessageType, c)
+	return err
+}
+
+func (s *Server) applyCreateRole(m *messaging.Message) error {
+	var c createRoleCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Validate role.
+	if c.Name == "" {
+		return ErrRoleNameRequired
+	} else if s.roles[c.Name] != nil {
+		return ErrRoleExists
+	}
+
+	// Create the role.
+	r := &Role{
+		Name:       c.Name,
+		Privileges: make(map[string]influxql.Privilege),
+	}
+
+	// Persist to metastore.
+	err := s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+		return tx.saveRole(r)
+	})
+
+	s.roles[r.Name] = r
+	return err
+}
+
+// DeleteRole removes a role from the server and from all of its users.
+func (s *Server) DeleteRole(name string) error {
+	c := &deleteRoleCommand{Name: name}
+	_, err := s.broadcast(deleteRoleMessageType, c)
+	return err
+}
+
+func (s *Server) applyDeleteRole(m *messaging.Message) error {
+	var c deleteRoleCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Validate role.
+	if c.Name == "" {
+		return ErrRoleNameRequired
+	} else if s.roles[c.Name] == nil {
+		return ErrRoleNotFound
+	}
+
+	// Remove the role from its users.
+	var updated []*User
+	for _, u := range s.users {
+		if u.removeRole(c.Name) {
+			updated = append(updated, u)
+		}
+	}
+	delete(s.roles, c.Name)
+	for _, u := range updated {
+		s.resolveRoles(u)
+	}
+
+	// Persist to metastore.
+	return s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+		for _, u := range updated {
+			if err := tx.saveUser(u); err != nil {
+				return err
+			}
+		}
+		return tx.deleteRole(c.Name)
+	})
+}
+
+// SetRolePrivilege grants / revokes a privilege on a database to a role.
+func (s *Server) SetRolePrivilege(p influxql.Privilege, role string, dbname string) error {
+	c := &setRolePrivilegeCommand{Privilege: p, Role: role, Database: dbname}
+	_, err := s.broadcast(setRolePrivilegeMessageType, c)
+	return err
+}
+
+// SetRoleMeasurementPrivilege grants a role a privilege on the measurements
+// of a database matching m. Setting NoPrivileges removes the grant.
+func (s *Server) SetRoleMeasurementPrivilege(p influxql.Privilege, role, dbname, policy string, m Matcher) error {
+	c := &setRolePrivilegeCommand{Privilege: p, Role: role, Database: dbname, RetentionPolicy: policy, Measurement: &m}
+	_, err := s.broadcast(setRolePrivilegeMessageType, c)
+	return err
+}
+
+func (s *Server) applySetRolePrivilege(m *messaging.Message) error {
+	var c setRolePrivilegeCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Validate role.
+	if c.Role == "" {
+		return ErrRoleNameRequired
+	}
+
+	r := s.roles[c.Role]
+	if r == nil {
+		return ErrRoleNotFound
+	}
+
+	// Roles cannot grant cluster admin so a database is always required.
+	if c.Database == "" {
+		return ErrInvalidGrantRevoke
+	} else if c.Measurement != nil {
+		r.MeasurementPrivileges = setMeasurementPrivilege(r.MeasurementPrivileges, c.Database, c.RetentionPolicy, *c.Measurement, c.Privilege)
+	} else {
+		r.Privileges[c.Database] = c.Privilege
+	}
+
+	// Persist to metastore.
+	return s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+		return tx.saveRole(r)
+	})
+}
+
+// GrantRole adds a user to a role.
+func (s *Server) GrantRole(role, username string) error {
+	c := &setUserRoleCommand{Username: username, Role: role, Granted: true}
+	_, err := s.broadcast(setUserRoleMessageType, c)
+	return err
+}
+
+// RevokeRole removes a user from a role.
+func (s *Server) RevokeRole(role, username string) error {
+	c := &setUserRoleCommand{Username: username, Role: role, Granted: false}
+	_, err := s.broadcast(setUserRoleMessageType, c)
+	return err
+}
+
+func (s *Server) applySetUserRole(m *messaging.Message) error {
+	var c setUserRoleCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Validate user and role.
+	u := s.users[c.Username]
+	if u == nil {
+		return ErrUserNotFound
+	} else if s.roles[c.Role] == nil {
+		return ErrRoleNotFound
+	}
+
+	// Update the user's roles.
+	if c.Granted {
+		u.addRole(c.Role)
+	} else {
+		u.removeRole(c.Role)
+	}
+	s.resolveRoles(u)
+
+	// Persist to metastore.
+	return s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+		return tx.saveUser(u)
+	})
+}
+
+// resolveRoles attaches the roles named by a user so they are used for authorization.
+func (s *Server) resolveRoles(u *User) {
+	u.roles = nil
+	for _, name := range u.Roles {
+		if r := s.roles[name]; r != nil {
+			u.roles = append(u.roles, r)
+		}
+	}
+}
+
 // RetentionPolicy returns a retention policy by name.
 // Returns an error if the database doesn't exist.
 func (s *Server) RetentionPolicy(database, name string) (*RetentionPolicy, error) {
@@ -1170,6 +1586,7 @@ func (s *Server) CreateRetentionPolicy(database string, rp *RetentionPolicy) err
 		Name:     rp.Name,
 		Duration: rp.Duration,
 		ReplicaN: rp.ReplicaN,
+		MaxSize:  rp.MaxSize,
 	}
 	_, err := s.broadcast(createRetentionPolicyMessageType, c)
 	return err
@@ -1194,6 +1611,7 @@ func (s *Server) applyCreateRetentionPolicy(m *messaging.Message) error {
 		Name:     c.Name,
 		Duration: c.Duration,
 		ReplicaN: c.ReplicaN,
+		MaxSize:  c.MaxSize,
 	}
 
 	// Persist to metastore.
@@ -1210,6 +1628,7 @@ type RetentionPolicyUpdate struct {
 	Name     *string        `json:"name,omitempty"`
 	Duration *time.Duration `json:"duration,omitempty"`
 	ReplicaN *uint32        `json:"replicaN,omitempty"`
+	MaxSize  *int64         `json:"maxSize,omitempty"`
 }
 
 // UpdateRetentionPolicy updates an existing retention policy on a database.
@@ -1254,6 +1673,11 @@ func (s *Server) applyUpdateRetentionPolicy(m *messaging.Message) (err error) {
 		p.ReplicaN = *c.Policy.ReplicaN
 	}
 
+	// Update maximum size.
+	if c.Policy.MaxSize != nil {
+		p.MaxSize = *c.Policy.MaxSize
+	}
+
 	// Persist to metastore.
 	err = s.meta.mustUpdate(m.Index, func(tx *metatx) error {
 		return tx.saveDatabase(db)
@@ -1286,6 +1710,20 @@ func (s *Server) applyDeleteRetentionPolicy(m *messaging.Message) (err error) {
 	// Remove retention policy.
 	delete(db.policies, c.Name)
 
+	// Remove any downsamples to or from the retention policy.
+	if len(db.downsamples) > 0 {
+		downsamples := db.downsamples[:0]
+		for _, ds := range db.downsamples {
+			if ds.SourceRetentionPolicy != c.Name && ds.TargetRetentionPolicy != c.Name {
+				downsamples = append(downsamples, ds)
+			}
+		}
+		db.downsamples = downsamples
+		if err := s.syncDownsampleQueries(db); err != nil {
+			return err
+		}
+	}
+
 	// Persist to metastore.
 	err = s.meta.mustUpdate(m.Index, func(tx *metatx) error {
 		return tx.saveDatabase(db)
@@ -1525,7 +1963,7 @@ func (s *Server) createMeasurementsIfNotExists(database, retentionPolicy string,
 	c := newCreateMeasurementsIfNotExistsCommand(database)
 
 	// Local function keeps lock management foolproof.
-	func() error {
+	if err := func() error {
 		s.mu.RLock()
 		defer s.mu.RUnlock()
 
@@ -1559,8 +1997,11 @@ func (s *Server) createMeasurementsIfNotExists(database, retentionPolicy string,
 			}
 		}
 
-		return nil
-	}()
+		// Reject the write if the new series would exceed the database's limits.
+		return s.checkSeriesLimits(db, c)
+	}(); err != nil {
+		return err
+	}
 
 	// Any broadcast actually required?
 	if len(c.Measurements) > 0 {
@@ -1573,6 +2014,85 @@ func (s *Server) createMeasurementsIfNotExists(database, retentionPolicy string,
 	return nil
 }
 
+// SeriesLimits restricts the number of series that can be created in a database.
+// A limit of zero means unlimited.
+type SeriesLimits struct {
+	MaxSeries       int // maximum number of series in the database
+	MaxValuesPerTag int // maximum number of values for a tag key of a measurement
+}
+
+// maxDatabaseSize returns the maximum number of bytes of shard data kept for a database.
+func (s *Server) maxDatabaseSize(database string) int64 {
+	if n, ok := s.DatabaseMaxSizes[database]; ok {
+		return n
+	}
+	return s.MaxDatabaseSize
+}
+
+// seriesLimits returns the series limits for a database.
+func (s *Server) seriesLimits(database string) SeriesLimits {
+	if l, ok := s.DatabaseSeriesLimits[database]; ok {
+		return l
+	}
+	return s.SeriesLimits
+}
+
+// checkSeriesLimits returns an error if creating the series in the command would
+// exceed the series limits of the database.
+func (s *Server) checkSeriesLimits(db *database, c *createMeasurementsIfNotExistsCommand) error {
+	limits := s.seriesLimits(db.name)
+
+	// Check the total number of series in the database.
+	if limits.MaxSeries > 0 {
+		n := len(db.series)
+		for _, cm := range c.Measurements {
+			for _, tags := range cm.Tags {
+				if _, ss := db.MeasurementAndSeries(cm.Name, tags); ss == nil {
+					n++
+				}
+			}
+		}
+		if n > limits.MaxSeries {
+			return ErrSeriesLimitExceeded{Database: db.name, Limit: limits.MaxSeries}
+		}
+	}
+
+	// Check the number of values for each tag key of each measurement.
+	if limits.MaxValuesPerTag > 0 {
+		for _, cm := range c.Measurements {
+			mm := db.measurements[cm.Name]
+
+			// Collect the tag values that don't exist yet.
+			values := make(map[string]map[string]struct{})
+			for _, tags := range cm.Tags {
+				for k, v := range tags {
+					if mm != nil {
+						if _, ok := mm.seriesByTagKeyValue[k][v]; ok {
+							continue
+						}
+					}
+					if values[k] == nil {
+						values[k] = make(map[string]struct{})
+					}
+					values[k][v] = struct{}{}
+				}
+			}
+
+			for k, v := range values {
+				n := len(v)
+				if mm != nil {
+					n += len(mm.seriesByTagKeyValue[k])
+				}
+				if n > limits.MaxValuesPerTag {
+					return ErrSeriesLimitExceeded{Database: db.name, Measurement: cm.Name, TagKey: k, Limit: limits.MaxValuesPerTag}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 // applyCreateMeasurementsIfNotExists creates the Measurements, Series, and Fields in the Metastore.
 func (s *Server) applyCreateMeasurementsIfNotExists(m *messaging.Message) error {
 	var c createMeasurementsIfNotExistsCommand
@@ -1628,6 +2148,16 @@ func (s *Server) applyCreateMeasurementsIfNotExists(m *messaging.Message) error
 			}
 		}
 
+		// Downsample any new measurements and fields.
+		if len(db.downsamples) > 0 {
+			if err := s.syncDownsampleQueries(db); err != nil {
+				return err
+			}
+			if err := tx.saveDatabase(db); err != nil {
+				return fmt.Errorf("save database: %s", err)
+			}
+		}
+
 		return nil
 	}); err != nil {
 		return err
@@ -1666,6 +2196,14 @@ func (s *Server) applyDropMeasurement(m *messaging.Message) error {
 		if err := database.dropMeasurement(c.Name); err != nil {
 			return err
 		}
+
+		// Remove the measurement's downsample queries.
+		if len(database.downsamples) > 0 {
+			if err := s.syncDownsampleQueries(database); err != nil {
+				return err
+			}
+			return tx.saveDatabase(database)
+		}
 		return nil
 	})
 	if err != nil {
@@ -1799,12 +2337,30 @@ func (s *Server) ExecuteQuery(q *influxql.Query, database string, user *User) Re
 			res = s.executeCreateUserStatement(stmt, user)
 		case *influxql.DropUserStatement:
 			res = s.executeDropUserStatement(stmt, user)
+		case *influxql.SetPasswordUserStatement:
+			res = s.executeSetPasswordUserStatement(stmt, user)
+		case *influxql.AlterUserStatement:
+			res = s.executeAlterUserStatement(stmt, user)
 		case *influxql.ShowUsersStatement:
 			res = s.executeShowUsersStatement(stmt, user)
+		case *influxql.ShowGrantsForUserStatement:
+			res = s.executeShowGrantsForUserStatement(stmt, user)
+		case *influxql.CreateRoleStatement:
+			res = s.executeCreateRoleStatement(stmt, user)
+		case *influxql.DropRoleStatement:
+			res = s.executeDropRoleStatement(stmt, user)
+		case *influxql.GrantRoleStatement:
+			res = s.executeGrantRoleStatement(stmt, user)
+		case *influxql.RevokeRoleStatement:
+			res = s.executeRevokeRoleStatement(stmt, user)
+		case *influxql.ShowRolesStatement:
+			res = s.executeShowRolesStatement(stmt, user)
 		case *influxql.DropSeriesStatement:
 			res = s.executeDropSeriesStatement(stmt, database, user)
 		case *influxql.ShowSeriesStatement:
 			res = s.executeShowSeriesStatement(stmt, database, user)
+		case *influxql.ShowSeriesCardinalityStatement:
+			res = s.executeShowSeriesCardinalityStatement(stmt, database, user)
 		case *influxql.DropMeasurementStatement:
 			res = s.executeDropMeasurementStatement(stmt, database, user)
 		case *influxql.ShowMeasurementsStatement:
@@ -1813,6 +2369,8 @@ func (s *Server) ExecuteQuery(q *influxql.Query, database string, user *User) Re
 			res = s.executeShowTagKeysStatement(stmt, database, user)
 		case *influxql.ShowTagValuesStatement:
 			res = s.executeShowTagValuesStatement(stmt, database, user)
+		case *influxql.ShowTagValuesCardinalityStatement:
+			res = s.executeShowTagValuesCardinalityStatement(stmt, database, user)
 		case *influxql.ShowFieldKeysStatement:
 			res = s.executeShowFieldKeysStatement(stmt, database, user)
 		case *influxql.GrantStatement:
@@ -1833,6 +2391,26 @@ func (s *Server) ExecuteQuery(q *influxql.Query, database string, user *User) Re
 			continue
 		case *influxql.ShowContinuousQueriesStatement:
 			res = s.executeShowContinuousQueriesStatement(stmt, database, user)
+		case *influxql.ShowContinuousQueryStatsStatement:
+			res = s.executeShowContinuousQueryStatsStatement(stmt, user)
+		case *influxql.BackfillContinuousQueryStatement:
+			res = s.executeBackfillContinuousQueryStatement(stmt, user)
+		case *influxql.CreateDownsampleStatement:
+			res = s.executeCreateDownsampleStatement(stmt, user)
+		case *influxql.DropDownsampleStatement:
+			res = s.executeDropDownsampleStatement(stmt, user)
+		case *influxql.ShowShardsStatement:
+			res = s.executeShowShardsStatement(stmt, user)
+		case *influxql.ShowShardGroupsStatement:
+			res = s.executeShowShardGroupsStatement(stmt, user)
+		case *influxql.DropShardStatement:
+			res = s.executeDropShardStatement(stmt, user)
+		case *influxql.ShowDataNodesStatement:
+			res = s.executeShowDataNodesStatement(stmt, user)
+		case *influxql.DropDataNodeStatement:
+			res = s.executeDropDataNodeStatement(stmt, user)
+		case *influxql.ShowBrokersStatement:
+			res = s.executeShowBrokersStatement(stmt, user)
 		default:
 			panic(fmt.Sprintf("unsupported statement type: %T", stmt))
 		}
@@ -1862,6 +2440,14 @@ func (s *Server) executeSelectStatement(stmt *influxql.SelectStatement, database
 		return &Result{Err: err}
 	}
 
+	// Determine where to write the results if an INTO clause was specified.
+	var db, rp, name string
+	if stmt.Target != nil {
+		if db, rp, name, err = s.resolveTarget(stmt.Target, database); err != nil {
+			return &Result{Err: err}
+		}
+	}
+
 	// Plan statement execution.
 	e, err := s.planSelectStatement(stmt)
 	if err != nil {
@@ -1874,6 +2460,11 @@ func (s *Server) executeSelectStatement(stmt *influxql.SelectStatement, database
 		return &Result{Err: err}
 	}
 
+	// Write the rows back into the target if an INTO clause was specified.
+	if stmt.Target != nil {
+		return s.writeSelectIntoResult(db, rp, name, ch)
+	}
+
 	// Read all rows from channel.
 	res := &Result{Series: make([]*influxql.Row, 0)}
 	for row := range ch {
@@ -1883,16 +2474,87 @@ func (s *Server) executeSelectStatement(stmt *influxql.SelectStatement, database
 	return res
 }
 
-// rewriteSelectStatement performs any necessary query re-writing.
-func (s *Server) rewriteSelectStatement(stmt *influxql.SelectStatement) (*influxql.SelectStatement, error) {
-	if !stmt.HasWildcard() {
-		return stmt, nil
+// resolveTarget returns the database, retention policy and measurement of an INTO target.
+// An empty retention policy is resolved to the database default on write.
+func (s *Server) resolveTarget(target *influxql.Target, database string) (db, rp, name string, err error) {
+	a, err := influxql.SplitIdent(target.Measurement)
+	if err != nil {
+		return "", "", "", err
 	}
+	switch len(a) {
+	case 1:
+		name = a[0]
+	case 2:
+		rp, name = a[0], a[1]
+	default:
+		db, rp, name = a[0], a[1], a[2]
+	}
+	if db == "" {
+		db = target.Database
+	}
+	if db == "" {
+		db = database
+	}
+	if !s.DatabaseExists(db) {
+		return "", "", "", ErrDatabaseNotFound
+	}
+	return db, rp, name, nil
+}
 
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	var fields influxql.Fields
+// writeSelectIntoResult writes all rows from a SELECT ... INTO query into the target
+// and returns the number of points written.
+func (s *Server) writeSelectIntoResult(db, rp, name string, ch <-chan *influxql.Row) *Result {
+	var n int64
+	var err error
+	for row := range ch {
+		// Continue draining the channel after an error so the executor can finish.
+		if err != nil {
+			continue
+		}
+
+		// Convert the row to points and write them into the target.
+		var points []Point
+		if points, err = s.convertRowToPoints(name, row); err != nil || len(points) == 0 {
+			continue
+		}
+		if _, err = s.WriteSeries(db, rp, points); err != nil {
+			continue
+		}
+		n += int64(len(points))
+	}
+	if err != nil {
+		return &Result{Err: err}
+	}
+
+	return &Result{
+		Series: influxql.Rows{{
+			Name:    "result",
+			Columns: []string{"time", "written"},
+			Values:  [][]interface{}{{time.Unix(0, 0).UTC(), n}},
+		}},
+	}
+}
+
+// rewriteSelectStatement performs any necessary query re-writing.
+func (s *Server) rewriteSelectStatement(stmt *influxql.SelectStatement) (*influxql.SelectStatement, error) {
+	// Rewrite the inner statement of a subquery source.
+	if sq, ok := stmt.Source.(*influxql.SubQuery); ok {
+		other, err := s.rewriteSelectStatement(sq.Statement)
+		if err != nil {
+			return nil, err
+		}
+		stmt = stmt.Clone()
+		stmt.Source = &influxql.SubQuery{Statement: other}
+	}
+
+	if !stmt.HasWildcard() {
+		return stmt, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var fields influxql.Fields
 	var dimensions influxql.Dimensions
 	if measurement, ok := stmt.Source.(*influxql.Measurement); ok {
 		segments, err := influxql.SplitIdent(measurement.Name)
@@ -1901,6 +2563,9 @@ func (s *Server) rewriteSelectStatement(stmt *influxql.SelectStatement) (*influx
 		}
 		db, m := segments[0], segments[2]
 
+		if s.databases[db] == nil {
+			return nil, ErrDatabaseNotFound
+		}
 		mm := s.databases[db].measurements[m]
 		if mm == nil {
 			return nil, fmt.Errorf("measurement not found: %s", measurement.Name)
@@ -1912,6 +2577,16 @@ func (s *Server) rewriteSelectStatement(stmt *influxql.SelectStatement) (*influx
 		for _, t := range mm.tagKeys() {
 			dimensions = append(dimensions, &influxql.Dimension{Expr: &influxql.VarRef{Val: t}})
 		}
+	} else if sq, ok := stmt.Source.(*influxql.SubQuery); ok {
+		// Subqueries expose their result columns as fields and their tag dimensions as tags.
+		for _, f := range sq.Statement.Fields {
+			fields = append(fields, &influxql.Field{Expr: &influxql.VarRef{Val: f.Name()}})
+		}
+		for _, d := range sq.Statement.Dimensions {
+			if ref, ok := d.Expr.(*influxql.VarRef); ok {
+				dimensions = append(dimensions, &influxql.Dimension{Expr: &influxql.VarRef{Val: ref.Val}})
+			}
+		}
 	}
 
 	return stmt.RewriteWildcards(fields, dimensions), nil
@@ -1956,6 +2631,21 @@ func (s *Server) executeDropUserStatement(q *influxql.DropUserStatement, user *U
 	return &Result{Err: s.DeleteUser(q.Name)}
 }
 
+func (s *Server) executeSetPasswordUserStatement(q *influxql.SetPasswordUserStatement, user *User) *Result {
+	if q.Password == "" {
+		return &Result{Err: ErrPasswordRequired}
+	}
+	return &Result{Err: s.UpdateUser(q.Name, q.Password)}
+}
+
+func (s *Server) executeAlterUserStatement(q *influxql.AlterUserStatement, user *User) *Result {
+	p := influxql.NoPrivileges
+	if q.Admin {
+		p = influxql.AllPrivileges
+	}
+	return &Result{Err: s.SetPrivilege(p, q.Name, "")}
+}
+
 func (s *Server) executeDropMeasurementStatement(stmt *influxql.DropMeasurementStatement, database string, user *User) *Result {
 	return &Result{Err: s.DropMeasurement(database, stmt.Name)}
 }
@@ -2229,6 +2919,83 @@ func (s *Server) executeShowTagKeysStatement(stmt *influxql.ShowTagKeysStatement
 	return result
 }
 
+func (s *Server) executeShowSeriesCardinalityStatement(stmt *influxql.ShowSeriesCardinalityStatement, database string, user *User) *Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Find the database.
+	db := s.databases[database]
+	if db == nil {
+		return &Result{Err: ErrDatabaseNotFound}
+	}
+
+	// Count the series of the requested measurements, or the whole database.
+	n := len(db.series)
+	if stmt.Source != nil {
+		measurements, err := measurementsFromSourceOrDB(stmt.Source, db)
+		if err != nil {
+			return &Result{Err: err}
+		}
+
+		n = 0
+		for _, m := range measurements {
+			n += len(m.seriesIDs)
+		}
+	}
+
+	return &Result{
+		Series: []*influxql.Row{{
+			Columns: []string{"count"},
+			Values:  [][]interface{}{{n}},
+		}},
+	}
+}
+
+func (s *Server) executeShowTagValuesCardinalityStatement(stmt *influxql.ShowTagValuesCardinalityStatement, database string, user *User) *Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Find the database.
+	db := s.databases[database]
+	if db == nil {
+		return &Result{Err: ErrDatabaseNotFound}
+	}
+
+	// Get the list of measurements we're interested in.
+	measurements, err := measurementsFromSourceOrDB(stmt.Source, db)
+	if err != nil {
+		return &Result{Err: err}
+	}
+
+	// Make result.
+	result := &Result{
+		Series: make(influxql.Rows, 0, len(measurements)),
+	}
+
+	// Add one row per measurement with the number of values for each tag key.
+	for _, m := range measurements {
+		keys := stmt.TagKeys
+		if len(keys) == 0 {
+			keys = m.tagKeys()
+		}
+
+		r := &influxql.Row{
+			Name:    m.Name,
+			Columns: []string{"key", "count"},
+		}
+		for _, k := range keys {
+			if n := len(m.seriesByTagKeyValue[k]); n > 0 {
+				r.Values = append(r.Values, []interface{}{k, n})
+			}
+		}
+		if len(r.Values) > 0 {
+			result.Series = append(result.Series, r)
+		}
+	}
+
+	return result
+}
+
 func (s *Server) executeShowTagValuesStatement(stmt *influxql.ShowTagValuesStatement, database string, user *User) *Result {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -2312,6 +3079,125 @@ func (s *Server) executeShowContinuousQueriesStatement(stmt *influxql.ShowContin
 	return &Result{Series: rows}
 }
 
+func (s *Server) executeShowContinuousQueryStatsStatement(stmt *influxql.ShowContinuousQueryStatsStatement, user *User) *Result {
+	rows := []*influxql.Row{}
+	for _, name := range s.Databases() {
+		// Only report queries in databases the user can read.
+		if user != nil && !user.Authorize(influxql.ReadPrivilege, name) {
+			continue
+		}
+
+		row := &influxql.Row{Columns: []string{"name", "runs", "failures", "last_error", "last_duration", "points_written", "last_interval"}, Name: name}
+		for _, cq := range s.ContinuousQueries(name) {
+			stats := cq.Stats()
+
+			// Only report the last interval once one has been computed.
+			var lastInterval interface{}
+			if !stats.LastInterval.IsZero() {
+				lastInterval = stats.LastInterval
+			}
+
+			row.Values = append(row.Values, []interface{}{stats.Name, stats.Runs, stats.Failures, stats.LastError, stats.LastDuration.String(), stats.PointsWritten, lastInterval})
+		}
+		rows = append(rows, row)
+	}
+	return &Result{Series: rows}
+}
+
+func (s *Server) executeBackfillContinuousQueryStatement(q *influxql.BackfillContinuousQueryStatement, user *User) *Result {
+	// Retrieve the continuous query and the progress of a previous backfill.
+	s.mu.RLock()
+	var cq *ContinuousQuery
+	var progress ContinuousQueryBackfill
+	db := s.databases[q.Database]
+	if db != nil {
+		if cq = db.continuousQueryByName(q.Name); cq != nil && cq.Backfill != nil {
+			progress = *cq.Backfill
+		}
+	}
+	s.mu.RUnlock()
+
+	if db == nil {
+		return &Result{Err: ErrDatabaseNotFound}
+	} else if cq == nil {
+		return &Result{Err: ErrContinuousQueryNotFound}
+	} else if !q.StartTime.Before(q.EndTime) {
+		return &Result{Err: ErrInvalidBackfillTimeRange}
+	}
+
+	if interval, err := cq.cq.Source.GroupByInterval(); err != nil {
+		return &Result{Err: err}
+	} else if interval == 0 {
+		return &Result{Err: ErrContinuousQueryIntervalRequired}
+	}
+
+	// Align the start to the CQ's intervals and resume a previous backfill over the same time range.
+	start, err := cq.cq.Source.GroupByIntervalStart(q.StartTime)
+	if err != nil {
+		return &Result{Err: err}
+	}
+	from := start
+	if progress.StartTime.Equal(q.StartTime) && progress.EndTime.Equal(q.EndTime) {
+		from = progress.Completed
+	}
+	progress = ContinuousQueryBackfill{StartTime: q.StartTime, EndTime: q.EndTime, Completed: from}
+
+	// Compute each interval on a copy of the statement so the running CQ is unaffected.
+	stmt := cq.cq.Source.Clone()
+	var n, intervals int64
+	saved := time.Now()
+	for t := from; t.Before(q.EndTime); {
+		next, err := stmt.NextGroupByInterval(t)
+		if err != nil {
+			return &Result{Err: err}
+		} else if err := stmt.SetTimeRange(t, next); err != nil {
+			return &Result{Err: err}
+		}
+
+		// Hold the CQ's lock so the interval isn't computed at the same time
+		// as a scheduled run. The CQ's statistics are left to scheduled runs.
+		cq.mu.Lock()
+		written, err := s.executeContinuousQuery(cq, stmt)
+		cq.mu.Unlock()
+		if err != nil {
+			// Save where we stopped so the backfill can be resumed.
+			if e := s.SetContinuousQueryBackfill(q.Database, q.Name, &progress); e != nil {
+				s.Logger.Printf("backfill of %s.%s could not save progress: %s", q.Database, q.Name, e)
+			}
+			return &Result{Err: fmt.Errorf("backfill stopped at %s: %s", t.Format(time.RFC3339Nano), err)}
+		}
+		n += written
+		intervals++
+		progress.Completed = next
+		t = next
+
+		// Periodically save progress.
+		if time.Since(saved) > time.Second {
+			if err := s.SetContinuousQueryBackfill(q.Database, q.Name, &progress); err != nil {
+				return &Result{Err: err}
+			}
+			s.Logger.Printf("backfill of %s.%s computed up to %s", q.Database, q.Name, progress.Completed.Format(time.RFC3339Nano))
+			saved = time.Now()
+		}
+
+		// Limit the rate at which intervals are computed.
+		time.Sleep(s.BackfillDelay)
+	}
+
+	// The backfill is finished so clear its progress.
+	if err := s.SetContinuousQueryBackfill(q.Database, q.Name, nil); err != nil {
+		return &Result{Err: err}
+	}
+
+	return &Result{
+		Series: influxql.Rows{{
+			Name:    "backfill",
+			Columns: []string{"start", "end", "resumed_from", "intervals", "points_written"},
+			Values:  [][]interface{}{{start, q.EndTime, from, intervals, n}},
+		}},
+	}
+}
+
 // filterMeasurementsByExpr filters a list of measurements by a tags expression.
 func filterMeasurementsByExpr(measurements Measurements, expr influxql.Expr) (Measurements, error) {
 	// Create a list to hold result measurements.
@@ -2383,13 +3269,125 @@ func (s *Server) executeShowFieldKeysStatement(stmt *influxql.ShowFieldKeysState
 }
 
 func (s *Server) executeGrantStatement(stmt *influxql.GrantStatement, user *User) *Result {
+	if stmt.Role != "" {
+		if m, ok := privilegeMatcher(stmt.Measurement, stmt.MeasurementRegex); ok {
+			return &Result{Err: s.SetRoleMeasurementPrivilege(stmt.Privilege, stmt.Role, stmt.On, stmt.RetentionPolicy, m)}
+		}
+		return &Result{Err: s.SetRolePrivilege(stmt.Privilege, stmt.Role, stmt.On)}
+	}
+	if m, ok := privilegeMatcher(stmt.Measurement, stmt.MeasurementRegex); ok {
+		return &Result{Err: s.SetMeasurementPrivilege(stmt.Privilege, stmt.User, stmt.On, stmt.RetentionPolicy, m)}
+	}
 	return &Result{Err: s.SetPrivilege(stmt.Privilege, stmt.User, stmt.On)}
 }
 
 func (s *Server) executeRevokeStatement(stmt *influxql.RevokeStatement, user *User) *Result {
+	if stmt.Role != "" {
+		if m, ok := privilegeMatcher(stmt.Measurement, stmt.MeasurementRegex); ok {
+			return &Result{Err: s.SetRoleMeasurementPrivilege(influxql.NoPrivileges, stmt.Role, stmt.On, stmt.RetentionPolicy, m)}
+		}
+		return &Result{Err: s.SetRolePrivilege(influxql.NoPrivileges, stmt.Role, stmt.On)}
+	}
+	if m, ok := privilegeMatcher(stmt.Measurement, stmt.MeasurementRegex); ok {
+		return &Result{Err: s.SetMeasurementPrivilege(influxql.NoPrivileges, stmt.User, stmt.On, stmt.RetentionPolicy, m)}
+	}
 	return &Result{Err: s.SetPrivilege(influxql.NoPrivileges, stmt.User, stmt.On)}
 }
 
+func (s *Server) executeCreateRoleStatement(stmt *influxql.CreateRoleStatement, user *User) *Result {
+	return &Result{Err: s.CreateRole(stmt.Name)}
+}
+
+func (s *Server) executeDropRoleStatement(stmt *influxql.DropRoleStatement, user *User) *Result {
+	return &Result{Err: s.DeleteRole(stmt.Name)}
+}
+
+func (s *Server) executeGrantRoleStatement(stmt *influxql.GrantRoleStatement, user *User) *Result {
+	return &Result{Err: s.GrantRole(stmt.Role, stmt.User)}
+}
+
+func (s *Server) executeRevokeRoleStatement(stmt *influxql.RevokeRoleStatement, user *User) *Result {
+	return &Result{Err: s.RevokeRole(stmt.Role, stmt.User)}
+}
+
+func (s *Server) executeShowRolesStatement(stmt *influxql.ShowRolesStatement, user *User) *Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Find the members of each role.
+	members := make(map[string][]string)
+	for _, u := range s.users {
+		for _, name := range u.Roles {
+			members[name] = append(members[name], u.Name)
+		}
+	}
+
+	var a []*Role
+	for _, r := range s.roles {
+		a = append(a, r)
+	}
+	sort.Sort(roles(a))
+
+	row := &influxql.Row{Columns: []string{"role", "users"}}
+	for _, r := range a {
+		users := members[r.Name]
+		sort.Strings(users)
+		row.Values = append(row.Values, []interface{}{r.Name, strings.Join(users, ",")})
+	}
+	return &Result{Series: []*influxql.Row{row}}
+}
+
+// privilegeMatcher returns a matcher for the measurement of a GRANT or REVOKE
+// statement. Returns false if the statement applies to the whole database.
+func privilegeMatcher(name string, regex *influxql.RegexLiteral) (Matcher, bool) {
+	if regex != nil {
+		return Matcher{IsRegex: true, Name: regex.Val.String()}, true
+	} else if name != "" {
+		return Matcher{Name: name}, true
+	}
+	return Matcher{}, false
+}
+
+func (s *Server) executeShowGrantsForUserStatement(q *influxql.ShowGrantsForUserStatement, user *User) *Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u := s.users[q.Name]
+	if u == nil {
+		return &Result{Err: ErrUserNotFound}
+	}
+
+	// List the user's own privileges followed by those granted through roles.
+	row := &influxql.Row{Columns: []string{"database", "retentionPolicy", "measurement", "privilege", "role"}}
+	row.Values = appendGrantValues(row.Values, u.Privileges, u.MeasurementPrivileges, "")
+	for _, r := range u.roles {
+		row.Values = appendGrantValues(row.Values, r.Privileges, r.MeasurementPrivileges, r.Name)
+	}
+
+	return &Result{Series: []*influxql.Row{row}}
+}
+
+// appendGrantValues appends a row value for each privilege. Database-wide
+// privileges are sorted by database and are followed by measurement-level
+// privileges in the order they were granted.
+func appendGrantValues(values [][]interface{}, privileges map[string]influxql.Privilege, measurementPrivileges []*MeasurementPrivilege, role string) [][]interface{} {
+	var names []string
+	for name, p := range privileges {
+		if p != influxql.NoPrivileges {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values = append(values, []interface{}{name, "", "", privileges[name].String(), role})
+	}
+
+	for _, p := range measurementPrivileges {
+		values = append(values, []interface{}{p.Database, p.RetentionPolicy, p.Measurement.String(), p.Privilege.String(), role})
+	}
+	return values
+}
+
 // measurementsFromSourceOrDB returns a list of measurements from the
 // statement passed in or, if the statement is nil, a list of all
 // measurement names from the database passed in.
@@ -2441,6 +3439,7 @@ func (s *Server) executeCreateRetentionPolicyStatement(q *influxql.CreateRetenti
 	rp := NewRetentionPolicy(q.Name)
 	rp.Duration = q.Duration
 	rp.ReplicaN = uint32(q.Replication)
+	rp.MaxSize = q.MaxSize
 
 	// Create new retention policy.
 	err := s.CreateRetentionPolicy(q.Database, rp)
@@ -2467,6 +3466,7 @@ func (s *Server) executeAlterRetentionPolicyStatement(stmt *influxql.AlterRetent
 				return &n
 			}
 		}(),
+		MaxSize: stmt.MaxSize,
 	}
 
 	// Update the retention policy.
@@ -2493,66 +3493,303 @@ func (s *Server) executeShowRetentionPoliciesStatement(q *influxql.ShowRetention
 		return &Result{Err: err}
 	}
 
-	row := &influxql.Row{Columns: []string{"name", "duration", "replicaN"}}
+	// Local usage is read from the shard stores so hold the lock while sizing.
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row := &influxql.Row{Columns: []string{"name", "duration", "replicaN", "maxSize", "size"}}
 	for _, rp := range a {
-		row.Values = append(row.Values, []interface{}{rp.Name, rp.Duration.String(), rp.ReplicaN})
+		row.Values = append(row.Values, []interface{}{rp.Name, rp.Duration.String(), rp.ReplicaN, rp.MaxSize, rp.size()})
 	}
 	return &Result{Series: []*influxql.Row{row}}
 }
 
-func (s *Server) executeCreateContinuousQueryStatement(q *influxql.CreateContinuousQueryStatement, user *User) *Result {
-	return &Result{Err: s.CreateContinuousQuery(q)}
-}
-
-// CreateContinuousQuery creates a continuous query.
-func (s *Server) CreateContinuousQuery(q *influxql.CreateContinuousQueryStatement) error {
-	c := &createContinuousQueryCommand{Query: q.String()}
-	_, err := s.broadcast(createContinuousQueryMessageType, c)
-	return err
-}
-
-// ContinuousQueries returns a list of all continuous queries.
-func (s *Server) ContinuousQueries(database string) []*ContinuousQuery {
+func (s *Server) executeShowShardsStatement(q *influxql.ShowShardsStatement, user *User) *Result {
+	// Return one row per database, ordered by policy, group start time and shard id.
+	// The shards are listed under the lock and sized afterwards since reading
+	// the shard stores can take a while.
+	var shards []*Shard
+	rows := []*influxql.Row{}
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	db := s.databases[database]
-	if db == nil {
-		return nil
+	for _, db := range s.sortedDatabases() {
+		row := &influxql.Row{Name: db.name, Columns: []string{"id", "retention_policy", "shard_group", "start_time", "end_time", "data_nodes", "size", "points"}}
+		for _, rp := range db.sortedPolicies() {
+			groups := make([]*ShardGroup, len(rp.shardGroups))
+			copy(groups, rp.shardGroups)
+			sort.Sort(shardGroupsByStartTime(groups))
+
+			for _, g := range groups {
+				for _, sh := range g.Shards {
+					row.Values = append(row.Values, []interface{}{sh.ID, rp.Name, g.ID, g.StartTime, g.EndTime, sh.DataNodeIDs, int64(0), 0})
+					shards = append(shards, sh)
+				}
+			}
+		}
+		rows = append(rows, row)
 	}
+	s.mu.RUnlock()
 
-	return db.continuousQueries
+	// Fill in the size and point count of each shard.
+	i := 0
+	for _, row := range rows {
+		for _, v := range row.Values {
+			v[6], v[7] = shards[i].size(), shards[i].pointN()
+			i++
+		}
+	}
+	return &Result{Series: rows}
 }
 
-// MeasurementNames returns a list of all measurements for the specified database.
-func (s *Server) MeasurementNames(database string) []string {
+func (s *Server) executeShowShardGroupsStatement(q *influxql.ShowShardGroupsStatement, user *User) *Result {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	db := s.databases[database]
-	if db == nil {
-		return nil
+	// Limit to a single database, if specified.
+	dbs := s.sortedDatabases()
+	if q.Database != "" {
+		db := s.databases[q.Database]
+		if db == nil {
+			return &Result{Err: ErrDatabaseNotFound}
+		}
+		dbs = []*database{db}
 	}
 
-	return db.names
+	// Return one row per database, ordered by policy and group start time.
+	rows := []*influxql.Row{}
+	for _, db := range dbs {
+		row := &influxql.Row{Name: db.name, Columns: []string{"id", "retention_policy", "start_time", "end_time", "shards"}}
+		for _, rp := range db.sortedPolicies() {
+			groups := make([]*ShardGroup, len(rp.shardGroups))
+			copy(groups, rp.shardGroups)
+			sort.Sort(shardGroupsByStartTime(groups))
+
+			for _, g := range groups {
+				shardIDs := make([]uint64, 0, len(g.Shards))
+				for _, sh := range g.Shards {
+					shardIDs = append(shardIDs, sh.ID)
+				}
+				row.Values = append(row.Values, []interface{}{g.ID, rp.Name, g.StartTime, g.EndTime, shardIDs})
+			}
+		}
+		rows = append(rows, row)
+	}
+	return &Result{Series: rows}
 }
 
-/*
-func (s *Server) MeasurementSeriesIDs(database, measurement string) []uint32 {
+func (s *Server) executeDropShardStatement(q *influxql.DropShardStatement, user *User) *Result {
+	return &Result{Err: s.DeleteShard(q.ID)}
+}
+
+func (s *Server) executeShowDataNodesStatement(q *influxql.ShowDataNodesStatement, user *User) *Result {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	db := s.databases[database]
-	if db == nil {
-		return nil
+	// Every data node subscribes to the broadcast topic and the topics of the shards it owns.
+	topics := make(map[uint64][]uint64)
+	for id := range s.dataNodes {
+		topics[id] = []uint64{messaging.BroadcastTopicID}
 	}
-
-	return []uint32(db.SeriesIDs([]string{measurement}, nil))
-}
-*/
-
-// measurement returns a measurement by database and name.
-func (s *Server) measurement(database, name string) (*Measurement, error) {
+	for _, db := range s.databases {
+		for _, rp := range db.policies {
+			for _, g := range rp.shardGroups {
+				for _, sh := range g.Shards {
+					for _, id := range sh.DataNodeIDs {
+						if _, ok := topics[id]; ok {
+							topics[id] = append(topics[id], sh.ID)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// The applied index is only known for this server's data node.
+	nodes := make([]*DataNode, 0, len(s.dataNodes))
+	for _, n := range s.dataNodes {
+		nodes = append(nodes, n)
+	}
+	sort.Sort(dataNodes(nodes))
+
+	row := &influxql.Row{Columns: []string{"id", "url", "topics", "index"}}
+	for _, n := range nodes {
+		var index interface{}
+		if n.ID == s.id {
+			index = s.index
+		}
+		sort.Sort(uint64Slice(topics[n.ID]))
+		row.Values = append(row.Values, []interface{}{n.ID, n.URL.String(), topics[n.ID], index})
+	}
+	return &Result{Series: []*influxql.Row{row}}
+}
+
+func (s *Server) executeDropDataNodeStatement(q *influxql.DropDataNodeStatement, user *User) *Result {
+	return &Result{Err: s.DeleteDataNode(q.ID)}
+}
+
+func (s *Server) executeShowBrokersStatement(q *influxql.ShowBrokersStatement, user *User) *Result {
+	if s.Broker == nil {
+		return &Result{Err: ErrBrokerUnavailable}
+	}
+	l := s.Broker.Log()
+
+	row := &influxql.Row{Columns: []string{"id", "url", "leader", "term", "commit_index", "applied_index"}}
+	config := l.Config()
+	if config == nil {
+		return &Result{Series: []*influxql.Row{row}}
+	}
+
+	// The raft state is only known for the broker running alongside this server.
+	leaderID, _ := l.Leader()
+	for _, n := range config.Nodes {
+		leader := n.ID == leaderID
+		var term, commitIndex, appliedIndex interface{}
+		if n.ID == l.ID() {
+			leader = leader || s.Broker.IsLeader()
+			term, commitIndex, appliedIndex = l.Term(), l.CommitIndex(), l.AppliedIndex()
+		}
+		row.Values = append(row.Values, []interface{}{n.ID, n.URL.String(), leader, term, commitIndex, appliedIndex})
+	}
+	return &Result{Series: []*influxql.Row{row}}
+}
+
+// sortedDatabases returns all databases sorted by name.
+// The caller must hold the server lock.
+func (s *Server) sortedDatabases() []*database {
+	a := make([]*database, 0, len(s.databases))
+	for _, db := range s.databases {
+		a = append(a, db)
+	}
+	sort.Sort(databasesByName(a))
+	return a
+}
+
+// databasesByName represents a list of databases sortable by name.
+type databasesByName []*database
+
+func (a databasesByName) Len() int           { return len(a) }
+func (a databasesByName) Less(i, j int) bool { return a[i].name < a[j].name }
+func (a databasesByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+func (s *Server) executeCreateContinuousQueryStatement(q *influxql.CreateContinuousQueryStatement, user *User) *Result {
+	return &Result{Err: s.CreateContinuousQuery(q)}
+}
+
+// CreateContinuousQuery creates a continuous query.
+func (s *Server) CreateContinuousQuery(q *influxql.CreateContinuousQueryStatement) error {
+	c := &createContinuousQueryCommand{Query: q.String()}
+	_, err := s.broadcast(createContinuousQueryMessageType, c)
+	return err
+}
+
+// SetContinuousQueryState records the end of the last completed interval of a continuous query.
+func (s *Server) SetContinuousQueryState(database, name string, lastCompleted time.Time) error {
+	c := &setContinuousQueryStateCommand{Database: database, Name: name, LastCompleted: lastCompleted}
+	_, err := s.broadcast(setContinuousQueryStateMessageType, c)
+	return err
+}
+
+// SetContinuousQueryBackfill records the progress of a continuous query backfill.
+// A nil backfill clears the progress.
+func (s *Server) SetContinuousQueryBackfill(database, name string, b *ContinuousQueryBackfill) error {
+	c := &setContinuousQueryBackfillCommand{Database: database, Name: name, Backfill: b}
+	_, err := s.broadcast(setContinuousQueryBackfillMessageType, c)
+	return err
+}
+
+// ContinuousQueryStats returns the execution statistics of all continuous queries.
+func (s *Server) ContinuousQueryStats() []ContinuousQueryStats {
+	a := make([]ContinuousQueryStats, 0)
+	for _, name := range s.Databases() {
+		for _, cq := range s.ContinuousQueries(name) {
+			a = append(a, cq.Stats())
+		}
+	}
+	return a
+}
+
+func (s *Server) executeCreateDownsampleStatement(q *influxql.CreateDownsampleStatement, user *User) *Result {
+	return &Result{Err: s.CreateDownsample(q.Database, &Downsample{
+		SourceRetentionPolicy: q.Source,
+		TargetRetentionPolicy: q.Target,
+		Aggregates:            q.Aggregates,
+		Interval:              q.Interval,
+	})}
+}
+
+func (s *Server) executeDropDownsampleStatement(q *influxql.DropDownsampleStatement, user *User) *Result {
+	return &Result{Err: s.DropDownsample(q.Database, q.Source, q.Target)}
+}
+
+// CreateDownsample creates a downsample on a database. The server generates and maintains
+// a continuous query for every measurement in the database.
+func (s *Server) CreateDownsample(database string, ds *Downsample) error {
+	c := &createDownsampleCommand{Database: database, Downsample: ds}
+	_, err := s.broadcast(createDownsampleMessageType, c)
+	return err
+}
+
+// DropDownsample removes a downsample and its continuous queries from a database.
+func (s *Server) DropDownsample(database, source, target string) error {
+	c := &dropDownsampleCommand{Database: database, Source: source, Target: target}
+	_, err := s.broadcast(dropDownsampleMessageType, c)
+	return err
+}
+
+// Downsamples returns a list of all downsamples on a database.
+func (s *Server) Downsamples(database string) []*Downsample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.databases[database]
+	if db == nil {
+		return nil
+	}
+
+	return db.downsamples
+}
+
+// ContinuousQueries returns a list of all continuous queries.
+func (s *Server) ContinuousQueries(database string) []*ContinuousQuery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.databases[database]
+	if db == nil {
+		return nil
+	}
+
+	return db.continuousQueries
+}
+
+// MeasurementNames returns a list of all measurements for the specified database.
+func (s *Server) MeasurementNames(database string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.databases[database]
+	if db == nil {
+		return nil
+	}
+
+	return db.names
+}
+
+/*
+func (s *Server) MeasurementSeriesIDs(database, measurement string) []uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.databases[database]
+	if db == nil {
+		return nil
+	}
+
+	return []uint32(db.SeriesIDs([]string{measurement}, nil))
+}
+*/
+
+// measurement returns a measurement by database and name.
+func (s *Server) measurement(database, name string) (*Measurement, error) {
 	db := s.databases[database]
 	if db == nil {
 		return nil, ErrDatabaseNotFound
@@ -2732,6 +3969,8 @@ func (s *Server) processor(client MessagingClient, done chan struct{}) {
 				err = s.applyCreateShardGroupIfNotExists(m)
 			case deleteShardGroupMessageType:
 				err = s.applyDeleteShardGroup(m)
+			case deleteShardMessageType:
+				err = s.applyDeleteShard(m)
 			case setDefaultRetentionPolicyMessageType:
 				err = s.applySetDefaultRetentionPolicy(m)
 			case createMeasurementsIfNotExistsMessageType:
@@ -2740,8 +3979,24 @@ func (s *Server) processor(client MessagingClient, done chan struct{}) {
 				err = s.applyDropMeasurement(m)
 			case setPrivilegeMessageType:
 				err = s.applySetPrivilege(m)
+			case setRolePrivilegeMessageType:
+				err = s.applySetRolePrivilege(m)
+			case createRoleMessageType:
+				err = s.applyCreateRole(m)
+			case deleteRoleMessageType:
+				err = s.applyDeleteRole(m)
+			case setUserRoleMessageType:
+				err = s.applySetUserRole(m)
 			case createContinuousQueryMessageType:
 				err = s.applyCreateContinuousQueryCommand(m)
+			case setContinuousQueryStateMessageType:
+				err = s.applySetContinuousQueryStateCommand(m)
+			case setContinuousQueryBackfillMessageType:
+				err = s.applySetContinuousQueryBackfillCommand(m)
+			case createDownsampleMessageType:
+				err = s.applyCreateDownsampleCommand(m)
+			case dropDownsampleMessageType:
+				err = s.applyDropDownsampleCommand(m)
 			case dropSeriesMessageType:
 				err = s.applyDropSeries(m)
 			}
@@ -2887,6 +4142,12 @@ func (p dataNodes) Len() int           { return len(p) }
 func (p dataNodes) Less(i, j int) bool { return p[i].ID < p[j].ID }
 func (p dataNodes) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
+type uint64Slice []uint64
+
+func (p uint64Slice) Len() int           { return len(p) }
+func (p uint64Slice) Less(i, j int) bool { return p[i] < p[j] }
+func (p uint64Slice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
 // Authorize user u to execute query q on database.
 // database can be "" for queries that do not require a database.
 // If u is nil, this means authorization is disabled.
@@ -2894,6 +4155,15 @@ func (s *Server) Authorize(u *User, q *influxql.Query, database string) error {
 	const authErrLogFmt = `unauthorized request | user: %q | query: %q | database %q\n`
 
 	if u == nil {
+		// Until an admin user exists, the only query allowed is the creation of one.
+		if !s.AdminUserExists() {
+			if isAdminUserCreation(q) {
+				return nil
+			}
+			s.Logger.Printf(authErrLogFmt, "", q.String(), database)
+			return ErrAdminUserRequired
+		}
+
 		s.Logger.Printf(authErrLogFmt, "", q.String(), database)
 		return ErrAuthorize{text: "no user provided"}
 	}
@@ -2905,9 +4175,21 @@ func (s *Server) Authorize(u *User, q *influxql.Query, database string) error {
 
 	// Check each statement in the query.
 	for _, stmt := range q.Statements {
+		// Users can always manage their own password and view their own grants.
+		if isOwnUserStatement(stmt, u) {
+			continue
+		}
+
 		// Get the privileges required to execute the statement.
 		privs := stmt.RequiredPrivileges()
 
+		// Backfills also write into the continuous query's target database.
+		if bf, ok := stmt.(*influxql.BackfillContinuousQueryStatement); ok {
+			if into := s.continuousQueryIntoDatabase(bf.Database, bf.Name); into != "" {
+				privs = append(privs, influxql.ExecutionPrivilege{Name: into, Privilege: influxql.WritePrivilege})
+			}
+		}
+
 		// Make sure the user has each privilege required to execute
 		// the statement.
 		for _, p := range privs {
@@ -2919,8 +4201,9 @@ func (s *Server) Authorize(u *User, q *influxql.Query, database string) error {
 				dbname = database
 			}
 
-			// Check if user has required privilege.
-			if !u.Authorize(p.Privilege, dbname) {
+			// Check if user has required privilege. Selects may also be
+			// allowed by privileges on the individual measurements.
+			if !u.Authorize(p.Privilege, dbname) && !s.authorizeMeasurements(u, stmt, p.Privilege, database) {
 				var msg string
 				if dbname == "" {
 					msg = "requires cluster admin"
@@ -2937,6 +4220,88 @@ func (s *Server) Authorize(u *User, q *influxql.Query, database string) error {
 	return nil
 }
 
+// continuousQueryIntoDatabase returns the database a continuous query writes into.
+// Returns a blank string if the continuous query doesn't exist.
+func (s *Server) continuousQueryIntoDatabase(database, name string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db := s.databases[database]
+	if db == nil {
+		return ""
+	}
+	cq := db.continuousQueryByName(name)
+	if cq == nil {
+		return ""
+	}
+	return cq.intoDB
+}
+
+// authorizeMeasurements returns true if u has measurement-level privileges
+// for every measurement a select statement reads from or writes into.
+func (s *Server) authorizeMeasurements(u *User, stmt influxql.Statement, privilege influxql.Privilege, database string) bool {
+	sel, ok := stmt.(*influxql.SelectStatement)
+	if !ok || !u.HasMeasurementPrivileges() {
+		return false
+	}
+
+	// Determine the measurements the privilege is required for.
+	var names []string
+	switch privilege {
+	case influxql.ReadPrivilege:
+		influxql.WalkFunc(sel.Source, func(n influxql.Node) {
+			if m, ok := n.(*influxql.Measurement); ok {
+				names = append(names, m.Name)
+			}
+		})
+	case influxql.WritePrivilege:
+		if sel.Target == nil || sel.Target.Measurement == "" {
+			return false
+		}
+		names = append(names, sel.Target.Measurement)
+		if sel.Target.Database != "" {
+			database = sel.Target.Database
+		}
+	default:
+		return false
+	}
+
+	for _, name := range names {
+		name, err := s.NormalizeMeasurement(name, database)
+		if err != nil {
+			return false
+		}
+		segments, err := influxql.SplitIdent(name)
+		if err != nil || len(segments) != 3 {
+			return false
+		}
+		if !u.AuthorizeMeasurement(privilege, segments[0], segments[1], segments[2]) {
+			return false
+		}
+	}
+	return len(names) > 0
+}
+
+// isOwnUserStatement returns true if stmt only applies to the account of u.
+func isOwnUserStatement(stmt influxql.Statement, u *User) bool {
+	switch stmt := stmt.(type) {
+	case *influxql.SetPasswordUserStatement:
+		return stmt.Name == u.Name
+	case *influxql.ShowGrantsForUserStatement:
+		return stmt.Name == u.Name
+	}
+	return false
+}
+
+// isAdminUserCreation returns true if q only creates a single admin user.
+func isAdminUserCreation(q *influxql.Query) bool {
+	if len(q.Statements) != 1 {
+		return false
+	}
+	stmt, ok := q.Statements[0].(*influxql.CreateUserStatement)
+	return ok && stmt.Privilege != nil && *stmt.Privilege == influxql.AllPrivileges
+}
+
 // BcryptCost is the cost associated with generating password with Bcrypt.
 // This setting is lowered during testing to improve test suite performance.
 var BcryptCost = 10
@@ -2948,6 +4313,14 @@ type User struct {
 	Hash       string                        `json:"hash"`
 	Privileges map[string]influxql.Privilege `json:"privileges"` // db name to privilege
 	Admin      bool                          `json:"admin,omitempty"`
+
+	// Privileges limited to the measurements of a database.
+	MeasurementPrivileges []*MeasurementPrivilege `json:"measurementPrivileges,omitempty"`
+
+	// Names of the roles the user is a member of.
+	Roles []string `json:"roles,omitempty"`
+
+	roles []*Role // resolved by the server from Roles
 }
 
 // Authenticate returns nil if the password matches the user's password.
@@ -2957,9 +4330,130 @@ func (u *User) Authenticate(password string) error {
 }
 
 // Authorize returns true if the user is authorized and false if not.
+// Privileges granted to the user's roles are included.
 func (u *User) Authorize(privilege influxql.Privilege, database string) bool {
-	p, ok := u.Privileges[database]
-	return (ok && p >= privilege) || (u.Admin)
+	if p, ok := u.Privileges[database]; (ok && p >= privilege) || (u.Admin) {
+		return true
+	}
+	for _, r := range u.roles {
+		if p, ok := r.Privileges[database]; ok && p >= privilege {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeMeasurement returns true if the user is authorized to perform
+// privilege on a measurement, either by a database-wide privilege or by a
+// privilege on measurements matching the name.
+func (u *User) AuthorizeMeasurement(privilege influxql.Privilege, database, policy, measurement string) bool {
+	if u.Authorize(privilege, database) {
+		return true
+	} else if authorizeMeasurement(u.MeasurementPrivileges, privilege, database, policy, measurement) {
+		return true
+	}
+	for _, r := range u.roles {
+		if authorizeMeasurement(r.MeasurementPrivileges, privilege, database, policy, measurement) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMeasurementPrivileges returns true if the user or any of its roles has
+// been granted privileges on individual measurements.
+func (u *User) HasMeasurementPrivileges() bool {
+	if len(u.MeasurementPrivileges) > 0 {
+		return true
+	}
+	for _, r := range u.roles {
+		if len(r.MeasurementPrivileges) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// addRole adds the user to a role if it is not already a member.
+func (u *User) addRole(name string) {
+	for _, n := range u.Roles {
+		if n == name {
+			return
+		}
+	}
+	u.Roles = append(u.Roles, name)
+	sort.Strings(u.Roles)
+}
+
+// removeRole removes the user from a role. Returns true if it was a member.
+func (u *User) removeRole(name string) bool {
+	for i, n := range u.Roles {
+		if n == name {
+			u.Roles = append(u.Roles[:i], u.Roles[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Role represents a named set of privileges that can be granted to users.
+type Role struct {
+	Name       string                        `json:"name"`
+	Privileges map[string]influxql.Privilege `json:"privileges"` // db name to privilege
+
+	// Privileges limited to the measurements of a database.
+	MeasurementPrivileges []*MeasurementPrivilege `json:"measurementPrivileges,omitempty"`
+}
+
+// roles represents a list of roles, sortable by name.
+type roles []*Role
+
+func (p roles) Len() int           { return len(p) }
+func (p roles) Less(i, j int) bool { return p[i].Name < p[j].Name }
+func (p roles) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// authorizeMeasurement returns true if any of the privileges allows privilege on a measurement.
+func authorizeMeasurement(a []*MeasurementPrivilege, privilege influxql.Privilege, database, policy, measurement string) bool {
+	for _, p := range a {
+		if p.Privilege >= privilege && p.Database == database &&
+			(p.RetentionPolicy == "" || p.RetentionPolicy == policy) && p.Measurement.Matches(measurement) {
+			return true
+		}
+	}
+	return false
+}
+
+// setMeasurementPrivilege updates the privilege on matching measurements and
+// returns the updated list. Setting NoPrivileges removes the grant.
+func setMeasurementPrivilege(a []*MeasurementPrivilege, database, policy string, m Matcher, privilege influxql.Privilege) []*MeasurementPrivilege {
+	for i, p := range a {
+		if p.Database != database || p.RetentionPolicy != policy || p.Measurement != m {
+			continue
+		}
+		if privilege == influxql.NoPrivileges {
+			return append(a[:i], a[i+1:]...)
+		}
+		p.Privilege = privilege
+		return a
+	}
+
+	if privilege != influxql.NoPrivileges {
+		a = append(a, &MeasurementPrivilege{
+			Database:        database,
+			RetentionPolicy: policy,
+			Measurement:     m,
+			Privilege:       privilege,
+		})
+	}
+	return a
+}
+
+// MeasurementPrivilege represents a privilege on the measurements of a database.
+type MeasurementPrivilege struct {
+	Database        string             `json:"database"`
+	RetentionPolicy string             `json:"retentionPolicy,omitempty"` // empty matches any policy
+	Measurement     Matcher            `json:"measurement"`
+	Privilege       influxql.Privilege `json:"privilege"`
 }
 
 // users represents a list of users, sortable by name.
@@ -2971,8 +4465,16 @@ func (p users) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
 // Matcher can match either a Regex or plain string.
 type Matcher struct {
-	IsRegex bool
-	Name    string
+	IsRegex bool   `json:"isRegex,omitempty"`
+	Name    string `json:"name"`
+}
+
+// String returns the name, or the regex surrounded by slashes.
+func (m *Matcher) String() string {
+	if m.IsRegex {
+		return "/" + strings.Replace(m.Name, "/", `\/`, -1) + "/"
+	}
+	return m.Name
 }
 
 // Matches returns true of the name passed in matches this Matcher.
@@ -2997,12 +4499,344 @@ func HashPassword(password string) ([]byte, error) {
 type ContinuousQuery struct {
 	Query string `json:"query"`
 
+	// The end of the last interval that has been computed. Intervals after this
+	// are computed on the next run, limited by the server's MaxCatchUpWindow.
+	LastCompleted time.Time `json:"lastCompleted,omitempty"`
+
+	// The progress of an unfinished backfill, if any.
+	Backfill *ContinuousQueryBackfill `json:"backfill,omitempty"`
+
+	// True if the query was generated by a downsample and is maintained by the server.
+	Managed bool `json:"managed,omitempty"`
+
 	mu              sync.Mutex
 	cq              *influxql.CreateContinuousQueryStatement
 	lastRun         time.Time
 	intoDB          string
 	intoRP          string
 	intoMeasurement string
+
+	statsMu sync.Mutex
+	stats   ContinuousQueryStats
+}
+
+// Downsample represents a rollup of every measurement in one retention policy into another.
+// Each numeric field is aggregated with every aggregate function over the interval and
+// written into a field named "<aggregate>_<field>" on the same measurement.
+type Downsample struct {
+	SourceRetentionPolicy string        `json:"source"`
+	TargetRetentionPolicy string        `json:"target"`
+	Aggregates            []string      `json:"aggregates"`
+	Interval              time.Duration `json:"interval"`
+}
+
+// continuousQueryName returns the name of the query that downsamples a measurement.
+// Names that contain anything other than letters and digits are replaced with
+// underscores and suffixed with a hash of the original names so they can't collide.
+func (ds *Downsample) continuousQueryName(measurement string) string {
+	parts := []string{ds.SourceRetentionPolicy, ds.TargetRetentionPolicy, measurement}
+
+	var escaped bool
+	h := fnv.New64a()
+	for i, part := range parts {
+		parts[i] = strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			escaped = true
+			return '_'
+		}, part)
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	name := "downsample_" + strings.Join(parts, "_")
+	if escaped {
+		name += fmt.Sprintf("_%016x", h.Sum64())
+	}
+	return name
+}
+
+// continuousQuery returns the query that downsamples the fields of a measurement.
+// Returns an empty string if the measurement has no fields to downsample.
+func (ds *Downsample) continuousQuery(database string, m *Measurement, fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	var exprs []string
+	for _, f := range fields {
+		for _, agg := range ds.Aggregates {
+			exprs = append(exprs, fmt.Sprintf("%s(%s) AS %s", agg, quoteIdentIfNeeded(f), quoteIdentIfNeeded(agg+"_"+f)))
+		}
+	}
+
+	return fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s BEGIN SELECT %s INTO %s FROM %s GROUP BY time(%s), * END",
+		ds.continuousQueryName(m.Name), quoteIdentIfNeeded(database), strings.Join(exprs, ", "),
+		influxql.QuoteIdent([]string{database, ds.TargetRetentionPolicy, m.Name}),
+		influxql.QuoteIdent([]string{database, ds.SourceRetentionPolicy, m.Name}),
+		influxql.FormatDuration(ds.Interval))
+}
+
+// applyCreateDownsampleCommand adds a downsample to the database and generates its continuous queries
+func (s *Server) applyCreateDownsampleCommand(m *messaging.Message) error {
+	var c createDownsampleCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Validate command.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	} else if db.policies[c.Downsample.SourceRetentionPolicy] == nil || db.policies[c.Downsample.TargetRetentionPolicy] == nil {
+		return ErrRetentionPolicyNotFound
+	} else if c.Downsample.SourceRetentionPolicy == c.Downsample.TargetRetentionPolicy {
+		return ErrDownsampleSourceIsTarget
+	} else if db.downsampleByRetentionPolicies(c.Downsample.SourceRetentionPolicy, c.Downsample.TargetRetentionPolicy) != nil {
+		return ErrDownsampleExists
+	}
+
+	// Add the downsample and generate its queries.
+	db.downsamples = append(db.downsamples, c.Downsample)
+	if err := s.syncDownsampleQueries(db); err != nil {
+		db.downsamples = db.downsamples[:len(db.downsamples)-1]
+		return err
+	}
+
+	// Persist to metastore.
+	s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+		return tx.saveDatabase(db)
+	})
+
+	return nil
+}
+
+// applyDropDownsampleCommand removes a downsample and its continuous queries from the database
+func (s *Server) applyDropDownsampleCommand(m *messaging.Message) error {
+	var c dropDownsampleCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Retrieve the database and downsample.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+	ds := db.downsampleByRetentionPolicies(c.Source, c.Target)
+	if ds == nil {
+		return ErrDownsampleNotFound
+	}
+
+	// Remove the downsample and its queries.
+	downsamples := make([]*Downsample, 0, len(db.downsamples))
+	for _, other := range db.downsamples {
+		if other != ds {
+			downsamples = append(downsamples, other)
+		}
+	}
+	db.downsamples = downsamples
+	if err := s.syncDownsampleQueries(db); err != nil {
+		return err
+	}
+
+	// Persist to metastore.
+	s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+		return tx.saveDatabase(db)
+	})
+
+	return nil
+}
+
+// syncDownsampleQueries creates, updates, and removes the continuous queries managed by the
+// database's downsamples so that there is one for every measurement with numeric fields.
+// Fields written by a downsample are only downsampled again from its target retention
+// policy. Queries that are unchanged are kept so their state is preserved.
+func (s *Server) syncDownsampleQueries(db *database) error {
+	// Separate user queries from the ones managed by downsamples.
+	queries := make([]*ContinuousQuery, 0, len(db.continuousQueries))
+	managed := make(map[string]*ContinuousQuery)
+	for _, cq := range db.continuousQueries {
+		if cq.Managed {
+			managed[cq.cq.Name] = cq
+			continue
+		}
+		queries = append(queries, cq)
+	}
+
+	for _, ds := range db.downsamples {
+		for _, name := range db.names {
+			mm := db.measurements[name]
+			q := ds.continuousQuery(db.name, mm, downsampleFields(mm, db.downsamples, ds.SourceRetentionPolicy))
+			if q == "" {
+				continue
+			}
+
+			// Keep the existing query if it hasn't changed.
+			if cq := managed[ds.continuousQueryName(name)]; cq != nil && cq.Query == q {
+				queries = append(queries, cq)
+				continue
+			}
+
+			cq, err := NewContinuousQuery(q)
+			if err != nil {
+				return err
+			}
+			if err := s.normalizeStatement(cq.cq.Source, cq.cq.Database); err != nil {
+				return err
+			}
+			cq.Managed = true
+
+			// Carry over the state of the query being replaced.
+			if prev := managed[cq.cq.Name]; prev != nil {
+				cq.LastCompleted = prev.LastCompleted
+			}
+			queries = append(queries, cq)
+		}
+	}
+
+	db.continuousQueries = queries
+	return nil
+}
+
+// downsampleFields returns the names of the numeric fields on a measurement that are
+// downsampled from a retention policy. A retention policy written by downsamples is read
+// from the fields those downsamples generate. Any other retention policy is read from
+// the fields that aren't generated by a downsample.
+func downsampleFields(m *Measurement, downsamples []*Downsample, rp string) []string {
+	return downsampleFieldsFrom(m, downsamples, rp, make(map[string]bool))
+}
+
+// downsampleFieldsFrom returns the fields downsampled from rp. Retention policies that
+// are already being visited are skipped in case downsamples form a cycle.
+func downsampleFieldsFrom(m *Measurement, downsamples []*Downsample, rp string, visited map[string]bool) []string {
+	visited[rp] = true
+	defer delete(visited, rp)
+
+	// Determine the fields generated by all downsamples and the ones written into rp.
+	generated, written := make(map[string]bool), make(map[string]bool)
+	var target bool
+	for _, ds := range downsamples {
+		for _, f := range m.Fields {
+			for _, agg := range ds.Aggregates {
+				generated[agg+"_"+f.Name] = true
+			}
+		}
+
+		if ds.TargetRetentionPolicy != rp || visited[ds.SourceRetentionPolicy] {
+			continue
+		}
+		target = true
+		for _, f := range downsampleFieldsFrom(m, downsamples, ds.SourceRetentionPolicy, visited) {
+			for _, agg := range ds.Aggregates {
+				written[agg+"_"+f] = true
+			}
+		}
+	}
+
+	var a []string
+	for _, f := range m.Fields {
+		if f.Type != influxql.Number {
+			continue
+		} else if target && !written[f.Name] {
+			continue
+		} else if !target && generated[f.Name] {
+			continue
+		}
+		a = append(a, f.Name)
+	}
+	return a
+}
+
+// quoteIdentIfNeeded returns s quoted as an identifier unless it can be used bare.
+func quoteIdentIfNeeded(s string) string {
+	if isBareIdent(s) {
+		return s
+	}
+	return influxql.QuoteIdent([]string{s})
+}
+
+// isBareIdent returns true if s can be used as an identifier without quoting.
+func isBareIdent(s string) bool {
+	if s == "" || influxql.Lookup(s) != influxql.IDENT {
+		return false
+	}
+	for i, r := range s {
+		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContinuousQueryID uniquely identifies a continuous query within a cluster.
+type ContinuousQueryID struct {
+	Database string `json:"database"`
+	Name     string `json:"name"`
+}
+
+// String returns a string representation of the identifier.
+func (id ContinuousQueryID) String() string { return id.Database + "." + id.Name }
+
+// continuousQueryIDs represents a list of identifiers sortable by database and name.
+type continuousQueryIDs []ContinuousQueryID
+
+func (a continuousQueryIDs) Len() int      { return len(a) }
+func (a continuousQueryIDs) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a continuousQueryIDs) Less(i, j int) bool {
+	if a[i].Database != a[j].Database {
+		return a[i].Database < a[j].Database
+	}
+	return a[i].Name < a[j].Name
+}
+
+// ContinuousQueryBackfill represents the progress of computing a continuous query over historical data.
+type ContinuousQueryBackfill struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+
+	// The end of the last interval that has been computed.
+	Completed time.Time `json:"completed"`
+}
+
+// ContinuousQueryStats represents the execution statistics of a continuous query on this server.
+type ContinuousQueryStats struct {
+	Database      string        `json:"database"`
+	Name          string        `json:"name"`
+	Runs          int64         `json:"runs"`
+	Failures      int64         `json:"failures"`
+	LastError     string        `json:"lastError,omitempty"`
+	LastDuration  time.Duration `json:"lastDuration"`
+	PointsWritten int64         `json:"pointsWritten"`
+	LastInterval  time.Time     `json:"lastInterval"`
+}
+
+// Stats returns a copy of the execution statistics of the continuous query.
+func (cq *ContinuousQuery) Stats() ContinuousQueryStats {
+	cq.statsMu.Lock()
+	defer cq.statsMu.Unlock()
+
+	stats := cq.stats
+	stats.Database = cq.cq.Database
+	stats.Name = cq.cq.Name
+	return stats
+}
+
+// updateStats records a single execution of the continuous query over the interval starting at tmin.
+func (cq *ContinuousQuery) updateStats(tmin time.Time, d time.Duration, pointsWritten int64, err error) {
+	cq.statsMu.Lock()
+	defer cq.statsMu.Unlock()
+
+	cq.stats.Runs++
+	cq.stats.LastDuration = d
+	cq.stats.PointsWritten += pointsWritten
+	if err != nil {
+		cq.stats.Failures++
+		cq.stats.LastError = err.Error()
+		return
+	}
+
+	// Recomputing previous intervals shouldn't move the last interval back.
+	if tmin.After(cq.stats.LastInterval) {
+		cq.stats.LastInterval = tmin
+	}
 }
 
 // NewContinuousQuery returns a ContinuousQuery object with a parsed influxql.CreateContinuousQueryStatement
@@ -3084,21 +4918,104 @@ func (s *Server) applyCreateContinuousQueryCommand(m *messaging.Message) error {
 	return nil
 }
 
+// applySetContinuousQueryStateCommand updates the last completed interval of a continuous query
+func (s *Server) applySetContinuousQueryStateCommand(m *messaging.Message) error {
+	var c setContinuousQueryStateCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Retrieve the database and continuous query.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+	cq := db.continuousQueryByName(c.Name)
+	if cq == nil {
+		return ErrContinuousQueryNotFound
+	}
+
+	// Never move the state backwards.
+	if !c.LastCompleted.After(cq.LastCompleted) {
+		return nil
+	}
+	cq.LastCompleted = c.LastCompleted
+
+	// Persist to metastore.
+	s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+		return tx.saveDatabase(db)
+	})
+
+	return nil
+}
+
+// applySetContinuousQueryBackfillCommand updates the backfill progress of a continuous query
+func (s *Server) applySetContinuousQueryBackfillCommand(m *messaging.Message) error {
+	var c setContinuousQueryBackfillCommand
+	mustUnmarshalJSON(m.Data, &c)
+
+	// Retrieve the database and continuous query.
+	db := s.databases[c.Database]
+	if db == nil {
+		return ErrDatabaseNotFound
+	}
+	cq := db.continuousQueryByName(c.Name)
+	if cq == nil {
+		return ErrContinuousQueryNotFound
+	}
+	cq.Backfill = c.Backfill
+
+	// Persist to metastore.
+	s.meta.mustUpdate(m.Index, func(tx *metatx) error {
+		return tx.saveDatabase(db)
+	})
+
+	return nil
+}
+
+// ContinuousQueryIDs returns the identifiers of all continuous queries on the server.
+func (s *Server) ContinuousQueryIDs() []ContinuousQueryID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a := make([]ContinuousQueryID, 0)
+	for _, d := range s.databases {
+		for _, c := range d.continuousQueries {
+			a = append(a, ContinuousQueryID{Database: d.name, Name: c.cq.Name})
+		}
+	}
+	sort.Sort(continuousQueryIDs(a))
+	return a
+}
+
 // RunContinuousQueries will run any continuous queries that are due to run and write the
-// results back into the database
-func (s *Server) RunContinuousQueries() error {
+// results back into the database. If ids are specified then only those queries are run.
+func (s *Server) RunContinuousQueries(ids ...ContinuousQueryID) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	// build a lookup of the requested queries, if any
+	var requested map[ContinuousQueryID]struct{}
+	if len(ids) > 0 {
+		requested = make(map[ContinuousQueryID]struct{}, len(ids))
+		for _, id := range ids {
+			requested[id] = struct{}{}
+		}
+	}
+
 	for _, d := range s.databases {
 		for _, c := range d.continuousQueries {
+			if requested != nil {
+				if _, ok := requested[ContinuousQueryID{Database: d.name, Name: c.cq.Name}]; !ok {
+					continue
+				}
+			}
+
 			if s.shouldRunContinuousQuery(c) {
 				// set the into retention policy based on what is now the default
 				if c.intoRP == "" {
 					c.intoRP = d.defaultRetentionPolicy
 				}
 				go func(cq *ContinuousQuery) {
-					s.runContinuousQuery(c)
+					s.runContinuousQuery(cq)
 				}(c)
 			}
 		}
@@ -3129,6 +5046,10 @@ func (s *Server) shouldRunContinuousQuery(cq *ContinuousQuery) bool {
 	if computeEvery < s.ComputeNoMoreThan {
 		computeEvery = s.ComputeNoMoreThan
 	}
+	// a RESAMPLE EVERY clause overrides the config settings
+	if cq.cq.ResampleEvery > 0 {
+		computeEvery = cq.cq.ResampleEvery
+	}
 
 	// if we've passed the amount of time since the last run, do it up
 	if cq.lastRun.Add(computeEvery).UnixNano() <= time.Now().UnixNano() {
@@ -3139,7 +5060,6 @@ func (s *Server) shouldRunContinuousQuery(cq *ContinuousQuery) bool {
 }
 
 // runContinuousQuery will execute a continuous query
-// TODO: make this fan out to the cluster instead of running all the queries on this single data node
 func (s *Server) runContinuousQuery(cq *ContinuousQuery) {
 	cq.mu.Lock()
 	defer cq.mu.Unlock()
@@ -3152,22 +5072,43 @@ func (s *Server) runContinuousQuery(cq *ContinuousQuery) {
 		return
 	}
 
-	startTime := now.Round(interval)
-	if startTime.UnixNano() > now.UnixNano() {
-		startTime = startTime.Add(-interval)
+	// Align to the intervals of the CQ's GROUP BY, including any offset and time zone.
+	startTime, err := cq.cq.Source.GroupByIntervalStart(now)
+	if err != nil {
+		return
 	}
 
+	// Catch up on any intervals that elapsed since the last completed interval.
+	s.mu.RLock()
+	lastCompleted := cq.LastCompleted
+	s.mu.RUnlock()
+	completed := s.catchUpContinuousQuery(cq, lastCompleted, startTime, interval)
+
 	if err := cq.cq.Source.SetTimeRange(startTime, startTime.Add(interval)); err != nil {
 		log.Printf("cq error setting time range: %s\n", err.Error())
 	}
 
-	if err := s.runContinuousQueryAndWriteResult(cq); err != nil {
+	if _, err := s.runContinuousQueryAndWriteResult(cq, cq.cq.Source); err != nil {
 		log.Printf("cq error: %s. running: %s\n", err.Error(), cq.cq.String())
 	}
 
-	for i := 0; i < s.RecomputePreviousN; i++ {
+	// Record the last completed interval so a restart resumes from here.
+	if completed.After(lastCompleted) {
+		if err := s.SetContinuousQueryState(cq.cq.Database, cq.cq.Name, completed); err != nil {
+			log.Printf("cq error saving state: %s. running: %s\n", err.Error(), cq.cq.String())
+		}
+	}
+
+	// determine how many previous intervals to recompute. A RESAMPLE FOR clause
+	// overrides the config settings and covers the current interval as well.
+	recomputeN, recomputeNoOlderThan := s.RecomputePreviousN, s.RecomputeNoOlderThan
+	if cq.cq.ResampleFor > 0 {
+		recomputeN, recomputeNoOlderThan = int(cq.cq.ResampleFor/interval)-1, cq.cq.ResampleFor
+	}
+
+	for i := 0; i < recomputeN; i++ {
 		// if we're already more time past the previous window than we're going to look back, stop
-		if now.Sub(startTime) > s.RecomputeNoOlderThan {
+		if now.Sub(startTime) > recomputeNoOlderThan {
 			return
 		}
 		newStartTime := startTime.Add(-interval)
@@ -3176,7 +5117,7 @@ func (s *Server) runContinuousQuery(cq *ContinuousQuery) {
 			log.Printf("cq error setting time range: %s\n", err.Error())
 		}
 
-		if err := s.runContinuousQueryAndWriteResult(cq); err != nil {
+		if _, err := s.runContinuousQueryAndWriteResult(cq, cq.cq.Source); err != nil {
 			log.Printf("cq error: %s. running: %s\n", err.Error(), cq.cq.String())
 		}
 
@@ -3184,37 +5125,96 @@ func (s *Server) runContinuousQuery(cq *ContinuousQuery) {
 	}
 }
 
-// runContinuousQueryAndWriteResult will run the query against the cluster and write the results back in
-func (s *Server) runContinuousQueryAndWriteResult(cq *ContinuousQuery) error {
-	e, err := s.planSelectStatement(cq.cq.Source)
+// catchUpContinuousQuery computes each interval between the last completed interval and
+// startTime, going back no further than MaxCatchUpWindow. Returns the end of the last
+// interval that was successfully computed.
+func (s *Server) catchUpContinuousQuery(cq *ContinuousQuery, lastCompleted, startTime time.Time, interval time.Duration) time.Time {
+	// Nothing to catch up on if this is the first run or catch-up is disabled.
+	if lastCompleted.IsZero() || s.MaxCatchUpWindow == 0 {
+		return startTime
+	}
 
+	// Limit how far back we go.
+	from := lastCompleted
+	if startTime.Sub(from) > s.MaxCatchUpWindow {
+		from = startTime.Add(-(s.MaxCatchUpWindow / interval) * interval)
+		log.Printf("cq catch-up limited to %s, skipping intervals from %s to %s. running: %s\n",
+			s.MaxCatchUpWindow, lastCompleted, from, cq.cq.String())
+	}
+
+	// Walk forward one interval at a time and stop at the first failure so
+	// the next run resumes from there.
+	for t := from; t.Before(startTime); t = t.Add(interval) {
+		if err := cq.cq.Source.SetTimeRange(t, t.Add(interval)); err != nil {
+			log.Printf("cq error setting time range: %s\n", err.Error())
+			return t
+		}
+
+		if _, err := s.runContinuousQueryAndWriteResult(cq, cq.cq.Source); err != nil {
+			log.Printf("cq error: %s. running: %s\n", err.Error(), cq.cq.String())
+			return t
+		}
+	}
+
+	return startTime
+}
+
+// runContinuousQueryAndWriteResult will run the CQ's select statement against the cluster and write
+// the results back in, recording the run in the CQ's statistics. Returns the number of points written.
+func (s *Server) runContinuousQueryAndWriteResult(cq *ContinuousQuery, source *influxql.SelectStatement) (n int64, err error) {
+	// Record the execution in the statistics of the CQ.
+	start := time.Now()
+	defer func() {
+		tmin, _ := influxql.TimeRange(source.Condition)
+		cq.updateStats(tmin, time.Since(start), n, err)
+	}()
+
+	return s.executeContinuousQuery(cq, source)
+}
+
+// executeContinuousQuery runs source against the cluster and writes the results into
+// the CQ's target. Returns the number of points written. The caller must hold cq.mu.
+func (s *Server) executeContinuousQuery(cq *ContinuousQuery, source *influxql.SelectStatement) (n int64, err error) {
+	// Expand any wildcard dimensions against the current tag keys.
+	stmt, err := s.rewriteSelectStatement(source)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	e, err := s.planSelectStatement(stmt)
+	if err != nil {
+		return 0, err
 	}
 
 	// Execute plan.
 	ch, err := e.Execute()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	// Read all rows from channel and write them in
+	// Read all rows from channel and write them in. Keep going after an error
+	// so that as much as possible is written, but report the first one.
 	for row := range ch {
-		points, err := s.convertRowToPoints(cq.intoMeasurement, row)
-		if err != nil {
-			log.Println(err)
+		points, e := s.convertRowToPoints(cq.intoMeasurement, row)
+		if e != nil {
+			if err == nil {
+				err = e
+			}
 			continue
 		}
 
 		if len(points) > 0 {
-			_, err = s.WriteSeries(cq.intoDB, cq.intoRP, points)
-			if err != nil {
-				log.Printf("[cq] err: %s", err)
+			if _, e := s.WriteSeries(cq.intoDB, cq.intoRP, points); e != nil {
+				if err == nil {
+					err = e
+				}
+				continue
 			}
+			n += int64(len(points))
 		}
 	}
 
-	return nil
+	return n, err
 }
 
 // convertRowToPoints will convert a query result Row into Points that can be written back in.
@@ -3239,9 +5239,18 @@ func (s *Server) convertRowToPoints(measurementName string, row *influxql.Row) (
 	for _, v := range row.Values {
 		vals := make(map[string]interface{})
 		for fieldName, fieldIndex := range fieldIndexes {
+			// Skip null values since they cannot be stored.
+			if v[fieldIndex] == nil {
+				continue
+			}
 			vals[fieldName] = v[fieldIndex]
 		}
 
+		// Skip points without any values.
+		if len(vals) == 0 {
+			continue
+		}
+
 		p := &Point{
 			Name:      measurementName,
 			Tags:      row.Tags,
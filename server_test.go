@@ -97,6 +97,105 @@ func TestServer_DeleteDataNode(t *testing.T) {
 	}
 }
 
+// Ensure the server can list and drop data nodes using queries.
+func TestServer_ShowDataNodes(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: time.Hour})
+	if err := s.CreateShardGroupIfNotExists("foo", "raw", mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("http://localhost:80000")
+	if err := s.CreateDataNode(u); err != nil {
+		t.Fatal(err)
+	}
+	n := s.DataNodeByURL(u)
+	g, _ := s.ShardGroups("foo")
+
+	// Verify both nodes are listed with the topics they subscribe to.
+	results := s.ExecuteQuery(MustParseQuery(`SHOW DATA NODES`), "", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != fmt.Sprintf(`{"series":[{"columns":["id","url","topics","index"],"values":[[1,"//127.0.0.1:8080",[0,%d],%d],[%d,"http://localhost:80000",[0],null]]}]}`, g[0].Shards[0].ID, results.Results[0].Series[0].Values[0][3], n.ID) {
+		t.Fatalf("unexpected results: %s", s)
+	}
+
+	// Drop the second node and verify it's gone.
+	results = s.ExecuteQuery(MustParseQuery(fmt.Sprintf(`DROP DATA NODE %d`, n.ID)), "", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s.DataNode(n.ID) != nil {
+		t.Fatal("data node not dropped")
+	}
+	results = s.ExecuteQuery(MustParseQuery(fmt.Sprintf(`DROP DATA NODE %d`, n.ID)), "", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrDataNodeNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure the server can report the state of the brokers it runs alongside.
+func TestServer_ShowBrokers(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+
+	// Without a broker there is nothing to report.
+	results := s.ExecuteQuery(MustParseQuery(`SHOW BROKERS`), "", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrBrokerUnavailable {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Attach an initialized broker.
+	b := influxdb.NewBroker()
+	f := tempfile()
+	defer os.Remove(f)
+	if err := b.Open(f, &url.URL{Scheme: "http", Host: "127.0.0.1:8086"}); err != nil {
+		t.Fatalf("error opening broker: %s", err)
+	} else if err := b.Initialize(); err != nil {
+		t.Fatalf("error initializing broker: %s", err)
+	}
+	defer b.Close()
+	s.Broker = b.Broker
+
+	l := b.Log()
+	results = s.ExecuteQuery(MustParseQuery(`SHOW BROKERS`), "", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != fmt.Sprintf(`{"series":[{"columns":["id","url","leader","term","commit_index","applied_index"],"values":[[1,"http://127.0.0.1:8086",true,%d,%d,%d]]}]}`, l.Term(), l.CommitIndex(), l.AppliedIndex()) {
+		t.Fatalf("unexpected results: %s", s)
+	}
+}
+
+// Ensure only the creation of an admin user is allowed until one exists.
+func TestServer_Authorize_Bootstrap(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.SetAuthenticationEnabled(true)
+	s.CreateUser("susy", "pass", false)
+
+	// Non-admin users do not end bootstrapping.
+	for _, q := range []string{
+		`SHOW DATABASES`,
+		`CREATE USER bob WITH PASSWORD 'pass'`,
+		`CREATE USER bob WITH PASSWORD 'pass' WITH ALL PRIVILEGES; SHOW DATABASES`,
+	} {
+		if res := s.ExecuteQuery(MustParseQuery(q), "", nil); res.Error() != influxdb.ErrAdminUserRequired {
+			t.Fatalf("%s: unexpected error: %s", q, res.Error())
+		}
+	}
+
+	if res := s.ExecuteQuery(MustParseQuery(`CREATE USER bob WITH PASSWORD 'pass' WITH ALL PRIVILEGES`), "", nil); res.Error() != nil {
+		t.Fatalf("unexpected error: %s", res.Error())
+	} else if !s.AdminUserExists() {
+		t.Fatal("admin user not created")
+	}
+
+	// A user is required once an admin exists.
+	if res := s.ExecuteQuery(MustParseQuery(`CREATE USER lucy WITH PASSWORD 'pass' WITH ALL PRIVILEGES`), "", nil); res.Error() == nil || res.Error().Error() != "no user provided" {
+		t.Fatalf("unexpected error: %v", res.Error())
+	}
+}
+
 // Test unuathorized requests logging
 func TestServer_UnauthorizedRequests(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
@@ -173,6 +272,190 @@ func TestServer_UserPrivilegeAuthorization(t *testing.T) {
 	}
 }
 
+// Ensure privileges can be granted on individual measurements and are enforced for selects.
+func TestServer_MeasurementPrivilegeAuthorization(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.CreateUser("admin", "admin", true)
+	s.CreateUser("jdoe", "jdoe", false)
+
+	// Grant read on the app_* measurements in any policy and write on cpu in "raw".
+	results := s.ExecuteQuery(MustParseQuery(`GRANT READ ON foo../^app_/ TO jdoe; GRANT WRITE ON "foo"."raw"."cpu" TO jdoe`), "", nil)
+	if err := results.Error(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s.Restart()
+
+	// Verify the grants were persisted.
+	user := s.User("jdoe")
+	if !user.AuthorizeMeasurement(influxql.ReadPrivilege, "foo", "raw", "app_requests") {
+		t.Fatal("expected read privilege on app_requests")
+	} else if user.AuthorizeMeasurement(influxql.ReadPrivilege, "foo", "raw", "billing") {
+		t.Fatal("unexpected read privilege on billing")
+	} else if !user.AuthorizeMeasurement(influxql.ReadPrivilege, "foo", "raw", "cpu") {
+		t.Fatal("expected write privilege on cpu to allow reads")
+	} else if user.AuthorizeMeasurement(influxql.WritePrivilege, "foo", "other", "cpu") {
+		t.Fatal("unexpected write privilege on cpu in another retention policy")
+	} else if user.Authorize(influxql.ReadPrivilege, "foo") {
+		t.Fatal("unexpected read privilege on database")
+	}
+
+	// Selects are only authorized if every source is covered by a grant.
+	if err := s.Authorize(user, MustParseQuery(`SELECT value FROM app_requests`), "foo"); err != nil {
+		t.Fatal(err)
+	} else if err := s.Authorize(user, MustParseQuery(`SELECT value FROM "foo"."raw"."cpu"`), ""); err != nil {
+		t.Fatal(err)
+	} else if err := s.Authorize(user, MustParseQuery(`SELECT value FROM billing`), "foo"); err == nil {
+		t.Fatal("expected error selecting from billing")
+	} else if err := s.Authorize(user, MustParseQuery(`SELECT value FROM merge(app_requests, billing)`), "foo"); err == nil {
+		t.Fatal("expected error merging billing")
+	} else if err := s.Authorize(user, MustParseQuery(`SHOW MEASUREMENTS`), "foo"); err == nil {
+		t.Fatal("expected error showing measurements")
+	}
+
+	// Verify the grants are listed.
+	results = s.ExecuteQuery(MustParseQuery(`SHOW GRANTS FOR jdoe`), "", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"columns":["database","retentionPolicy","measurement","privilege","role"],"values":[["foo","","/^app_/","READ",""],["foo","raw","cpu","WRITE",""]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Revoke the regex grant.
+	results = s.ExecuteQuery(MustParseQuery(`REVOKE READ ON foo../^app_/ FROM jdoe`), "", nil)
+	if err := results.Error(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s.Restart()
+
+	if user := s.User("jdoe"); len(user.MeasurementPrivileges) != 1 || user.AuthorizeMeasurement(influxql.ReadPrivilege, "foo", "raw", "app_requests") {
+		t.Fatalf("unexpected privileges: %s", mustMarshalJSON(user.MeasurementPrivileges))
+	}
+}
+
+// Ensure users are authorized by the privileges granted to their roles.
+func TestServer_Roles(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateUser("jdoe", "jdoe", false)
+	s.CreateUser("susy", "susy", false)
+
+	// Create a role, grant it privileges and add users to it.
+	results := s.ExecuteQuery(MustParseQuery(`CREATE ROLE readers; GRANT READ ON foo TO ROLE readers; GRANT WRITE ON foo../^app_/ TO ROLE readers; GRANT ROLE readers TO jdoe; GRANT ROLE readers TO susy`), "", nil)
+	if err := results.Error(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s.Restart()
+
+	// Verify the role was persisted and is resolved for its users.
+	if r := s.Role("readers"); r == nil || r.Privileges["foo"] != influxql.ReadPrivilege {
+		t.Fatalf("unexpected role: %s", mustMarshalJSON(r))
+	} else if u := s.User("jdoe"); !u.Authorize(influxql.ReadPrivilege, "foo") {
+		t.Fatal("expected read privilege through role")
+	} else if u.Authorize(influxql.WritePrivilege, "foo") {
+		t.Fatal("unexpected write privilege")
+	} else if !u.AuthorizeMeasurement(influxql.WritePrivilege, "foo", "default", "app_requests") {
+		t.Fatal("expected measurement write privilege through role")
+	}
+
+	// Verify the roles and grants are listed.
+	results = s.ExecuteQuery(MustParseQuery(`SHOW ROLES; SHOW GRANTS FOR jdoe`), "", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"columns":["role","users"],"values":[["readers","jdoe,susy"]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+	if res := results.Results[1]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"columns":["database","retentionPolicy","measurement","privilege","role"],"values":[["foo","","","READ","readers"],["foo","","/^app_/","WRITE","readers"]]}]}` {
+		t.Fatalf("unexpected row(1): %s", s)
+	}
+
+	// Roles that don't exist cannot be granted.
+	if err := s.GrantRole("writers", "jdoe"); err != influxdb.ErrRoleNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Revoking the role from a user removes its privileges.
+	results = s.ExecuteQuery(MustParseQuery(`REVOKE ROLE readers FROM susy`), "", nil)
+	if err := results.Error(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if s.User("susy").Authorize(influxql.ReadPrivilege, "foo") {
+		t.Fatal("unexpected read privilege after revoking role")
+	}
+
+	// Dropping the role removes it from all users.
+	results = s.ExecuteQuery(MustParseQuery(`DROP ROLE readers`), "", nil)
+	if err := results.Error(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s.Restart()
+
+	if s.Role("readers") != nil {
+		t.Fatal("expected role to be dropped")
+	} else if u := s.User("jdoe"); len(u.Roles) != 0 || u.Authorize(influxql.ReadPrivilege, "foo") {
+		t.Fatalf("unexpected user: %s", mustMarshalJSON(u))
+	}
+}
+
+// Ensure users can change passwords and admins can grant and revoke cluster admin.
+func TestServer_SetPasswordAndAlterUser(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateUser("admin", "admin", true)
+	s.CreateUser("jdoe", "jdoe", false)
+	s.CreateUser("susy", "susy", false)
+	jdoe := s.User("jdoe")
+
+	// Users can change their own password and view their own grants but not another's.
+	if err := s.Authorize(jdoe, MustParseQuery(`SET PASSWORD FOR jdoe = 'secret'; SHOW GRANTS FOR jdoe`), ""); err != nil {
+		t.Fatal(err)
+	} else if err := s.Authorize(jdoe, MustParseQuery(`SET PASSWORD FOR susy = 'secret'`), ""); err == nil {
+		t.Fatal("expected error setting another user's password")
+	} else if err := s.Authorize(jdoe, MustParseQuery(`SHOW GRANTS FOR susy`), ""); err == nil {
+		t.Fatal("expected error showing another user's grants")
+	} else if err := s.Authorize(jdoe, MustParseQuery(`ALTER USER jdoe WITH ADMIN`), ""); err == nil {
+		t.Fatal("expected error making self cluster admin")
+	}
+
+	// Change the password and grant cluster admin.
+	results := s.ExecuteQuery(MustParseQuery(`SET PASSWORD FOR jdoe = 'secret'; ALTER USER jdoe WITH ADMIN`), "", nil)
+	if err := results.Error(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s.Restart()
+
+	if _, err := s.Authenticate("jdoe", "secret"); err != nil {
+		t.Fatalf("unexpected error authenticating with new password: %s", err)
+	} else if _, err := s.Authenticate("jdoe", "jdoe"); err == nil {
+		t.Fatal("expected error authenticating with old password")
+	} else if !s.User("jdoe").Admin {
+		t.Fatal("expected jdoe to be cluster admin")
+	}
+
+	// Revoke cluster admin.
+	results = s.ExecuteQuery(MustParseQuery(`ALTER USER jdoe WITHOUT ADMIN`), "", nil)
+	if err := results.Error(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if s.User("jdoe").Admin {
+		t.Fatal("expected jdoe not to be cluster admin")
+	}
+
+	// Blank passwords and unknown users are rejected.
+	results = s.ExecuteQuery(MustParseQuery(`SET PASSWORD FOR jdoe = ''`), "", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrPasswordRequired {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+	results = s.ExecuteQuery(MustParseQuery(`ALTER USER nobody WITH ADMIN`), "", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrUserNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
 // Test single statement query authorization.
 func TestServer_SingleStatementQueryAuthorization(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
@@ -788,6 +1071,161 @@ func TestServer_EnforceRetentionPolices(t *testing.T) {
 	}
 }
 
+// Ensure the oldest shard groups are removed once a policy exceeds its maximum size.
+func TestServer_EnforceRetentionPolices_MaxSize(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "mypolicy", Duration: time.Hour})
+
+	// Create three consecutive shard groups that are not yet due to age out.
+	now := time.Now().UTC()
+	for i := 1; i <= 3; i++ {
+		if err := s.CreateShardGroupIfNotExists("foo", "mypolicy", now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Limit the policy to slightly more than the size of a single empty shard.
+	rp, _ := s.RetentionPolicy("foo", "mypolicy")
+	g, _ := s.ShardGroups("foo")
+	if len(g) != 3 {
+		t.Fatalf("expected 3 shard groups but found %d", len(g))
+	}
+	results := s.ExecuteQuery(MustParseQuery(`SHOW RETENTION POLICIES foo`), "foo", nil)
+	size := results.Results[0].Series[0].Values[0][4].(int64)
+	if size == 0 {
+		t.Fatal("expected non-zero policy size")
+	}
+	maxSize := size/3 + 1
+	if err := s.UpdateRetentionPolicy("foo", "mypolicy", &influxdb.RetentionPolicyUpdate{MaxSize: &maxSize}); err != nil {
+		t.Fatal(err)
+	} else if rp.MaxSize != maxSize {
+		t.Fatalf("unexpected max size: %d", rp.MaxSize)
+	}
+
+	// Run retention enforcement.
+	s.EnforceRetentionPolicies()
+	s.Restart()
+
+	// Only the newest shard group should remain.
+	g, err := s.ShardGroups("foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(g) != 1 {
+		t.Fatalf("expected 1 shard group but found %d", len(g))
+	} else if !g[0].EndTime.After(now.Add(3 * time.Hour)) {
+		t.Fatalf("unexpected shard group remaining: %s - %s", g[0].StartTime, g[0].EndTime)
+	}
+
+	// Verify the limit is reported and persisted.
+	results = s.ExecuteQuery(MustParseQuery(`SHOW RETENTION POLICIES foo`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if v := res.Series[0].Values[0]; v[3] != maxSize {
+		t.Fatalf("unexpected max size: %v", v)
+	}
+}
+
+// Ensure the oldest shard groups across all policies are removed once a database exceeds
+// its maximum size, and that each group is only deleted once.
+func TestServer_EnforceRetentionPolices_DatabaseMaxSize(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "p1", Duration: time.Hour})
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "p2", Duration: time.Hour})
+
+	// Create an expired group and interleaved groups in both policies.
+	now := time.Now().UTC()
+	for _, tt := range []struct {
+		policy string
+		offset time.Duration
+	}{{"p1", -3 * time.Hour}, {"p1", 1 * time.Hour}, {"p2", 2 * time.Hour}, {"p1", 3 * time.Hour}, {"p2", 4 * time.Hour}} {
+		if err := s.CreateShardGroupIfNotExists("foo", tt.policy, now.Add(tt.offset)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Determine the size of a single empty shard.
+	var total int64
+	results := s.ExecuteQuery(MustParseQuery(`SHOW RETENTION POLICIES foo`), "foo", nil)
+	for _, v := range results.Results[0].Series[0].Values {
+		total += v[4].(int64)
+	}
+	size := total / 5
+	if size == 0 {
+		t.Fatal("expected non-zero shard size")
+	}
+
+	// Limit the first policy to one group and the database to two groups.
+	maxSize := size + 1
+	if err := s.UpdateRetentionPolicy("foo", "p1", &influxdb.RetentionPolicyUpdate{MaxSize: &maxSize}); err != nil {
+		t.Fatal(err)
+	}
+	s.DatabaseMaxSizes = map[string]int64{"foo": 2*size + 1}
+
+	// Count the deletions requested.
+	var n int
+	c.PublishFunc = func(m *messaging.Message) (uint64, error) {
+		n++
+		return c.send(m)
+	}
+	s.EnforceRetentionPolicies()
+	if n != 3 {
+		t.Fatalf("unexpected deletion count: %d", n)
+	}
+
+	// Only the newest group of each policy should remain.
+	g, err := s.ShardGroups("foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(g) != 2 {
+		t.Fatalf("expected 2 shard groups but found %d", len(g))
+	}
+	for _, g := range g {
+		if !g.EndTime.After(now.Add(3 * time.Hour)) {
+			t.Fatalf("unexpected shard group remaining: %s - %s", g.StartTime, g.EndTime)
+		}
+	}
+}
+
+// Ensure sizes aren't enforced with multiple data nodes since each node only knows its own shards.
+func TestServer_EnforceRetentionPolices_DatabaseMaxSize_Clustered(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "p1", Duration: time.Hour, MaxSize: 1})
+	u, _ := url.Parse("http://localhost:8087")
+	if err := s.CreateDataNode(u); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	for _, offset := range []time.Duration{1 * time.Hour, 2 * time.Hour, 3 * time.Hour} {
+		if err := s.CreateShardGroupIfNotExists("foo", "p1", now.Add(offset)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.DatabaseMaxSizes = map[string]int64{"foo": 1}
+
+	// No deletions should be requested.
+	c.PublishFunc = func(m *messaging.Message) (uint64, error) {
+		t.Fatalf("unexpected message: %d", m.Type)
+		return 0, nil
+	}
+	s.EnforceRetentionPolicies()
+
+	if g, err := s.ShardGroups("foo"); err != nil {
+		t.Fatal(err)
+	} else if len(g) != 3 {
+		t.Fatalf("expected 3 shard groups but found %d", len(g))
+	}
+}
+
 // Ensure the database can write data to the database.
 func TestServer_WriteSeries(t *testing.T) {
 	c := NewMessagingClient()
@@ -1440,13 +1878,15 @@ func TestServer_RawDataReturnsInOrder(t *testing.T) {
 		t.Fatalf("unexpected error during GROUP BY *: %s", res.Err)
 	} else if len(res.Series) != 10 {
 		t.Fatalf("expected 10 series back but got %d", len(res.Series))
-	} else if len(res.Series[0].Values) != 50 {
-		t.Fatalf("expected 50 values per series but got %d", len(res.Series[0].Values))
+	} else if len(res.Series[0].Values) != 49 {
+		t.Fatalf("expected 49 values for server-0 but got %d", len(res.Series[0].Values))
+	} else if len(res.Series[1].Values) != 50 {
+		t.Fatalf("expected 50 values per series but got %d", len(res.Series[1].Values))
 	}
 }
 
-// Ensure that limit and offset work
-func TestServer_LimitAndOffset(t *testing.T) {
+// Ensure that series limit and offset work
+func TestServer_SeriesLimitAndOffset(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
 	defer s.Close()
 	s.CreateDatabase("foo")
@@ -1458,85 +1898,224 @@ func TestServer_LimitAndOffset(t *testing.T) {
 		s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east", "host": host}, Timestamp: time.Unix(int64(i), 0), Fields: map[string]interface{}{"value": float64(i)}}})
 	}
 
-	results := s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * LIMIT 20`), "foo", nil)
+	results := s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * SLIMIT 20`), "foo", nil)
 	if res := results.Results[0]; res.Err != nil {
 		t.Fatalf("unexpected error during COUNT: %s", res.Err)
 	} else if len(res.Series) != 9 {
 		t.Fatalf("unexpected 9 series back but got %d", len(res.Series))
 	}
 
-	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * LIMIT 2 OFFSET 1`), "foo", nil)
+	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * SLIMIT 2 SOFFSET 1`), "foo", nil)
 	if res := results.Results[0]; res.Err != nil {
 		t.Fatalf("unexpected error during COUNT: %s", res.Err)
 	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"host":"server-2","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-3","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}` {
 		t.Fatalf("unexpected row(0) during COUNT: %s", s)
 	}
 
-	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * LIMIT 2 OFFSET 3`), "foo", nil)
+	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * SLIMIT 2 SOFFSET 3`), "foo", nil)
 	if res := results.Results[0]; res.Err != nil {
 		t.Fatalf("unexpected error during COUNT: %s", res.Err)
-	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"host":"server-5","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-4","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}` {
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"host":"server-4","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-5","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}` {
 		t.Fatalf("unexpected row(0) during COUNT: %s", s)
 	}
 
-	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * LIMIT 3 OFFSET 8`), "foo", nil)
+	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * SLIMIT 3 SOFFSET 8`), "foo", nil)
 	if res := results.Results[0]; res.Err != nil {
 		t.Fatalf("unexpected error during COUNT: %s", res.Err)
 	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"host":"server-9","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}` {
 		t.Fatalf("unexpected row(0) during COUNT: %s", s)
 	}
 
-	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * LIMIT 3 OFFSET 20`), "foo", nil)
+	results = s.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu GROUP BY * SLIMIT 3 SOFFSET 20`), "foo", nil)
 	if res := results.Results[0]; res.Err != nil {
 		t.Fatalf("unexpected error during COUNT: %s", res.Err)
 	}
 }
 
-// Ensure the server can execute a wildcard query and return the data correctly.
-func TestServer_ExecuteWildcardQuery(t *testing.T) {
+// Ensure that point limit and offset are applied within each series.
+func TestServer_LimitAndOffset(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
 	defer s.Close()
 	s.CreateDatabase("foo")
 	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
 	s.SetDefaultRetentionPolicy("foo", "raw")
-	s.CreateUser("susy", "pass", false)
 
-	// Write series with one point to the database.
-	// We deliberatly write one value per insert as we need to create each field in a predicatable order for testing.
-	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(10)}}})
-	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Fields: map[string]interface{}{"val-x": 20}}})
-	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Fields: map[string]interface{}{"value": 30, "val-x": 40}}})
+	for i := 1; i < 5; i++ {
+		for _, host := range []string{"serverA", "serverB"} {
+			s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": host}, Timestamp: mustParseTime("2000-01-01T00:00:00Z").Add(time.Duration(i) * time.Minute), Fields: map[string]interface{}{"value": float64(i)}}})
+		}
+	}
 
-	// Select * (wildcard).
-	results := s.ExecuteQuery(MustParseQuery(`SELECT * FROM cpu`), "foo", nil)
+	// Aggregate query.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT sum(value) FROM cpu WHERE time >= '2000-01-01T00:01:00Z' AND time < '2000-01-01T00:05:00Z' GROUP BY time(1m), host LIMIT 2 OFFSET 1`), "foo", nil)
 	if res := results.Results[0]; res.Err != nil {
-		t.Fatalf("unexpected error during SELECT *: %s", res.Err)
-	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","columns":["time","value","val-x"],"values":[["2000-01-01T00:00:00Z",10,0],["2000-01-01T00:00:10Z",0,20],["2000-01-01T00:00:20Z",30,40]]}]}` {
-		t.Fatalf("unexpected results during SELECT *: %s", s)
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","sum"],"values":[["2000-01-01T00:02:00Z",2],["2000-01-01T00:03:00Z",3]]},{"name":"cpu","tags":{"host":"serverB"},"columns":["time","sum"],"values":[["2000-01-01T00:02:00Z",2],["2000-01-01T00:03:00Z",3]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Raw query.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value FROM cpu LIMIT 1 OFFSET 3`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:02:00Z",2]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Series and point limits combined.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT sum(value) FROM cpu WHERE time >= '2000-01-01T00:01:00Z' AND time < '2000-01-01T00:05:00Z' GROUP BY time(1m), host LIMIT 1 SLIMIT 1 SOFFSET 1`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","sum"],"values":[["2000-01-01T00:01:00Z",1]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
 	}
 }
 
-// Ensure the server can execute a wildcard GROUP BY
-func TestServer_ExecuteWildcardGroupBy(t *testing.T) {
-	s := OpenServer(NewMessagingClient())
+// Ensure the server can write the results of a SELECT ... INTO query.
+func TestServer_ExecuteQuery_SelectInto(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
 	defer s.Close()
 	s.CreateDatabase("foo")
 	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
 	s.SetDefaultRetentionPolicy("foo", "raw")
-	s.CreateUser("susy", "pass", false)
+	s.CreateDatabase("bar")
+	s.CreateRetentionPolicy("bar", &influxdb.RetentionPolicy{Name: "1m", Duration: 1 * time.Hour})
 
-	// Write series with one point to the database.
-	// We deliberatly write one value per insert as we need to create each field in a predicatable order for testing.
-	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(10)}}})
-	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Fields: map[string]interface{}{"value": 20}}})
-	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-west"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Fields: map[string]interface{}{"value": 30}}})
+	// Write series to the database.
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:30Z"), Fields: map[string]interface{}{"value": float64(30)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(50)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:01:00Z"), Fields: map[string]interface{}{"value": float64(70)}}})
 
-	// GROUP BY * (wildcard).
-	results := s.ExecuteQuery(MustParseQuery(`SELECT mean(value) FROM cpu GROUP BY *`), "foo", nil)
+	// Downsample into a measurement in the same database.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT mean(value) INTO cpu_1m FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:02:00Z' GROUP BY time(1m), host`), "foo", nil)
 	if res := results.Results[0]; res.Err != nil {
-		t.Fatalf("unexpected error during GROUP BY *: %s", res.Err)
-	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"region":"us-east"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",15]]},{"name":"cpu","tags":{"region":"us-west"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",30]]}]}` {
-		t.Fatalf("unexpected results during SELECT *: %s", s)
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"result","columns":["time","written"],"values":[["1970-01-01T00:00:00Z",3]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+	if err := s.Sync(c.index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	}
+
+	// Verify the points were written with their tags.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT mean FROM cpu_1m GROUP BY host`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu_1m","tags":{"host":"serverA"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",20]]},{"name":"cpu_1m","tags":{"host":"serverB"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",50],["2000-01-01T00:01:00Z",70]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Write into a fully qualified measurement in another database.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT max(value) INTO bar."1m".cpu_max FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:02:00Z' GROUP BY time(2m)`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"result","columns":["time","written"],"values":[["1970-01-01T00:00:00Z",1]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+	if err := s.Sync(c.index); err != nil {
+		t.Fatalf("sync error: %s", err)
+	}
+
+	results = s.ExecuteQuery(MustParseQuery(`SELECT max FROM "1m".cpu_max`), "bar", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu_max","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",70]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Writing into a missing database returns an error.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT value INTO baz."1m".cpu FROM cpu`), "foo", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrDatabaseNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure the server can execute an aggregate query over a subquery.
+func TestServer_ExecuteQuery_SubQuery(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+
+	// Write series to the database.
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:30Z"), Fields: map[string]interface{}{"value": float64(30)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(50)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:01:00Z"), Fields: map[string]interface{}{"value": float64(70)}}})
+
+	// Select the maximum of the per-host means.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT max(m) FROM (SELECT mean(value) AS m FROM cpu GROUP BY time(1m), host) GROUP BY time(1h)`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",70]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Group the outer statement by a tag from the subquery.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT min(m) FROM (SELECT mean(value) AS m FROM cpu GROUP BY time(1m), host) GROUP BY time(1h), host`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","min"],"values":[["2000-01-01T00:00:00Z",20]]},{"name":"cpu","tags":{"host":"serverB"},"columns":["time","min"],"values":[["2000-01-01T00:00:00Z",50]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// A subquery without rows has an empty result.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT max(m) FROM (SELECT mean(value) AS m FROM cpu WHERE time >= '2001-01-01T00:00:00Z' GROUP BY time(1m), host) GROUP BY time(1h)`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 0 {
+		t.Fatalf("unexpected row(0): %s", mustMarshalJSON(res))
+	}
+}
+
+// Ensure the server can execute a wildcard query and return the data correctly.
+func TestServer_ExecuteWildcardQuery(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.CreateUser("susy", "pass", false)
+
+	// Write series with one point to the database.
+	// We deliberatly write one value per insert as we need to create each field in a predicatable order for testing.
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Fields: map[string]interface{}{"val-x": 20}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Fields: map[string]interface{}{"value": 30, "val-x": 40}}})
+
+	// Select * (wildcard).
+	results := s.ExecuteQuery(MustParseQuery(`SELECT * FROM cpu`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error during SELECT *: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","columns":["time","value","val-x"],"values":[["2000-01-01T00:00:00Z",10,0],["2000-01-01T00:00:10Z",0,20],["2000-01-01T00:00:20Z",30,40]]}]}` {
+		t.Fatalf("unexpected results during SELECT *: %s", s)
+	}
+}
+
+// Ensure the server can execute a wildcard GROUP BY
+func TestServer_ExecuteWildcardGroupBy(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.CreateUser("susy", "pass", false)
+
+	// Write series with one point to the database.
+	// We deliberatly write one value per insert as we need to create each field in a predicatable order for testing.
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Fields: map[string]interface{}{"value": 20}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-west"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Fields: map[string]interface{}{"value": 30}}})
+
+	// GROUP BY * (wildcard).
+	results := s.ExecuteQuery(MustParseQuery(`SELECT mean(value) FROM cpu GROUP BY *`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error during GROUP BY *: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"region":"us-east"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",15]]},{"name":"cpu","tags":{"region":"us-west"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",30]]}]}` {
+		t.Fatalf("unexpected results during SELECT *: %s", s)
 	}
 
 	// GROUP BY * (wildcard) with time.
@@ -1548,6 +2127,35 @@ func TestServer_ExecuteWildcardGroupBy(t *testing.T) {
 	}
 }
 
+// Ensure the server can execute a GROUP BY on a regex of tag keys.
+func TestServer_ExecuteRegexGroupBy(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east", "host": "serverA"}, Timestamp: mustParseTime("2000-01-01T00:00:00Z"), Fields: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-east", "host": "serverB"}, Timestamp: mustParseTime("2000-01-01T00:00:10Z"), Fields: map[string]interface{}{"value": float64(20)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"region": "us-west", "host": "serverC"}, Timestamp: mustParseTime("2000-01-01T00:00:20Z"), Fields: map[string]interface{}{"value": float64(30)}}})
+
+	// GROUP BY /regex/ only groups by the matching tag keys.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT sum(value) FROM cpu GROUP BY /^reg/`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error during GROUP BY /regex/: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]},{"name":"cpu","tags":{"region":"us-west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]}]}` {
+		t.Fatalf("unexpected results during GROUP BY /regex/: %s", s)
+	}
+
+	// GROUP BY /regex/ with time and no matching tag keys.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT sum(value) FROM cpu GROUP BY time(1m), /^dc/`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error during GROUP BY /regex/: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",60]]}]}` {
+		t.Fatalf("unexpected results during GROUP BY /regex/: %s", s)
+	}
+}
+
 func TestServer_CreateShardGroupIfNotExist(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
 	defer s.Close()
@@ -1606,6 +2214,103 @@ func TestServer_DeleteShardGroup(t *testing.T) {
 	}
 }
 
+// Ensure the server can list shard groups and shards and drop individual shards.
+func TestServer_ShowShards(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: time.Hour})
+
+	// Write two points into the first hour and one into the second.
+	for _, ts := range []string{"2000-01-01T00:00:00Z", "2000-01-01T00:00:10Z", "2000-01-01T01:30:00Z"} {
+		index, err := s.WriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime(ts), Fields: map[string]interface{}{"value": float64(1)}}})
+		if err != nil {
+			t.Fatal(err)
+		} else if err = s.Sync(index); err != nil {
+			t.Fatalf("sync error: %s", err)
+		}
+	}
+
+	// Verify the shard groups are listed in time order.
+	results := s.ExecuteQuery(MustParseQuery(`SHOW SHARD GROUPS ON foo`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 1 || res.Series[0].Name != "foo" || len(res.Series[0].Values) != 2 {
+		t.Fatalf("unexpected results: %s", mustMarshalJSON(res))
+	} else if v := res.Series[0].Values[0]; v[1] != "raw" || v[2] != mustParseTime("2000-01-01T00:00:00Z") || v[3] != mustParseTime("2000-01-01T01:00:00Z") {
+		t.Fatalf("unexpected shard group: %s", mustMarshalJSON(v))
+	}
+
+	// Verify the shards and their point counts.
+	results = s.ExecuteQuery(MustParseQuery(`SHOW SHARDS`), "foo", nil)
+	res := results.Results[0]
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 1 || len(res.Series[0].Values) != 2 {
+		t.Fatalf("unexpected results: %s", mustMarshalJSON(res))
+	} else if v := res.Series[0].Values[0]; v[1] != "raw" || v[5].([]uint64)[0] != s.ID() || v[6].(int64) == 0 || v[7] != 2 {
+		t.Fatalf("unexpected shard: %s", mustMarshalJSON(v))
+	} else if v := res.Series[0].Values[1]; v[7] != 1 {
+		t.Fatalf("unexpected shard: %s", mustMarshalJSON(v))
+	}
+	id := res.Series[0].Values[0][0].(uint64)
+
+	// Drop the first shard. Its shard group is removed too.
+	results = s.ExecuteQuery(MustParseQuery(fmt.Sprintf(`DROP SHARD %d`, id)), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+	s.Restart()
+
+	if g, err := s.ShardGroups("foo"); err != nil {
+		t.Fatal(err)
+	} else if len(g) != 1 || g[0].StartTime != mustParseTime("2000-01-01T01:00:00Z") {
+		t.Fatalf("unexpected shard groups: %s", mustMarshalJSON(g))
+	}
+
+	// Dropping an unknown shard returns an error.
+	results = s.ExecuteQuery(MustParseQuery(fmt.Sprintf(`DROP SHARD %d`, id)), "foo", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrShardNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure a shard can't be dropped from a group with other shards.
+func TestServer_DropShard_MultipleShards(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	u, _ := url.Parse("http://localhost:8087")
+	if err := s.CreateDataNode(u); err != nil {
+		t.Fatal(err)
+	}
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: time.Hour, ReplicaN: 1})
+
+	// Create a group with a shard for each data node.
+	if err := s.CreateShardGroupIfNotExists("foo", "raw", mustParseTime("2000-01-01T00:00:00Z")); err != nil {
+		t.Fatal(err)
+	}
+	g, err := s.ShardGroups("foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(g) != 1 || len(g[0].Shards) != 2 {
+		t.Fatalf("unexpected shard groups: %s", mustMarshalJSON(g))
+	}
+
+	results := s.ExecuteQuery(MustParseQuery(fmt.Sprintf(`DROP SHARD %d`, g[0].Shards[0].ID)), "foo", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrShardGroupHasMultipleShards {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+
+	// The group and its shards are unchanged.
+	s.Restart()
+	if g, err := s.ShardGroups("foo"); err != nil {
+		t.Fatal(err)
+	} else if len(g) != 1 || len(g[0].Shards) != 2 {
+		t.Fatalf("unexpected shard groups: %s", mustMarshalJSON(g))
+	}
+}
+
 /* TODO(benbjohnson): Change test to not expose underlying series ids directly.
 func TestServer_Measurements(t *testing.T) {
 	s := OpenServer(NewMessagingClient())
@@ -1777,7 +2482,7 @@ func TestServer_CreateContinuousQuery(t *testing.T) {
 
 	// check again
 	queries = s.ContinuousQueries("foo")
-	if !reflect.DeepEqual(queries, expected) {
+	if mustMarshalJSON(expected) != mustMarshalJSON(queries) {
 		t.Fatalf("query not saved:\n\texp: %s\ngot: %s", mustMarshalJSON(expected), mustMarshalJSON(queries))
 	}
 }
@@ -1880,6 +2585,586 @@ func TestServer_RunContinuousQueries(t *testing.T) {
 	verify(3, `{"series":[{"name":"cpu_region","tags":{"region":"us-east"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",25]]},{"name":"cpu_region","tags":{"region":"us-west"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",75]]}]}`)
 }
 
+// Ensure continuous queries compute intervals missed since their last completed interval.
+func TestServer_RunContinuousQueries_CatchUp(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 24 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.RecomputePreviousN = 0
+	s.ComputeRunsPerInterval = 1
+	s.ComputeNoMoreThan = time.Millisecond
+	s.MaxCatchUpWindow = 3 * time.Hour
+
+	q := `CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`
+	if err := s.CreateContinuousQuery(MustParseQuery(q).Statements[0].(*influxql.CreateContinuousQueryStatement)); err != nil {
+		t.Fatalf("error creating continuous query %s", err.Error())
+	}
+
+	// Pretend the query last completed four hours ago.
+	startTime := time.Now().UTC().Truncate(time.Hour)
+	if err := s.SetContinuousQueryState("foo", "myquery", startTime.Add(-4*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write points into missed intervals, one of which is outside the catch-up window.
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime.Add(-210 * time.Minute), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime.Add(-150 * time.Minute), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime.Add(-90 * time.Minute), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime.Add(-80 * time.Minute), Fields: map[string]interface{}{"value": float64(1)}}})
+
+	// Run CQs and give them time to run.
+	if err := s.RunContinuousQueries(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Only the intervals within the catch-up window should have been computed.
+	results := s.ExecuteQuery(MustParseQuery(`SELECT count FROM cpu_count WHERE count > 0`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 1 {
+		t.Fatalf("unexpected row count: %d", len(res.Series))
+	} else if v := res.Series[0].Values; len(v) != 2 || v[0][0] != startTime.Add(-3*time.Hour) || v[1][0] != startTime.Add(-2*time.Hour) || v[1][1] != float64(2) {
+		t.Fatalf("unexpected values: %v", v)
+	}
+
+	// Ensure the last completed interval is persisted.
+	s.Restart()
+	if cqs := s.ContinuousQueries("foo"); len(cqs) != 1 {
+		t.Fatalf("unexpected continuous query count: %d", len(cqs))
+	} else if !cqs[0].LastCompleted.Equal(startTime) {
+		t.Fatalf("unexpected last completed interval: %s", cqs[0].LastCompleted)
+	}
+}
+
+// Ensure a continuous query's RESAMPLE clause overrides the server's recompute settings.
+func TestServer_RunContinuousQueries_Resample(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 24 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.RecomputePreviousN = 0
+	s.ComputeRunsPerInterval = 1
+	s.ComputeNoMoreThan = time.Millisecond
+
+	q := `CREATE CONTINUOUS QUERY myquery ON foo RESAMPLE EVERY 10s FOR 3h BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`
+	results := s.ExecuteQuery(MustParseQuery(q), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Write points into the current interval and the three before it.
+	startTime := time.Now().UTC().Truncate(time.Hour)
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime.Add(-150 * time.Minute), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime.Add(-90 * time.Minute), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime.Add(-30 * time.Minute), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime, Fields: map[string]interface{}{"value": float64(1)}}})
+
+	// Run CQs and give them time to run.
+	if err := s.RunContinuousQueries(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The current interval and the two before it should have been computed.
+	results = s.ExecuteQuery(MustParseQuery(`SELECT count FROM cpu_count WHERE count > 0`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 1 {
+		t.Fatalf("unexpected row count: %d", len(res.Series))
+	} else if v := res.Series[0].Values; len(v) != 3 || v[0][0] != startTime.Add(-2*time.Hour) || v[1][0] != startTime.Add(-time.Hour) || v[2][0] != startTime {
+		t.Fatalf("unexpected values: %v", v)
+	}
+
+	// Ensure the RESAMPLE clause is persisted and shown.
+	s.Restart()
+	results = s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERIES`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"foo","columns":["name","query"],"values":[["myquery","CREATE CONTINUOUS QUERY myquery ON foo RESAMPLE EVERY 10s FOR 3h BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END"]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+}
+
+// Ensure the server only runs the requested continuous queries when a subset is specified.
+func TestServer_RunContinuousQueries_Subset(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 24 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.RecomputePreviousN = 0
+	s.ComputeRunsPerInterval = 1
+	s.ComputeNoMoreThan = time.Millisecond
+
+	for _, q := range []string{
+		`CREATE CONTINUOUS QUERY cq_count ON foo BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`,
+		`CREATE CONTINUOUS QUERY cq_max ON foo BEGIN SELECT max(value) INTO cpu_max FROM cpu GROUP BY time(1h) END`,
+	} {
+		if err := s.CreateContinuousQuery(MustParseQuery(q).Statements[0].(*influxql.CreateContinuousQueryStatement)); err != nil {
+			t.Fatalf("error creating continuous query %s", err.Error())
+		}
+	}
+	if ids := s.ContinuousQueryIDs(); !reflect.DeepEqual(ids, []influxdb.ContinuousQueryID{{Database: "foo", Name: "cq_count"}, {Database: "foo", Name: "cq_max"}}) {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: time.Now().UTC(), Fields: map[string]interface{}{"value": float64(1)}}})
+
+	// Run only one of the CQs and give it time to run.
+	if err := s.RunContinuousQueries(influxdb.ContinuousQueryID{Database: "foo", Name: "cq_max"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	results := s.ExecuteQuery(MustParseQuery(`SHOW MEASUREMENTS`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"measurements","columns":["name"],"values":[["cpu"],["cpu_max"]]}]}` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+}
+
+// Ensure the server generates and maintains continuous queries for a downsample.
+func TestServer_Downsample(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 24 * time.Hour})
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "hourly", Duration: 7 * 24 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.RecomputePreviousN = 0
+	s.ComputeRunsPerInterval = 1
+	s.ComputeNoMoreThan = time.Millisecond
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: now, Fields: map[string]interface{}{"value": float64(10), "desc": "x"}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Timestamp: now.Add(time.Second), Fields: map[string]interface{}{"value": float64(20)}}})
+
+	results := s.ExecuteQuery(MustParseQuery(`CREATE DOWNSAMPLE ON foo FROM raw TO hourly AGGREGATE mean, max EVERY 1h`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+
+	// Creating the same downsample again should fail.
+	if err := s.CreateDownsample("foo", &influxdb.Downsample{SourceRetentionPolicy: "raw", TargetRetentionPolicy: "hourly", Aggregates: []string{"mean"}, Interval: time.Hour}); err != influxdb.ErrDownsampleExists {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := s.CreateDownsample("foo", &influxdb.Downsample{SourceRetentionPolicy: "raw", TargetRetentionPolicy: "raw", Aggregates: []string{"mean"}, Interval: time.Hour}); err != influxdb.ErrDownsampleSourceIsTarget {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A measurement created later should be downsampled as well.
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "mem", Timestamp: now, Fields: map[string]interface{}{"free": float64(100)}}})
+
+	exp := `{"series":[{"name":"foo","columns":["name","query"],"values":[` +
+		`["downsample_raw_hourly_cpu","CREATE CONTINUOUS QUERY downsample_raw_hourly_cpu ON foo BEGIN SELECT mean(value) AS mean_value, max(value) AS max_value INTO \"foo\".\"hourly\".\"cpu\" FROM \"foo\".\"raw\".\"cpu\" GROUP BY time(1h), * END"],` +
+		`["downsample_raw_hourly_mem","CREATE CONTINUOUS QUERY downsample_raw_hourly_mem ON foo BEGIN SELECT mean(free) AS mean_free, max(free) AS max_free INTO \"foo\".\"hourly\".\"mem\" FROM \"foo\".\"raw\".\"mem\" GROUP BY time(1h), * END"]]}]}`
+	results = s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERIES`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != exp {
+		t.Fatalf("unexpected result:\n\texp: %s\n\tgot: %s", exp, s)
+	}
+
+	// Run CQs and give them time to run.
+	if err := s.RunContinuousQueries(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	results = s.ExecuteQuery(MustParseQuery(`SELECT mean_value, max_value FROM "foo"."hourly".cpu GROUP BY host`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 1 || !reflect.DeepEqual(res.Series[0].Tags, map[string]string{"host": "serverA"}) {
+		t.Fatalf("unexpected series: %s", mustMarshalJSON(res))
+	} else if v := res.Series[0].Values; len(v) != 1 || v[0][1] != float64(15) || v[0][2] != float64(20) {
+		t.Fatalf("unexpected values: %v", v)
+	}
+
+	// The downsampled fields shouldn't be downsampled themselves, so the queries are unchanged.
+	s.Restart()
+	results = s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERIES`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != exp {
+		t.Fatalf("unexpected result:\n\texp: %s\n\tgot: %s", exp, s)
+	}
+	if a := s.Downsamples("foo"); len(a) != 1 || !reflect.DeepEqual(a[0].Aggregates, []string{"mean", "max"}) {
+		t.Fatalf("unexpected downsamples: %s", mustMarshalJSON(a))
+	}
+
+	// Dropping the downsample removes its queries.
+	results = s.ExecuteQuery(MustParseQuery(`DROP DOWNSAMPLE ON foo FROM raw TO hourly`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if cqs := s.ContinuousQueries("foo"); len(cqs) != 0 {
+		t.Fatalf("unexpected continuous query count: %d", len(cqs))
+	} else if err := s.DropDownsample("foo", "raw", "hourly"); err != influxdb.ErrDownsampleNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure downsamples work on databases that need quoting and their query names don't collide.
+func TestServer_Downsample_Names(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("my-db")
+	for _, name := range []string{"a_b", "c", "a", "b_c"} {
+		s.CreateRetentionPolicy("my-db", &influxdb.RetentionPolicy{Name: name, Duration: 24 * time.Hour})
+	}
+	s.SetDefaultRetentionPolicy("my-db", "a")
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	for _, rp := range []string{"a_b", "a"} {
+		s.MustWriteSeries("my-db", rp, []influxdb.Point{{Name: "cpu.load", Timestamp: now, Fields: map[string]interface{}{"value": float64(1)}}})
+		s.MustWriteSeries("my-db", rp, []influxdb.Point{{Name: "cpu_load", Timestamp: now, Fields: map[string]interface{}{"value": float64(1)}}})
+	}
+
+	if err := s.CreateDownsample("my-db", &influxdb.Downsample{SourceRetentionPolicy: "a_b", TargetRetentionPolicy: "c", Aggregates: []string{"mean"}, Interval: time.Hour}); err != nil {
+		t.Fatal(err)
+	} else if err := s.CreateDownsample("my-db", &influxdb.Downsample{SourceRetentionPolicy: "a", TargetRetentionPolicy: "b_c", Aggregates: []string{"mean"}, Interval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every source, target and measurement has its own query.
+	results := s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERIES`), "my-db", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 1 || len(res.Series[0].Values) != 4 {
+		t.Fatalf("unexpected rows: %s", mustMarshalJSON(res))
+	} else {
+		names := make(map[interface{}]bool)
+		for _, v := range res.Series[0].Values {
+			names[v[0]] = true
+		}
+		if len(names) != 4 {
+			t.Fatalf("duplicate query names: %s", mustMarshalJSON(res))
+		}
+	}
+}
+
+// Ensure downsamples can be chained so each tier downsamples the fields of the previous one.
+func TestServer_Downsample_Tiers(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 24 * time.Hour})
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "hourly", Duration: 7 * 24 * time.Hour})
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "daily", Duration: 30 * 24 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+
+	// Write raw fields and the fields generated by each tier.
+	now := time.Now().UTC().Truncate(time.Hour)
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: now, Fields: map[string]interface{}{"value": float64(10)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: now, Fields: map[string]interface{}{"load avg": float64(1)}}})
+	s.MustWriteSeries("foo", "hourly", []influxdb.Point{{Name: "cpu", Timestamp: now, Fields: map[string]interface{}{"mean_value": float64(10)}}})
+	s.MustWriteSeries("foo", "hourly", []influxdb.Point{{Name: "cpu", Timestamp: now, Fields: map[string]interface{}{"mean_load avg": float64(1)}}})
+	s.MustWriteSeries("foo", "daily", []influxdb.Point{{Name: "cpu", Timestamp: now, Fields: map[string]interface{}{"mean_mean_value": float64(10)}}})
+
+	results := s.ExecuteQuery(MustParseQuery(`CREATE DOWNSAMPLE ON foo FROM raw TO hourly AGGREGATE mean EVERY 1h; CREATE DOWNSAMPLE ON foo FROM hourly TO daily AGGREGATE mean EVERY 1d`), "foo", nil)
+	for _, res := range results.Results {
+		if res.Err != nil {
+			t.Fatalf("unexpected error: %s", res.Err)
+		}
+	}
+
+	exp := `{"series":[{"name":"foo","columns":["name","query"],"values":[` +
+		`["downsample_raw_hourly_cpu","CREATE CONTINUOUS QUERY downsample_raw_hourly_cpu ON foo BEGIN SELECT mean(value) AS mean_value, mean(\"load avg\") AS \"mean_load avg\" INTO \"foo\".\"hourly\".\"cpu\" FROM \"foo\".\"raw\".\"cpu\" GROUP BY time(1h), * END"],` +
+		`["downsample_hourly_daily_cpu","CREATE CONTINUOUS QUERY downsample_hourly_daily_cpu ON foo BEGIN SELECT mean(mean_value) AS mean_mean_value, mean(\"mean_load avg\") AS \"mean_mean_load avg\" INTO \"foo\".\"daily\".\"cpu\" FROM \"foo\".\"hourly\".\"cpu\" GROUP BY time(1d), * END"]]}]}`
+	results = s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERIES`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != exp {
+		t.Fatalf("unexpected result:\n\texp: %s\n\tgot: %s", exp, s)
+	}
+}
+
+// Ensure the server rejects writes that exceed the series limits of a database.
+func TestServer_WriteSeries_SeriesLimits(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.SeriesLimits = influxdb.SeriesLimits{MaxSeries: 3, MaxValuesPerTag: 2}
+	s.DatabaseSeriesLimits = map[string]influxdb.SeriesLimits{"bar": {}}
+	for _, name := range []string{"foo", "bar"} {
+		s.CreateDatabase(name)
+		s.CreateRetentionPolicy(name, &influxdb.RetentionPolicy{Name: "raw", Duration: time.Hour})
+		s.SetDefaultRetentionPolicy(name, "raw")
+	}
+
+	now := time.Now().UTC()
+	write := func(database, name string, tags map[string]string) error {
+		_, err := s.WriteSeries(database, "raw", []influxdb.Point{{Name: name, Tags: tags, Timestamp: now, Fields: map[string]interface{}{"value": float64(1)}}})
+		return err
+	}
+
+	// Write up to the limit of values for the "host" tag.
+	if err := write("foo", "cpu", map[string]string{"host": "serverA"}); err != nil {
+		t.Fatal(err)
+	} else if err := write("foo", "cpu", map[string]string{"host": "serverB"}); err != nil {
+		t.Fatal(err)
+	} else if err := write("foo", "cpu", map[string]string{"host": "serverC"}); err != (influxdb.ErrSeriesLimitExceeded{Database: "foo", Measurement: "cpu", TagKey: "host", Limit: 2}) {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err.Error() != "max values per tag exceeded: database=foo measurement=cpu tag=host limit=2" {
+		t.Fatalf("unexpected error text: %s", err)
+	}
+
+	// Write up to the limit of series for the database.
+	if err := write("foo", "mem", nil); err != nil {
+		t.Fatal(err)
+	} else if err := write("foo", "disk", nil); err != (influxdb.ErrSeriesLimitExceeded{Database: "foo", Limit: 3}) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Existing series can still be written to.
+	if err := write("foo", "cpu", map[string]string{"host": "serverA"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The limits are overridden for the "bar" database.
+	for _, host := range []string{"serverA", "serverB", "serverC", "serverD"} {
+		if err := write("bar", "cpu", map[string]string{"host": host}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Verify the cardinality of the "foo" database.
+	for i, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{q: `SHOW SERIES CARDINALITY`, exp: `{"series":[{"columns":["count"],"values":[[3]]}]}`},
+		{q: `SHOW SERIES CARDINALITY FROM cpu`, exp: `{"series":[{"columns":["count"],"values":[[2]]}]}`},
+		{q: `SHOW TAG VALUES CARDINALITY`, exp: `{"series":[{"name":"cpu","columns":["key","count"],"values":[["host",2]]}]}`},
+		{q: `SHOW TAG VALUES CARDINALITY FROM mem`, exp: `{}`},
+	} {
+		results := s.ExecuteQuery(MustParseQuery(tt.q), "foo", nil)
+		if res := results.Results[0]; res.Err != nil {
+			t.Fatalf("%d. unexpected error: %s", i, res.Err)
+		} else if s := mustMarshalJSON(res); s != tt.exp {
+			t.Fatalf("%d. %s: unexpected result:\n\texp: %s\n\tgot: %s", i, tt.q, tt.exp, s)
+		}
+	}
+}
+
+// Ensure the series limits are not checked when new series are applied, so every node
+// applies the same commands regardless of its own limits.
+func TestServer_WriteSeries_SeriesLimits_Apply(t *testing.T) {
+	c := NewMessagingClient()
+	s := OpenServer(c)
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: time.Now().UTC(), Fields: map[string]interface{}{"value": float64(1)}}})
+
+	// Lower the limit after the write is checked but before it is applied.
+	c.PublishFunc = func(m *messaging.Message) (uint64, error) {
+		s.SeriesLimits = influxdb.SeriesLimits{MaxSeries: 1}
+		return c.send(m)
+	}
+
+	if _, err := s.WriteSeries("foo", "raw", []influxdb.Point{{Name: "mem", Timestamp: time.Now().UTC(), Fields: map[string]interface{}{"value": float64(1)}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if a := s.MeasurementNames("foo"); len(a) != 2 {
+		t.Fatalf("unexpected measurements: %v", a)
+	}
+}
+
+// Ensure the server records execution statistics for continuous queries.
+func TestServer_ContinuousQueryStats(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 24 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.CreateDatabase("bar")
+	s.CreateRetentionPolicy("bar", &influxdb.RetentionPolicy{Name: "raw", Duration: 24 * time.Hour})
+	s.RecomputePreviousN = 0
+	s.ComputeRunsPerInterval = 1000000
+	s.ComputeNoMoreThan = time.Millisecond
+
+	q := `CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO "bar"."raw".cpu_count FROM cpu GROUP BY time(1h) END`
+	if err := s.CreateContinuousQuery(MustParseQuery(q).Statements[0].(*influxql.CreateContinuousQueryStatement)); err != nil {
+		t.Fatalf("error creating continuous query %s", err.Error())
+	}
+	startTime := time.Now().UTC().Truncate(time.Hour)
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: startTime, Fields: map[string]interface{}{"value": float64(1)}}})
+
+	// Run the CQ successfully.
+	s.RunContinuousQueries()
+	time.Sleep(50 * time.Millisecond)
+
+	// Run the CQ again after removing the target database.
+	s.DropDatabase("bar")
+	s.RunContinuousQueries()
+	time.Sleep(50 * time.Millisecond)
+
+	if a := s.ContinuousQueryStats(); len(a) != 1 {
+		t.Fatalf("unexpected stats count: %d", len(a))
+	} else if a[0].Database != "foo" || a[0].Name != "myquery" {
+		t.Fatalf("unexpected continuous query: %s.%s", a[0].Database, a[0].Name)
+	} else if a[0].Runs != 2 || a[0].Failures != 1 || a[0].PointsWritten != 1 {
+		t.Fatalf("unexpected counters: runs=%d, failures=%d, points=%d", a[0].Runs, a[0].Failures, a[0].PointsWritten)
+	} else if a[0].LastError != `database not found "bar"` {
+		t.Fatalf("unexpected last error: %s", a[0].LastError)
+	} else if !a[0].LastInterval.Equal(startTime) {
+		t.Fatalf("unexpected last interval: %s", a[0].LastInterval)
+	}
+
+	// Ensure the statistics can be queried.
+	results := s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERY STATS`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 1 || len(res.Series[0].Values) != 1 {
+		t.Fatalf("unexpected rows: %s", mustMarshalJSON(res))
+	} else if v := res.Series[0].Values[0]; v[0] != "myquery" || v[1] != int64(2) || v[2] != int64(1) || v[5] != int64(1) {
+		t.Fatalf("unexpected values: %v", v)
+	}
+
+	// Ensure users only see the queries of databases they can read.
+	s.CreateDatabase("baz")
+	s.CreateUser("susy", "pass", false)
+	s.SetPrivilege(influxql.ReadPrivilege, "susy", "baz")
+	results = s.ExecuteQuery(MustParseQuery(`SHOW CONTINUOUS QUERY STATS`), "baz", s.User("susy"))
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if len(res.Series) != 1 || res.Series[0].Name != "baz" || len(res.Series[0].Values) != 0 {
+		t.Fatalf("unexpected rows: %s", mustMarshalJSON(res))
+	}
+}
+
+// Ensure the server can backfill a continuous query over historical data.
+func TestServer_BackfillContinuousQuery(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+
+	q := `CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h) END`
+	if err := s.CreateContinuousQuery(MustParseQuery(q).Statements[0].(*influxql.CreateContinuousQueryStatement)); err != nil {
+		t.Fatalf("error creating continuous query %s", err.Error())
+	}
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:30:00Z"), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T01:30:00Z"), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T01:45:00Z"), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T03:10:00Z"), Fields: map[string]interface{}{"value": float64(1)}}})
+
+	// Backfill the continuous query.
+	results := s.ExecuteQuery(MustParseQuery(`BACKFILL CONTINUOUS QUERY myquery ON foo FROM '2000-01-01 00:00:00' TO '2000-01-01 04:00:00'`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"backfill","columns":["start","end","resumed_from","intervals","points_written"],"values":[["2000-01-01T00:00:00Z","2000-01-01T04:00:00Z","2000-01-01T00:00:00Z",4,3]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	results = s.ExecuteQuery(MustParseQuery(`SELECT count FROM cpu_count`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu_count","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T01:00:00Z",2],["2000-01-01T03:00:00Z",1]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Ensure the progress is cleared once the backfill has finished.
+	if b := s.ContinuousQueries("foo")[0].Backfill; b != nil {
+		t.Fatalf("unexpected backfill progress: %#v", b)
+	}
+
+	// Ensure the backfill isn't recorded in the statistics of the scheduled runs.
+	if a := s.ContinuousQueryStats(); len(a) != 1 || a[0].Runs != 0 || a[0].PointsWritten != 0 {
+		t.Fatalf("unexpected stats: %#v", a)
+	}
+
+	// Ensure an interrupted backfill is resumed.
+	if err := s.SetContinuousQueryBackfill("foo", "myquery", &influxdb.ContinuousQueryBackfill{
+		StartTime: mustParseTime("2000-01-01T00:00:00Z"),
+		EndTime:   mustParseTime("2000-01-01T04:00:00Z"),
+		Completed: mustParseTime("2000-01-01T03:00:00Z"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Restart()
+	results = s.ExecuteQuery(MustParseQuery(`BACKFILL CONTINUOUS QUERY myquery ON foo FROM '2000-01-01 00:00:00' TO '2000-01-01 04:00:00'`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"backfill","columns":["start","end","resumed_from","intervals","points_written"],"values":[["2000-01-01T00:00:00Z","2000-01-01T04:00:00Z","2000-01-01T03:00:00Z",1,1]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	// Ensure invalid backfills return errors.
+	results = s.ExecuteQuery(MustParseQuery(`BACKFILL CONTINUOUS QUERY no_such_query ON foo FROM '2000-01-01' TO '2000-01-02'`), "foo", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrContinuousQueryNotFound {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+	results = s.ExecuteQuery(MustParseQuery(`BACKFILL CONTINUOUS QUERY myquery ON foo FROM '2000-01-02' TO '2000-01-01'`), "foo", nil)
+	if res := results.Results[0]; res.Err != influxdb.ErrInvalidBackfillTimeRange {
+		t.Fatalf("unexpected error: %s", res.Err)
+	}
+}
+
+// Ensure a backfill computes the same intervals as the continuous query's GROUP BY.
+func TestServer_BackfillContinuousQuery_Offset(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+
+	q := `CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1h, 30m) END`
+	if err := s.CreateContinuousQuery(MustParseQuery(q).Statements[0].(*influxql.CreateContinuousQueryStatement)); err != nil {
+		t.Fatalf("error creating continuous query %s", err.Error())
+	}
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T00:40:00Z"), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T01:20:00Z"), Fields: map[string]interface{}{"value": float64(1)}}})
+	s.MustWriteSeries("foo", "raw", []influxdb.Point{{Name: "cpu", Timestamp: mustParseTime("2000-01-01T01:40:00Z"), Fields: map[string]interface{}{"value": float64(1)}}})
+
+	results := s.ExecuteQuery(MustParseQuery(`BACKFILL CONTINUOUS QUERY myquery ON foo FROM '2000-01-01 00:45:00' TO '2000-01-01 02:30:00'`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"backfill","columns":["start","end","resumed_from","intervals","points_written"],"values":[["2000-01-01T00:30:00Z","2000-01-01T02:30:00Z","2000-01-01T00:30:00Z",2,2]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+
+	results = s.ExecuteQuery(MustParseQuery(`SELECT count FROM cpu_count`), "foo", nil)
+	if res := results.Results[0]; res.Err != nil {
+		t.Fatalf("unexpected error: %s", res.Err)
+	} else if s := mustMarshalJSON(res); s != `{"series":[{"name":"cpu_count","columns":["time","count"],"values":[["2000-01-01T00:30:00Z",2],["2000-01-01T01:30:00Z",1]]}]}` {
+		t.Fatalf("unexpected row(0): %s", s)
+	}
+}
+
+// Ensure a backfill requires write privilege on the database the continuous query writes into.
+func TestServer_BackfillContinuousQuery_Authorize(t *testing.T) {
+	s := OpenServer(NewMessagingClient())
+	defer s.Close()
+	s.CreateDatabase("foo")
+	s.CreateRetentionPolicy("foo", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.SetDefaultRetentionPolicy("foo", "raw")
+	s.CreateDatabase("bar")
+	s.CreateRetentionPolicy("bar", &influxdb.RetentionPolicy{Name: "raw", Duration: 1 * time.Hour})
+	s.CreateUser("admin", "admin", true)
+	s.CreateUser("susy", "pass", false)
+	s.SetPrivilege(influxql.WritePrivilege, "susy", "foo")
+
+	q := `CREATE CONTINUOUS QUERY myquery ON foo BEGIN SELECT count(value) INTO "bar"."raw".cpu_count FROM cpu GROUP BY time(1h) END`
+	if err := s.CreateContinuousQuery(MustParseQuery(q).Statements[0].(*influxql.CreateContinuousQueryStatement)); err != nil {
+		t.Fatalf("error creating continuous query %s", err.Error())
+	}
+
+	backfill := MustParseQuery(`BACKFILL CONTINUOUS QUERY myquery ON foo FROM '2000-01-01' TO '2000-01-02'`)
+	if err := s.Authorize(s.User("susy"), backfill, "foo"); err == nil {
+		t.Fatal("expected authorization error")
+	}
+
+	s.SetPrivilege(influxql.WritePrivilege, "susy", "bar")
+	if err := s.Authorize(s.User("susy"), backfill, "foo"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 func mustMarshalJSON(v interface{}) string {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -45,6 +45,14 @@ func newShardGroup() *ShardGroup { return &ShardGroup{} }
 // Duration returns the duration between the shard group's start and end time.
 func (g *ShardGroup) Duration() time.Duration { return g.EndTime.Sub(g.StartTime) }
 
+// size returns the number of bytes used by the shards in the group stored on this server.
+func (g *ShardGroup) size() (n int64) {
+	for _, sh := range g.Shards {
+		n += sh.size()
+	}
+	return
+}
+
 // Contains return whether the shard group contains data for the time between min and max
 func (g *ShardGroup) Contains(min, max time.Time) bool {
 	return timeBetweenInclusive(g.StartTime, min, max) ||
@@ -100,6 +108,37 @@ func (s *Shard) close() error {
 	return s.store.Close()
 }
 
+// size returns the number of bytes used by the shard's store.
+// Returns zero if the shard is not stored on this server.
+func (s *Shard) size() (n int64) {
+	if s.store == nil {
+		return 0
+	}
+	_ = s.store.View(func(tx *bolt.Tx) error {
+		n = tx.Size()
+		return nil
+	})
+	return
+}
+
+// pointN returns the number of points stored in the shard.
+// Returns zero if the shard is not stored on this server.
+func (s *Shard) pointN() (n int) {
+	if s.store == nil {
+		return 0
+	}
+	_ = s.store.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			// Only series buckets, keyed by series id, contain points.
+			if len(name) == 4 {
+				n += b.Stats().KeyN
+			}
+			return nil
+		})
+	})
+	return
+}
+
 // HasDataNodeID return true if the data node owns the shard.
 func (s *Shard) HasDataNodeID(id uint64) bool {
 	for _, dataNodeID := range s.DataNodeIDs {
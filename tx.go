@@ -148,24 +148,24 @@ func (tx *tx) CreateIterators(stmt *influxql.SelectStatement) ([]influxql.Iterat
 		fieldNames = tx.fieldNames(stmt.Fields)
 	}
 
-	// limit the number of series in this query if they specified a limit
-	if stmt.Limit > 0 {
-		if stmt.Offset > len(tagSets) {
+	// limit the number of series in this query if they specified a series limit or offset
+	if stmt.SLimit > 0 || stmt.SOffset > 0 {
+		if stmt.SOffset >= len(tagSets) {
 			return nil, nil
 		}
 
-		limitSets := make(map[string]map[uint32]influxql.Expr)
-		orderedSets := make([]string, 0, len(tagSets))
-		for k, _ := range tagSets {
+		orderedSets := make(influxql.TagSets, 0, len(tagSets))
+		for k := range tagSets {
 			orderedSets = append(orderedSets, k)
 		}
-		sort.Strings(orderedSets)
+		sort.Sort(orderedSets)
 
-		if stmt.Offset+stmt.Limit > len(orderedSets) {
-			stmt.Limit = len(orderedSets) - stmt.Offset
+		sets := orderedSets[stmt.SOffset:]
+		if stmt.SLimit > 0 && stmt.SLimit < len(sets) {
+			sets = sets[:stmt.SLimit]
 		}
 
-		sets := orderedSets[stmt.Offset : stmt.Offset+stmt.Limit]
+		limitSets := make(map[string]map[uint32]influxql.Expr)
 		for _, s := range sets {
 			limitSets[s] = tagSets[s]
 		}
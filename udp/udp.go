@@ -20,12 +20,17 @@ type SeriesWriter interface {
 // UDPServer
 type UDPServer struct {
 	writer SeriesWriter
+
+	// Limits the rate of points written per database. Nothing is limited if nil.
+	RateLimiter *influxdb.RateLimiter
+	dropped     *influxdb.DroppedPoints
 }
 
 // NewUDPServer returns a new instance of a UDPServer
 func NewUDPServer(w SeriesWriter) *UDPServer {
 	u := UDPServer{
-		writer: w,
+		writer:  w,
+		dropped: influxdb.NewDroppedPoints("UDP "),
 	}
 	return &u
 }
@@ -67,6 +72,11 @@ func (u *UDPServer) ListenAndServe(iface string) error {
 				continue
 			}
 
+			if err := u.RateLimiter.AllowPoints("", bp.Database, len(points)); err != nil {
+				u.dropped.Add(len(points), err)
+				continue
+			}
+
 			if msgIndex, err := u.writer.WriteSeries(bp.Database, bp.RetentionPolicy, points); err != nil {
 				log.Printf("Server write failed. Message index was %d: %s", msgIndex, err)
 			}